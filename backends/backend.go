@@ -1,19 +1,241 @@
 package backends
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
 
 type User struct {
-	Key        string
-	Attributes map[string]string
+	Key string `json:"key"`
+
+	// Attributes are strings, float64s, bools, or json arrays and objects
+	// ([]any and map[string]any), so that backends can compare numbers as
+	// numbers, and check if a list contains a value.
+	Attributes map[string]any `json:"attributes"`
+
+	// PrivateAttributes are the names of attributes which can be used to
+	// evaluate flags, but shouldn't be stored by the backend, or written to
+	// traces
+	PrivateAttributes []string `json:"privateAttributes,omitempty"`
+
+	// Anonymous users are not stored by backends which keep a list of users
+	Anonymous bool `json:"anonymous,omitempty"`
+
+	// Contexts are kinds of context other than the user, such as a repository
+	// or an organisation.  Only backends which support multiple kinds of
+	// context use them, others only evaluate flags for the user.
+	Contexts []Context `json:"contexts,omitempty"`
+}
+
+type Context struct {
+	Kind       string         `json:"kind"`
+	Key        string         `json:"key"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+
+	PrivateAttributes []string `json:"privateAttributes,omitempty"`
 }
 
+// PublicAttributes returns the attributes which are not private, such as for
+// writing to traces
+func PublicAttributes(attrs map[string]any, private []string) map[string]any {
+	public := make(map[string]any, len(attrs))
+
+	for k, v := range attrs {
+		public[k] = v
+	}
+
+	for _, name := range private {
+		delete(public, name)
+	}
+
+	return public
+}
+
+// AttributeString converts an attribute or flag value to a string, for backends
+// which only support string attributes, or for environment variables.  Strings
+// are unquoted, and json values are compact json.
+func AttributeString(val any) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	}
+}
+
+// StringAttributes converts each attribute value with AttributeString
+func StringAttributes(attrs map[string]any) map[string]string {
+	m := make(map[string]string, len(attrs))
+
+	for k, v := range attrs {
+		m[k] = AttributeString(v)
+	}
+
+	return m
+}
+
+type FlagType string
+
+const (
+	TypeBool   FlagType = "bool"
+	TypeString FlagType = "string"
+	TypeNumber FlagType = "number"
+	TypeJSON   FlagType = "json"
+)
+
+// Flag is both the query and the result of a flag evaluation.  The Type
+// defaults to bool if not specified, matching the original boolean-only flags.
 type Flag struct {
-	Key          string `json:"key"`
-	DefaultValue bool   `json:"defaultValue"`
-	Value        bool   `json:"value"`
+	Key          string   `json:"key"`
+	Type         FlagType `json:"type,omitempty"`
+	DefaultValue any      `json:"defaultValue"`
+	Value        any      `json:"value"`
+
+	VariationIndex *int    `json:"variationIndex,omitempty"`
+	Reason         *Reason `json:"reason,omitempty"`
+
+	// Backend is the name of the backend which evaluated the flag, when the
+	// flag could have come from more than one, such as with `--backend chain`
+	Backend string `json:"backend,omitempty"`
+}
+
+func (f Flag) ValueType() FlagType {
+	if f.Type == "" {
+		return TypeBool
+	}
+
+	return f.Type
+}
+
+// IsOn is true only for a boolean flag which evaluated to `true`
+func (f Flag) IsOn() bool {
+	on, ok := f.Value.(bool)
+	return ok && on
 }
 
 type Backend interface {
+	// State evaluates the flag for the user, as the type specified by `flag.ValueType()`.
+	// If the flag cannot be evaluated, the value is set to the flag's default.
 	State(ctx context.Context, flag Flag, user User) (Flag, error)
 	Close(ctx context.Context) error
 }
+
+// AllFlagsBackend is implemented by backends which can evaluate every flag
+// they know about for a user.  The Type of each flag is taken from its value,
+// and there is no DefaultValue.
+type AllFlagsBackend interface {
+	AllFlags(ctx context.Context, user User) ([]Flag, error)
+}
+
+func ParseFlagType(val string) (FlagType, error) {
+	switch t := FlagType(val); t {
+	case TypeBool, TypeString, TypeNumber, TypeJSON:
+		return t, nil
+	default:
+		return "", fmt.Errorf("unsupported flag type: %s (must be one of bool, string, number, json)", val)
+	}
+}
+
+// ParseValue converts a value from the command line into the go type used
+// for a flag type: bool, string, float64 or any json value.
+func ParseValue(t FlagType, raw string) (any, error) {
+	switch t {
+	case TypeBool:
+		return strconv.ParseBool(raw)
+
+	case TypeString:
+		return raw, nil
+
+	case TypeNumber:
+		return strconv.ParseFloat(raw, 64)
+
+	case TypeJSON:
+		var val any
+		if err := json.Unmarshal([]byte(raw), &val); err != nil {
+			return nil, err
+		}
+		return val, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported flag type: %s", t)
+	}
+}
+
+// TypeOf returns the flag type for a value, where anything which is not a
+// bool, string, or float64 is json.
+func TypeOf(val any) FlagType {
+	switch val.(type) {
+	case bool:
+		return TypeBool
+	case string:
+		return TypeString
+	case float64:
+		return TypeNumber
+	default:
+		return TypeJSON
+	}
+}
+
+// IsType checks if a value can be used as a value for the flag type.  Any
+// value can be used for json flags.
+func IsType(t FlagType, val any) bool {
+	switch t {
+	case TypeBool:
+		_, ok := val.(bool)
+		return ok
+
+	case TypeString:
+		_, ok := val.(string)
+		return ok
+
+	case TypeNumber:
+		_, ok := val.(float64)
+		return ok
+
+	case TypeJSON:
+		return true
+
+	default:
+		return false
+	}
+}
+
+// EnvVarName converts a flag key into an environment variable name, by
+// uppercasing it and replacing anything which is not a letter or digit with
+// an underscore.  It is used by the env backend, and the env and exec commands,
+// so that they all agree on the names.
+func EnvVarName(prefix string, key string) string {
+	sb := strings.Builder{}
+	sb.WriteString(prefix)
+
+	for _, r := range key {
+		switch {
+		case r >= 'a' && r <= 'z':
+			sb.WriteRune(r - 'a' + 'A')
+		case (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'):
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune('_')
+		}
+	}
+
+	name := sb.String()
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+
+	return name
+}
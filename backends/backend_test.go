@@ -2,6 +2,7 @@ package backends
 
 import (
 	"encoding/json"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -23,3 +24,100 @@ func TestMarshaling(t *testing.T) {
 	assert.Equal(t, f.DefaultValue, plain["defaultValue"])
 	assert.Equal(t, f.Value, plain["value"])
 }
+
+func TestParseValue(t *testing.T) {
+
+	cases := []struct {
+		flagType FlagType
+		raw      string
+		expected any
+		err      bool
+	}{
+		{flagType: TypeBool, raw: "true", expected: true},
+		{flagType: TypeBool, raw: "nope", err: true},
+		{flagType: TypeString, raw: "blue-green", expected: "blue-green"},
+		{flagType: TypeString, raw: "", expected: ""},
+		{flagType: TypeNumber, raw: "17.5", expected: 17.5},
+		{flagType: TypeNumber, raw: "many", err: true},
+		{flagType: TypeJSON, raw: `{"retries": 3}`, expected: map[string]any{"retries": 3.0}},
+		{flagType: TypeJSON, raw: `"quoted"`, expected: "quoted"},
+		{flagType: TypeJSON, raw: `{`, err: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(string(tc.flagType)+" "+tc.raw, func(t *testing.T) {
+			val, err := ParseValue(tc.flagType, tc.raw)
+
+			if tc.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, val)
+			}
+		})
+	}
+}
+
+func TestParseFlagType(t *testing.T) {
+
+	flagType, err := ParseFlagType("number")
+	assert.NoError(t, err)
+	assert.Equal(t, TypeNumber, flagType)
+
+	_, err = ParseFlagType("integer")
+	assert.EqualError(t, err, "unsupported flag type: integer (must be one of bool, string, number, json)")
+}
+
+func TestPublicAttributes(t *testing.T) {
+
+	attrs := map[string]any{"email": "alice@example.com", "branch": "main"}
+
+	assert.Equal(t, map[string]any{"branch": "main"}, PublicAttributes(attrs, []string{"email", "missing"}))
+	assert.Equal(t, attrs, PublicAttributes(attrs, nil))
+	assert.Len(t, attrs, 2)
+}
+
+func TestAttributeString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input    any
+		expected string
+	}{
+		{input: true, expected: "true"},
+		{input: "blue-green", expected: "blue-green"},
+		{input: 4.0, expected: "4"},
+		{input: 0.25, expected: "0.25"},
+		{input: nil, expected: ""},
+		{input: map[string]any{"retries": 3.0}, expected: `{"retries":3}`},
+		{input: []any{"a", "b"}, expected: `["a","b"]`},
+	}
+
+	for i, tc := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			assert.Equal(t, tc.expected, AttributeString(tc.input))
+		})
+	}
+}
+
+func TestEnvVarName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		prefix   string
+		key      string
+		expected string
+	}{
+		{prefix: "FLAG_", key: "deploy-v2", expected: "FLAG_DEPLOY_V2"},
+		{prefix: "FLAG_", key: "some.flag name", expected: "FLAG_SOME_FLAG_NAME"},
+		{prefix: "", key: "MixedCase", expected: "MIXEDCASE"},
+		{prefix: "", key: "2fast", expected: "_2FAST"},
+		{prefix: "", key: "naïve", expected: "NA_VE"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.key, func(t *testing.T) {
+			assert.Equal(t, tc.expected, EnvVarName(tc.prefix, tc.key))
+		})
+	}
+}
@@ -0,0 +1,35 @@
+// Package backendtest provides backends for testing code which uses a
+// backend, such as commands and watchers
+package backendtest
+
+import (
+	"context"
+	"flagon/backends"
+	"sync"
+)
+
+// SequenceBackend returns each of its values in turn, repeating the last one,
+// and calling OnLast (if set) when the last value is reached
+type SequenceBackend struct {
+	lock   sync.Mutex
+	Values []any
+	OnLast func()
+}
+
+func (b *SequenceBackend) State(ctx context.Context, flag backends.Flag, user backends.User) (backends.Flag, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	flag.Value = b.Values[0]
+	if len(b.Values) > 1 {
+		b.Values = b.Values[1:]
+	} else if b.OnLast != nil {
+		b.OnLast()
+	}
+
+	return flag, nil
+}
+
+func (b *SequenceBackend) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,196 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"flagon/backends"
+	"flagon/tracing"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var tr = otel.Tracer("backend.chain")
+
+// Name is the value of `--backend` which selects the chain
+const Name = "chain"
+
+// ChainBackend tries each of its backends in order, until one evaluates the
+// flag without an error, and knows the flag exists.  The flag's Backend is
+// set to the name of the backend which answered.
+type ChainBackend struct {
+	links []link
+}
+
+type link struct {
+	name    string
+	backend backends.Backend
+}
+
+func CreateBackend(ctx context.Context, cfg ChainConfiguration, create backends.Factory) (*ChainBackend, error) {
+	ctx, span := tr.Start(ctx, "create_backend")
+	defer span.End()
+
+	span.SetAttributes(attribute.StringSlice("chain.backends", cfg.Backends))
+
+	if len(cfg.Backends) == 0 {
+		return nil, tracing.Errorf(span, "no backends specified for the chain, use --chain to list them")
+	}
+
+	for _, name := range cfg.Backends {
+		if name == Name {
+			return nil, tracing.Errorf(span, "a chain cannot contain itself")
+		}
+	}
+
+	links := make([]link, 0, len(cfg.Backends))
+	var firstErr error
+
+	for _, name := range cfg.Backends {
+		// an unavailable backend is skipped, so that the rest of the chain
+		// can still answer
+		backend, err := create(ctx, name)
+		if err != nil {
+			span.SetAttributes(attribute.String("chain."+name+".error", err.Error()))
+			if firstErr == nil {
+				firstErr = fmt.Errorf("unable to create the %s backend: %w", name, err)
+			}
+			continue
+		}
+
+		links = append(links, link{name: name, backend: backend})
+	}
+
+	if len(links) == 0 {
+		return nil, tracing.Error(span, firstErr)
+	}
+
+	return &ChainBackend{
+		links: links,
+	}, nil
+}
+
+func (cb *ChainBackend) Close(ctx context.Context) error {
+	ctx, span := tr.Start(ctx, "close")
+	defer span.End()
+
+	var firstErr error
+
+	for _, l := range cb.links {
+		if err := l.backend.Close(ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	if firstErr != nil {
+		return tracing.Error(span, firstErr)
+	}
+
+	return nil
+}
+
+func (cb *ChainBackend) State(ctx context.Context, flag backends.Flag, user backends.User) (backends.Flag, error) {
+	ctx, span := tr.Start(ctx, "state")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("flag.key", flag.Key))
+
+	// when nothing answers, the first error is more useful than a later
+	// backend not knowing the flag
+	var failed *backends.Flag
+	var failedErr error
+
+	for _, l := range cb.links {
+		result, err := l.backend.State(ctx, flag, user)
+		result.Backend = l.name
+
+		if err != nil {
+			span.SetAttributes(attribute.String("chain."+l.name+".error", err.Error()))
+			if failedErr == nil {
+				failed, failedErr = &result, err
+			}
+			continue
+		}
+
+		if isNotFound(result) {
+			span.SetAttributes(attribute.String("chain."+l.name+".reason", result.Reason.String()))
+			continue
+		}
+
+		span.SetAttributes(
+			attribute.String("chain.backend", l.name),
+			attribute.String("reason", result.Reason.String()),
+		)
+
+		return result, nil
+	}
+
+	flag.Value = flag.DefaultValue
+
+	if failedErr != nil {
+		span.SetAttributes(attribute.String("reason", failed.Reason.String()))
+		return *failed, tracing.Errorf(span, "no backend in the chain could evaluate %s: %w", flag.Key, failedErr)
+	}
+
+	flag.Reason = &backends.Reason{Kind: backends.ReasonError, ErrorKind: backends.ErrorFlagNotFound}
+	span.SetAttributes(attribute.String("reason", flag.Reason.String()))
+
+	return flag, nil
+}
+
+// AllFlags combines the flags of every backend which supports listing them,
+// where a flag from an earlier backend takes precedence over a later one.
+func (cb *ChainBackend) AllFlags(ctx context.Context, user backends.User) ([]backends.Flag, error) {
+	ctx, span := tr.Start(ctx, "all_flags")
+	defer span.End()
+
+	seen := map[string]bool{}
+	flags := []backends.Flag{}
+	answered := false
+
+	var firstErr error
+
+	for _, l := range cb.links {
+		all, ok := l.backend.(backends.AllFlagsBackend)
+		if !ok {
+			continue
+		}
+
+		current, err := all.AllFlags(ctx, user)
+		if err != nil {
+			span.SetAttributes(attribute.String("chain."+l.name+".error", err.Error()))
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		answered = true
+
+		for _, flag := range current {
+			if seen[flag.Key] || isNotFound(flag) {
+				continue
+			}
+
+			seen[flag.Key] = true
+			flag.Backend = l.name
+			flags = append(flags, flag)
+		}
+	}
+
+	if !answered {
+		if firstErr == nil {
+			firstErr = errors.New("none of the backends in the chain can list all flags")
+		}
+		return nil, tracing.Error(span, firstErr)
+	}
+
+	span.SetAttributes(attribute.Int("flags.count", len(flags)))
+
+	return flags, nil
+}
+
+func isNotFound(flag backends.Flag) bool {
+	return flag.Reason != nil && flag.Reason.Kind == backends.ReasonError && flag.Reason.ErrorKind == backends.ErrorFlagNotFound
+}
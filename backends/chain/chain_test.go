@@ -0,0 +1,178 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"flagon/backends"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// staticBackend knows a fixed set of flags, or fails every evaluation
+type staticBackend struct {
+	flags  map[string]any
+	err    error
+	closed bool
+}
+
+func (sb *staticBackend) State(ctx context.Context, flag backends.Flag, user backends.User) (backends.Flag, error) {
+	flag.Value = flag.DefaultValue
+
+	if sb.err != nil {
+		flag.Reason = &backends.Reason{Kind: backends.ReasonError, ErrorKind: "CLIENT_NOT_READY"}
+		return flag, sb.err
+	}
+
+	value, found := sb.flags[flag.Key]
+	if !found {
+		flag.Reason = &backends.Reason{Kind: backends.ReasonError, ErrorKind: backends.ErrorFlagNotFound}
+		return flag, nil
+	}
+
+	flag.Value = value
+	flag.Reason = &backends.Reason{Kind: backends.ReasonFallthrough}
+
+	return flag, nil
+}
+
+func (sb *staticBackend) AllFlags(ctx context.Context, user backends.User) ([]backends.Flag, error) {
+	if sb.err != nil {
+		return nil, sb.err
+	}
+
+	flags := []backends.Flag{}
+	for key, value := range sb.flags {
+		flags = append(flags, backends.Flag{Key: key, Type: backends.TypeOf(value), Value: value, Reason: &backends.Reason{Kind: backends.ReasonFallthrough}})
+	}
+
+	return flags, nil
+}
+
+func (sb *staticBackend) Close(ctx context.Context) error {
+	sb.closed = true
+	return nil
+}
+
+func factory(available map[string]*staticBackend) backends.Factory {
+	return func(ctx context.Context, name string) (backends.Backend, error) {
+		if b, found := available[name]; found {
+			return b, nil
+		}
+		return nil, errors.New("unreachable")
+	}
+}
+
+func TestState(t *testing.T) {
+
+	unreachable := &staticBackend{err: errors.New("client not ready")}
+	primary := &staticBackend{flags: map[string]any{"deploy": true}}
+	fallback := &staticBackend{flags: map[string]any{"deploy": false, "migrate": true}}
+
+	ctx := context.Background()
+	user := backends.User{Key: "alice"}
+
+	t.Run("first backend answers", func(t *testing.T) {
+		cb, err := CreateBackend(ctx, ChainConfiguration{Backends: []string{"primary", "fallback"}}, factory(map[string]*staticBackend{"primary": primary, "fallback": fallback}))
+		assert.NoError(t, err)
+
+		flag, err := cb.State(ctx, backends.Flag{Key: "deploy"}, user)
+		assert.NoError(t, err)
+		assert.Equal(t, true, flag.Value)
+		assert.Equal(t, "primary", flag.Backend)
+	})
+
+	t.Run("falls through when not found", func(t *testing.T) {
+		cb, _ := CreateBackend(ctx, ChainConfiguration{Backends: []string{"primary", "fallback"}}, factory(map[string]*staticBackend{"primary": primary, "fallback": fallback}))
+
+		flag, err := cb.State(ctx, backends.Flag{Key: "migrate"}, user)
+		assert.NoError(t, err)
+		assert.Equal(t, true, flag.Value)
+		assert.Equal(t, "fallback", flag.Backend)
+	})
+
+	t.Run("falls through on errors", func(t *testing.T) {
+		cb, _ := CreateBackend(ctx, ChainConfiguration{Backends: []string{"unreachable", "fallback"}}, factory(map[string]*staticBackend{"unreachable": unreachable, "fallback": fallback}))
+
+		flag, err := cb.State(ctx, backends.Flag{Key: "deploy", DefaultValue: true}, user)
+		assert.NoError(t, err)
+		assert.Equal(t, false, flag.Value)
+		assert.Equal(t, "fallback", flag.Backend)
+	})
+
+	t.Run("skips backends which cannot be created", func(t *testing.T) {
+		cb, err := CreateBackend(ctx, ChainConfiguration{Backends: []string{"missing", "fallback"}}, factory(map[string]*staticBackend{"fallback": fallback}))
+		assert.NoError(t, err)
+
+		flag, err := cb.State(ctx, backends.Flag{Key: "deploy", DefaultValue: true}, user)
+		assert.NoError(t, err)
+		assert.Equal(t, "fallback", flag.Backend)
+	})
+
+	t.Run("not found anywhere", func(t *testing.T) {
+		cb, _ := CreateBackend(ctx, ChainConfiguration{Backends: []string{"primary", "fallback"}}, factory(map[string]*staticBackend{"primary": primary, "fallback": fallback}))
+
+		flag, err := cb.State(ctx, backends.Flag{Key: "other", DefaultValue: true}, user)
+		assert.NoError(t, err)
+		assert.Equal(t, true, flag.Value)
+		assert.Equal(t, backends.ErrorFlagNotFound, flag.Reason.ErrorKind)
+		assert.Equal(t, "", flag.Backend)
+	})
+
+	t.Run("the first error is returned", func(t *testing.T) {
+		cb, _ := CreateBackend(ctx, ChainConfiguration{Backends: []string{"unreachable", "fallback"}}, factory(map[string]*staticBackend{"unreachable": unreachable, "fallback": fallback}))
+
+		flag, err := cb.State(ctx, backends.Flag{Key: "other", DefaultValue: true}, user)
+		assert.EqualError(t, err, "no backend in the chain could evaluate other: client not ready")
+		assert.Equal(t, true, flag.Value)
+		assert.Equal(t, "CLIENT_NOT_READY", flag.Reason.ErrorKind)
+		assert.Equal(t, "unreachable", flag.Backend)
+	})
+}
+
+func TestAllFlags(t *testing.T) {
+
+	ctx := context.Background()
+
+	cb, _ := CreateBackend(ctx, ChainConfiguration{Backends: []string{"unreachable", "primary", "fallback"}}, factory(map[string]*staticBackend{
+		"unreachable": {err: errors.New("client not ready")},
+		"primary":     {flags: map[string]any{"deploy": true}},
+		"fallback":    {flags: map[string]any{"deploy": false, "migrate": true}},
+	}))
+
+	flags, err := cb.AllFlags(ctx, backends.User{Key: "alice"})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []backends.Flag{
+		{Key: "deploy", Type: backends.TypeBool, Value: true, Reason: &backends.Reason{Kind: backends.ReasonFallthrough}, Backend: "primary"},
+		{Key: "migrate", Type: backends.TypeBool, Value: true, Reason: &backends.Reason{Kind: backends.ReasonFallthrough}, Backend: "fallback"},
+	}, flags)
+}
+
+func TestCreating(t *testing.T) {
+
+	ctx := context.Background()
+
+	t.Run("no backends", func(t *testing.T) {
+		_, err := CreateBackend(ctx, ChainConfiguration{}, factory(nil))
+		assert.ErrorContains(t, err, "use --chain")
+	})
+
+	t.Run("containing itself", func(t *testing.T) {
+		_, err := CreateBackend(ctx, ChainConfiguration{Backends: []string{"file", Name}}, factory(nil))
+		assert.EqualError(t, err, "a chain cannot contain itself")
+	})
+
+	t.Run("nothing can be created", func(t *testing.T) {
+		_, err := CreateBackend(ctx, ChainConfiguration{Backends: []string{"launchdarkly", "file"}}, factory(nil))
+		assert.EqualError(t, err, "unable to create the launchdarkly backend: unreachable")
+	})
+
+	t.Run("closes every backend", func(t *testing.T) {
+		first, second := &staticBackend{}, &staticBackend{}
+		cb, _ := CreateBackend(ctx, ChainConfiguration{Backends: []string{"first", "second"}}, factory(map[string]*staticBackend{"first": first, "second": second}))
+
+		assert.NoError(t, cb.Close(ctx))
+		assert.True(t, first.closed)
+		assert.True(t, second.closed)
+	})
+}
@@ -0,0 +1,47 @@
+package chain
+
+import (
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+const BackendsEnvVar = "FLAGON_CHAIN"
+
+type ChainConfiguration struct {
+	Backends []string
+}
+
+func (cfg *ChainConfiguration) OverrideFrom(other ChainConfiguration) {
+	if len(other.Backends) > 0 {
+		cfg.Backends = other.Backends
+	}
+}
+
+func (cfg *ChainConfiguration) Flags() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("Chain Backend", pflag.ContinueOnError)
+
+	flags.StringSliceVar(&cfg.Backends, "chain", nil, "the backends to try in order when using --backend chain, for example launchdarkly,file")
+
+	return flags
+}
+
+func ConfigFromEnvironment() ChainConfiguration {
+
+	cfg := ChainConfiguration{}
+
+	if val := os.Getenv(BackendsEnvVar); val != "" {
+		for _, name := range strings.Split(val, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				cfg.Backends = append(cfg.Backends, name)
+			}
+		}
+	}
+
+	return cfg
+}
+
+func DefaultConfig() ChainConfiguration {
+	return ChainConfiguration{}
+}
@@ -0,0 +1,43 @@
+package chain
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadEnvironment(t *testing.T) {
+
+	os.Setenv(BackendsEnvVar, "launchdarkly, file")
+
+	cfg := ConfigFromEnvironment()
+
+	assert.Equal(t, []string{"launchdarkly", "file"}, cfg.Backends)
+}
+
+func TestFlags(t *testing.T) {
+
+	cfg := ChainConfiguration{}
+	flags := cfg.Flags()
+
+	assert.NoError(t, flags.Parse([]string{
+		"--chain", "unleash,env",
+		"--chain", "file",
+	}))
+
+	assert.Equal(t, []string{"unleash", "env", "file"}, cfg.Backends)
+}
+
+func TestOverridingValues(t *testing.T) {
+
+	base := DefaultConfig()
+	base.OverrideFrom(ChainConfiguration{Backends: []string{"launchdarkly", "file"}})
+	assert.Equal(t, []string{"launchdarkly", "file"}, base.Backends)
+
+	base.OverrideFrom(ChainConfiguration{})
+	assert.Equal(t, []string{"launchdarkly", "file"}, base.Backends)
+
+	base.OverrideFrom(ChainConfiguration{Backends: []string{"env"}})
+	assert.Equal(t, []string{"env"}, base.Backends)
+}
@@ -0,0 +1,18 @@
+package chain
+
+import (
+	"context"
+	"flagon/backends"
+)
+
+func init() {
+	backends.Register(Name, "Chain Backend", DefaultConfig, ConfigFromEnvironment,
+		func(ctx context.Context, cfg ChainConfiguration) (backends.Backend, error) {
+			backend, err := CreateBackend(ctx, cfg, backends.CreateNamed)
+			if err != nil {
+				return nil, err
+			}
+			return backend, nil
+		},
+	)
+}
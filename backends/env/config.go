@@ -0,0 +1,51 @@
+package env
+
+import (
+	"os"
+
+	"github.com/spf13/pflag"
+)
+
+const (
+	PrefixEnvVar  = "FLAGON_ENV_PREFIX"
+	TargetsEnvVar = "FLAGON_ENV_TARGETS"
+)
+
+type EnvConfiguration struct {
+	Prefix  string
+	Targets string
+}
+
+func (cfg *EnvConfiguration) OverrideFrom(other EnvConfiguration) {
+	if other.Prefix != "" {
+		cfg.Prefix = other.Prefix
+	}
+
+	if other.Targets != "" {
+		cfg.Targets = other.Targets
+	}
+}
+
+func (cfg *EnvConfiguration) Flags() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("Env Backend", pflag.ContinueOnError)
+
+	flags.StringVar(&cfg.Prefix, "env-prefix", "", "the prefix of the environment variables which hold flag values")
+	flags.StringVar(&cfg.Targets, "env-targets", "", "json of per-user values, in the form {\"flagKey\": {\"userKey\": value}}")
+
+	return flags
+}
+
+func ConfigFromEnvironment() EnvConfiguration {
+
+	cfg := EnvConfiguration{}
+	cfg.Prefix = os.Getenv(PrefixEnvVar)
+	cfg.Targets = os.Getenv(TargetsEnvVar)
+
+	return cfg
+}
+
+func DefaultConfig() EnvConfiguration {
+	return EnvConfiguration{
+		Prefix: "FLAGON_ENV_FLAG_",
+	}
+}
@@ -0,0 +1,45 @@
+package env
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadEnvironment(t *testing.T) {
+
+	os.Setenv(PrefixEnvVar, "CI_FLAG_")
+	os.Setenv(TargetsEnvVar, `{"deploy": {"alice": true}}`)
+
+	cfg := ConfigFromEnvironment()
+
+	assert.Equal(t, "CI_FLAG_", cfg.Prefix)
+	assert.Equal(t, `{"deploy": {"alice": true}}`, cfg.Targets)
+}
+
+func TestFlags(t *testing.T) {
+
+	cfg := EnvConfiguration{}
+	flags := cfg.Flags()
+
+	assert.NoError(t, flags.Parse([]string{
+		"--env-prefix", "LOCAL_",
+		"--env-targets", `{"deploy": {"bob": false}}`,
+	}))
+
+	assert.Equal(t, "LOCAL_", cfg.Prefix)
+	assert.Equal(t, `{"deploy": {"bob": false}}`, cfg.Targets)
+}
+
+func TestOverridingValues(t *testing.T) {
+
+	base := DefaultConfig()
+	base.OverrideFrom(EnvConfiguration{})
+	assert.Equal(t, "FLAGON_ENV_FLAG_", base.Prefix)
+	assert.Equal(t, "", base.Targets)
+
+	base.OverrideFrom(EnvConfiguration{Prefix: "OTHER_", Targets: "{}"})
+	assert.Equal(t, "OTHER_", base.Prefix)
+	assert.Equal(t, "{}", base.Targets)
+}
@@ -0,0 +1,154 @@
+package env
+
+import (
+	"context"
+	"encoding/json"
+	"flagon/backends"
+	"flagon/tracing"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var tr = otel.Tracer("backend.env")
+
+// EnvBackend reads flag values from environment variables, named by the
+// prefix and the flag key, such as `FLAGON_ENV_FLAG_CI_REPLACEMENT_DEPLOY`.
+// Targets take precedence, and hold values for specific users.
+type EnvBackend struct {
+	prefix    string
+	variables map[string]string
+	targets   map[string]map[string]any
+}
+
+func CreateBackend(ctx context.Context, cfg EnvConfiguration) (*EnvBackend, error) {
+	ctx, span := tr.Start(ctx, "create_backend")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("env.prefix", cfg.Prefix))
+
+	targets := map[string]map[string]any{}
+	if cfg.Targets != "" {
+		if err := json.Unmarshal([]byte(cfg.Targets), &targets); err != nil {
+			return nil, tracing.Errorf(span, "error reading the env targets: %w", err)
+		}
+	}
+
+	variables := map[string]string{}
+	for _, pair := range os.Environ() {
+		name, value, _ := strings.Cut(pair, "=")
+
+		if cfg.Prefix != "" && strings.HasPrefix(name, cfg.Prefix) && len(name) > len(cfg.Prefix) {
+			variables[name] = value
+		}
+	}
+
+	span.SetAttributes(
+		attribute.Int("env.variables", len(variables)),
+		attribute.Int("env.targets", len(targets)),
+	)
+
+	return &EnvBackend{
+		prefix:    cfg.Prefix,
+		variables: variables,
+		targets:   targets,
+	}, nil
+}
+
+func (eb *EnvBackend) Close(ctx context.Context) error {
+	return nil
+}
+
+func (eb *EnvBackend) State(ctx context.Context, flag backends.Flag, user backends.User) (backends.Flag, error) {
+	ctx, span := tr.Start(ctx, "state")
+	defer span.End()
+
+	name := backends.EnvVarName(eb.prefix, flag.Key)
+
+	span.SetAttributes(
+		attribute.String("flag.key", flag.Key),
+		attribute.String("env.variable", name),
+	)
+
+	flag.Value = flag.DefaultValue
+
+	if value, found := eb.targets[flag.Key][user.Key]; found {
+		if value != nil && !backends.IsType(flag.ValueType(), value) {
+			flag.Reason = &backends.Reason{Kind: backends.ReasonError, ErrorKind: backends.ErrorWrongType}
+			span.SetAttributes(attribute.String("reason", flag.Reason.String()))
+			return flag, tracing.Errorf(span, "flag %s targets a %T value at %s, which cannot be used as a %s", flag.Key, value, user.Key, flag.ValueType())
+		}
+
+		flag.Reason = &backends.Reason{Kind: backends.ReasonTargetMatch}
+		span.SetAttributes(attribute.String("reason", flag.Reason.String()))
+
+		if value != nil {
+			flag.Value = value
+		}
+
+		return flag, nil
+	}
+
+	raw, found := eb.variables[name]
+	if !found {
+		flag.Reason = &backends.Reason{Kind: backends.ReasonError, ErrorKind: backends.ErrorFlagNotFound}
+		span.SetAttributes(attribute.String("reason", flag.Reason.String()))
+		return flag, nil
+	}
+
+	value, err := backends.ParseValue(flag.ValueType(), raw)
+	if err != nil {
+		flag.Reason = &backends.Reason{Kind: backends.ReasonError, ErrorKind: backends.ErrorWrongType}
+		span.SetAttributes(attribute.String("reason", flag.Reason.String()))
+		return flag, tracing.Errorf(span, "unable to parse %s as a %s: %w", name, flag.ValueType(), err)
+	}
+
+	flag.Value = value
+	flag.Reason = &backends.Reason{Kind: backends.ReasonFallthrough}
+	span.SetAttributes(attribute.String("reason", flag.Reason.String()))
+
+	return flag, nil
+}
+
+// AllFlags returns every flag with a variable or a target.  As variable
+// names are upper cased, flags only set by a variable have a key made from
+// the lower cased name, with `_` replaced by `-`.
+func (eb *EnvBackend) AllFlags(ctx context.Context, user backends.User) ([]backends.Flag, error) {
+	ctx, span := tr.Start(ctx, "all_flags")
+	defer span.End()
+
+	keys := map[string]string{}
+
+	for name := range eb.variables {
+		keys[name] = strings.ReplaceAll(strings.ToLower(strings.TrimPrefix(name, eb.prefix)), "_", "-")
+	}
+
+	for key := range eb.targets {
+		keys[backends.EnvVarName(eb.prefix, key)] = key
+	}
+
+	flags := make([]backends.Flag, 0, len(keys))
+
+	for _, key := range keys {
+		flag, err := eb.State(ctx, backends.Flag{Key: key, Type: backends.TypeJSON}, user)
+		if err != nil {
+			// a variable which is not json is a string flag
+			if flag, err = eb.State(ctx, backends.Flag{Key: key, Type: backends.TypeString}, user); err != nil {
+				return nil, tracing.Error(span, err)
+			}
+		}
+
+		if flag.Reason.Kind == backends.ReasonError {
+			continue
+		}
+
+		flag.Type = backends.TypeOf(flag.Value)
+		flags = append(flags, flag)
+	}
+
+	span.SetAttributes(attribute.Int("flags.count", len(flags)))
+
+	return flags, nil
+}
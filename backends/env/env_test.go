@@ -0,0 +1,95 @@
+package env
+
+import (
+	"context"
+	"flagon/backends"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createBackend(t *testing.T, targets string, variables map[string]string) *EnvBackend {
+	for name, value := range variables {
+		t.Setenv(name, value)
+	}
+
+	eb, err := CreateBackend(context.Background(), EnvConfiguration{Prefix: "TEST_FLAG_", Targets: targets})
+	assert.NoError(t, err)
+
+	return eb
+}
+
+func TestState(t *testing.T) {
+
+	eb := createBackend(t, `{"ci-replacement-deploy": {"alice": false}, "deploy-strategy": {"bob": {"retries": 3}}}`, map[string]string{
+		"TEST_FLAG_CI_REPLACEMENT_DEPLOY": "true",
+		"TEST_FLAG_DEPLOY_STRATEGY":       "blue-green",
+		"TEST_FLAG_RETRIES":               "3",
+	})
+	ctx := context.Background()
+
+	t.Run("variable", func(t *testing.T) {
+		flag, err := eb.State(ctx, backends.Flag{Key: "ci-replacement-deploy", DefaultValue: false}, backends.User{Key: "bob"})
+		assert.NoError(t, err)
+		assert.Equal(t, true, flag.Value)
+		assert.Equal(t, backends.ReasonFallthrough, flag.Reason.Kind)
+	})
+
+	t.Run("target", func(t *testing.T) {
+		flag, err := eb.State(ctx, backends.Flag{Key: "ci-replacement-deploy", DefaultValue: true}, backends.User{Key: "alice"})
+		assert.NoError(t, err)
+		assert.Equal(t, false, flag.Value)
+		assert.Equal(t, backends.ReasonTargetMatch, flag.Reason.Kind)
+	})
+
+	t.Run("number variable", func(t *testing.T) {
+		flag, err := eb.State(ctx, backends.Flag{Key: "retries", Type: backends.TypeNumber}, backends.User{Key: "alice"})
+		assert.NoError(t, err)
+		assert.Equal(t, 3.0, flag.Value)
+	})
+
+	t.Run("variable of the wrong type", func(t *testing.T) {
+		flag, err := eb.State(ctx, backends.Flag{Key: "deploy-strategy", DefaultValue: true}, backends.User{Key: "alice"})
+		assert.Error(t, err)
+		assert.Equal(t, true, flag.Value)
+		assert.Equal(t, backends.ErrorWrongType, flag.Reason.ErrorKind)
+	})
+
+	t.Run("target of the wrong type", func(t *testing.T) {
+		flag, err := eb.State(ctx, backends.Flag{Key: "deploy-strategy", Type: backends.TypeString, DefaultValue: "rolling"}, backends.User{Key: "bob"})
+		assert.Error(t, err)
+		assert.Equal(t, "rolling", flag.Value)
+		assert.Equal(t, backends.ErrorWrongType, flag.Reason.ErrorKind)
+	})
+
+	t.Run("missing flag", func(t *testing.T) {
+		flag, err := eb.State(ctx, backends.Flag{Key: "missing", DefaultValue: true}, backends.User{Key: "alice"})
+		assert.NoError(t, err)
+		assert.Equal(t, true, flag.Value)
+		assert.Equal(t, backends.ErrorFlagNotFound, flag.Reason.ErrorKind)
+	})
+}
+
+func TestAllFlags(t *testing.T) {
+
+	eb := createBackend(t, `{"deploy-strategy": {"bob": {"retries": 3}}, "only_targeted": {"alice": true}}`, map[string]string{
+		"TEST_FLAG_CI_REPLACEMENT_DEPLOY": "true",
+		"TEST_FLAG_DEPLOY_STRATEGY":       "blue-green",
+	})
+
+	flags, err := eb.AllFlags(context.Background(), backends.User{Key: "bob"})
+	assert.NoError(t, err)
+
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Key < flags[j].Key })
+
+	assert.Equal(t, []backends.Flag{
+		{Key: "ci-replacement-deploy", Type: backends.TypeBool, Value: true, Reason: &backends.Reason{Kind: backends.ReasonFallthrough}},
+		{Key: "deploy-strategy", Type: backends.TypeJSON, Value: map[string]any{"retries": 3.0}, Reason: &backends.Reason{Kind: backends.ReasonTargetMatch}},
+	}, flags)
+}
+
+func TestInvalidTargets(t *testing.T) {
+	_, err := CreateBackend(context.Background(), EnvConfiguration{Prefix: "TEST_FLAG_", Targets: "{not json"})
+	assert.ErrorContains(t, err, "error reading the env targets")
+}
@@ -0,0 +1,18 @@
+package env
+
+import (
+	"context"
+	"flagon/backends"
+)
+
+func init() {
+	backends.Register("env", "Env Backend", DefaultConfig, ConfigFromEnvironment,
+		func(ctx context.Context, cfg EnvConfiguration) (backends.Backend, error) {
+			backend, err := CreateBackend(ctx, cfg)
+			if err != nil {
+				return nil, err
+			}
+			return backend, nil
+		},
+	)
+}
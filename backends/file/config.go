@@ -0,0 +1,41 @@
+package file
+
+import (
+	"os"
+
+	"github.com/spf13/pflag"
+)
+
+const PathEnvVar = "FLAGON_FILE_PATH"
+
+type FileConfiguration struct {
+	Path string
+}
+
+func (cfg *FileConfiguration) OverrideFrom(other FileConfiguration) {
+	if other.Path != "" {
+		cfg.Path = other.Path
+	}
+}
+
+func (cfg *FileConfiguration) Flags() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("File Backend", pflag.ContinueOnError)
+
+	flags.StringVar(&cfg.Path, "file-path", "", "the yaml or json file to read flag definitions from")
+
+	return flags
+}
+
+func ConfigFromEnvironment() FileConfiguration {
+
+	cfg := FileConfiguration{}
+	cfg.Path = os.Getenv(PathEnvVar)
+
+	return cfg
+}
+
+func DefaultConfig() FileConfiguration {
+	return FileConfiguration{
+		Path: "flagon.flags.yaml",
+	}
+}
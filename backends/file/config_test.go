@@ -0,0 +1,39 @@
+package file
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadEnvironment(t *testing.T) {
+
+	os.Setenv(PathEnvVar, "some/flags.json")
+
+	cfg := ConfigFromEnvironment()
+
+	assert.Equal(t, "some/flags.json", cfg.Path)
+}
+
+func TestFlags(t *testing.T) {
+
+	cfg := FileConfiguration{}
+	flags := cfg.Flags()
+
+	assert.NoError(t, flags.Parse([]string{
+		"--file-path", "other/flags.yaml",
+	}))
+
+	assert.Equal(t, "other/flags.yaml", cfg.Path)
+}
+
+func TestOverridingValues(t *testing.T) {
+
+	base := DefaultConfig()
+	base.OverrideFrom(FileConfiguration{})
+	assert.Equal(t, "flagon.flags.yaml", base.Path)
+
+	base.OverrideFrom(FileConfiguration{Path: "override.yaml"})
+	assert.Equal(t, "override.yaml", base.Path)
+}
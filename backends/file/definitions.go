@@ -0,0 +1,157 @@
+package file
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/launchdarkly/go-semver"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	OpEquals            = "equals"
+	OpIn                = "in"
+	OpMatches           = "matches"
+	OpSemverEqual       = "semverEqual"
+	OpSemverLessThan    = "semverLessThan"
+	OpSemverGreaterThan = "semverGreaterThan"
+)
+
+// the attribute name which refers to the user's key rather than one of
+// their attributes
+const userKeyAttribute = "key"
+
+type definitions struct {
+	Flags map[string]*flagDefinition `yaml:"flags"`
+}
+
+type flagDefinition struct {
+	Rules       []*rule `yaml:"rules"`
+	Fallthrough any     `yaml:"fallthrough"`
+}
+
+type rule struct {
+	ID      string    `yaml:"id"`
+	Clauses []*clause `yaml:"clauses"`
+	Value   any       `yaml:"value"`
+}
+
+type clause struct {
+	Attribute string   `yaml:"attribute"`
+	Operator  string   `yaml:"op"`
+	Values    []string `yaml:"values"`
+	Negate    bool     `yaml:"negate"`
+
+	patterns []*regexp.Regexp
+	versions []semver.Version
+}
+
+// parseDefinitions reads either yaml or json (json being a subset of yaml)
+// and validates every clause, so that a bad file fails fast rather than on
+// the first evaluation which happens to hit the broken rule.
+func parseDefinitions(content []byte) (map[string]*flagDefinition, error) {
+
+	defs := definitions{}
+	if err := yaml.Unmarshal(content, &defs); err != nil {
+		return nil, err
+	}
+
+	for key, flag := range defs.Flags {
+		if flag == nil {
+			return nil, fmt.Errorf("flag %s has no definition", key)
+		}
+
+		flag.Fallthrough = normalise(flag.Fallthrough)
+
+		for i, r := range flag.Rules {
+			r.Value = normalise(r.Value)
+
+			if len(r.Clauses) == 0 {
+				return nil, fmt.Errorf("flag %s, rule %d: no clauses specified", key, i)
+			}
+
+			for _, c := range r.Clauses {
+				if err := c.prepare(); err != nil {
+					return nil, fmt.Errorf("flag %s, rule %d: %w", key, i, err)
+				}
+			}
+		}
+	}
+
+	if defs.Flags == nil {
+		defs.Flags = map[string]*flagDefinition{}
+	}
+
+	return defs.Flags, nil
+}
+
+// normalise converts the values yaml decodes into the same types that json
+// decoding produces, so that numbers are always float64.
+func normalise(val any) any {
+	switch v := val.(type) {
+	case int:
+		return float64(v)
+
+	case []any:
+		for i := range v {
+			v[i] = normalise(v[i])
+		}
+		return v
+
+	case map[string]any:
+		for k := range v {
+			v[k] = normalise(v[k])
+		}
+		return v
+
+	default:
+		return v
+	}
+}
+
+func (c *clause) prepare() error {
+
+	if c.Attribute == "" {
+		return fmt.Errorf("no attribute specified")
+	}
+
+	if len(c.Values) == 0 {
+		return fmt.Errorf("no values specified for attribute %s", c.Attribute)
+	}
+
+	switch c.Operator {
+	case OpEquals:
+		if len(c.Values) != 1 {
+			return fmt.Errorf("the %s operator takes exactly one value", c.Operator)
+		}
+
+	case OpIn:
+
+	case OpMatches:
+		for _, v := range c.Values {
+			re, err := regexp.Compile(v)
+			if err != nil {
+				return err
+			}
+			c.patterns = append(c.patterns, re)
+		}
+
+	case OpSemverEqual, OpSemverLessThan, OpSemverGreaterThan:
+		for _, v := range c.Values {
+			version, err := parseVersion(v)
+			if err != nil {
+				return fmt.Errorf("unable to parse %s as a semantic version: %w", v, err)
+			}
+			c.versions = append(c.versions, version)
+		}
+
+	default:
+		return fmt.Errorf("unsupported operator: %s", c.Operator)
+	}
+
+	return nil
+}
+
+func parseVersion(v string) (semver.Version, error) {
+	return semver.ParseAs(v, semver.ParseModeAllowMissingMinorAndPatch)
+}
@@ -0,0 +1,179 @@
+package file
+
+import (
+	"context"
+	"flagon/backends"
+	"flagon/tracing"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var tr = otel.Tracer("backend.file")
+
+type FileBackend struct {
+	flags map[string]*flagDefinition
+}
+
+func CreateBackend(ctx context.Context, cfg FileConfiguration) (*FileBackend, error) {
+	ctx, span := tr.Start(ctx, "create_backend")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("file.path", cfg.Path))
+
+	content, err := os.ReadFile(cfg.Path)
+	if err != nil {
+		return nil, tracing.Error(span, err)
+	}
+
+	flags, err := parseDefinitions(content)
+	if err != nil {
+		return nil, tracing.Errorf(span, "error reading %s: %w", cfg.Path, err)
+	}
+
+	span.SetAttributes(attribute.Int("file.flags", len(flags)))
+
+	return &FileBackend{
+		flags: flags,
+	}, nil
+}
+
+func (fb *FileBackend) Close(ctx context.Context) error {
+	return nil
+}
+
+func (fb *FileBackend) State(ctx context.Context, flag backends.Flag, user backends.User) (backends.Flag, error) {
+	ctx, span := tr.Start(ctx, "state")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("flag.key", flag.Key))
+
+	flag.Value = flag.DefaultValue
+
+	def, found := fb.flags[flag.Key]
+	if !found {
+		flag.Reason = &backends.Reason{Kind: backends.ReasonError, ErrorKind: backends.ErrorFlagNotFound}
+		span.SetAttributes(attribute.String("reason", flag.Reason.String()))
+		return flag, nil
+	}
+
+	value := def.Fallthrough
+	reason := &backends.Reason{Kind: backends.ReasonFallthrough}
+
+	for i, r := range def.Rules {
+		if r.matches(user) {
+			index := i
+			value = r.Value
+			reason = &backends.Reason{Kind: backends.ReasonRuleMatch, RuleIndex: &index, RuleID: r.ID}
+			break
+		}
+	}
+
+	if value != nil && !backends.IsType(flag.ValueType(), value) {
+		flag.Reason = &backends.Reason{Kind: backends.ReasonError, ErrorKind: backends.ErrorWrongType}
+		span.SetAttributes(attribute.String("reason", flag.Reason.String()))
+		return flag, tracing.Errorf(span, "flag %s has a %T value, which cannot be used as a %s", flag.Key, value, flag.ValueType())
+	}
+
+	flag.Reason = reason
+	span.SetAttributes(attribute.String("reason", flag.Reason.String()))
+
+	if value != nil {
+		flag.Value = value
+	}
+
+	return flag, nil
+}
+
+func (fb *FileBackend) AllFlags(ctx context.Context, user backends.User) ([]backends.Flag, error) {
+	ctx, span := tr.Start(ctx, "all_flags")
+	defer span.End()
+
+	flags := make([]backends.Flag, 0, len(fb.flags))
+
+	for key := range fb.flags {
+		flag, err := fb.State(ctx, backends.Flag{Key: key, Type: backends.TypeJSON}, user)
+		if err != nil {
+			return nil, tracing.Error(span, err)
+		}
+
+		flag.Type = backends.TypeOf(flag.Value)
+		flags = append(flags, flag)
+	}
+
+	span.SetAttributes(attribute.Int("flags.count", len(flags)))
+
+	return flags, nil
+}
+
+func (r *rule) matches(user backends.User) bool {
+	for _, c := range r.Clauses {
+		if !c.matches(user) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c *clause) matches(user backends.User) bool {
+
+	if c.Attribute == userKeyAttribute {
+		return c.matchesValue(user.Key) != c.Negate
+	}
+
+	val, found := user.Attributes[c.Attribute]
+	if !found {
+		return false
+	}
+
+	// a list attribute matches if any of its items match
+	if list, isList := val.([]any); isList {
+		for _, item := range list {
+			if c.matchesValue(backends.AttributeString(item)) {
+				return !c.Negate
+			}
+		}
+		return c.Negate
+	}
+
+	return c.matchesValue(backends.AttributeString(val)) != c.Negate
+}
+
+func (c *clause) matchesValue(value string) bool {
+
+	switch c.Operator {
+	case OpEquals, OpIn:
+		for _, v := range c.Values {
+			if v == value {
+				return true
+			}
+		}
+
+	case OpMatches:
+		for _, re := range c.patterns {
+			if re.MatchString(value) {
+				return true
+			}
+		}
+
+	case OpSemverEqual, OpSemverLessThan, OpSemverGreaterThan:
+		version, err := parseVersion(value)
+		if err != nil {
+			return false
+		}
+
+		for _, v := range c.versions {
+			cmp := version.ComparePrecedence(v)
+
+			if (c.Operator == OpSemverEqual && cmp == 0) ||
+				(c.Operator == OpSemverLessThan && cmp < 0) ||
+				(c.Operator == OpSemverGreaterThan && cmp > 0) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
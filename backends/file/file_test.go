@@ -0,0 +1,364 @@
+package file
+
+import (
+	"context"
+	"flagon/backends"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testDefinitions = `
+flags:
+  fallthrough-only:
+    fallthrough: true
+
+  branch-equals:
+    rules:
+      - clauses:
+          - attribute: branch
+            op: equals
+            values: [main]
+        value: true
+
+  user-in-list:
+    rules:
+      - clauses:
+          - attribute: key
+            op: in
+            values: [alice, bob]
+        value: true
+
+  email-matches:
+    rules:
+      - clauses:
+          - attribute: email
+            op: matches
+            values: ['@example\.com$']
+        value: true
+
+  not-main:
+    rules:
+      - clauses:
+          - attribute: branch
+            op: equals
+            values: [main]
+            negate: true
+        value: true
+
+  versions:
+    rules:
+      - clauses:
+          - attribute: version
+            op: semverLessThan
+            values: ["2.0"]
+        value: false
+      - clauses:
+          - attribute: version
+            op: semverGreaterThan
+            values: ["2.0.0"]
+        value: true
+      - clauses:
+          - attribute: version
+            op: semverEqual
+            values: ["2"]
+        value: true
+
+  multiple-clauses:
+    rules:
+      - clauses:
+          - attribute: branch
+            op: equals
+            values: [main]
+          - attribute: team
+            op: in
+            values: [platform]
+        value: true
+`
+
+func TestState(t *testing.T) {
+
+	flags, err := parseDefinitions([]byte(testDefinitions))
+	assert.NoError(t, err)
+
+	backend := &FileBackend{flags: flags}
+
+	cases := []struct {
+		name         string
+		key          string
+		defaultValue bool
+		user         backends.User
+		expected     bool
+	}{
+		{name: "unknown flag, default off", key: "unknown", expected: false},
+		{name: "unknown flag, default on", key: "unknown", defaultValue: true, expected: true},
+		{name: "fallthrough", key: "fallthrough-only", expected: true},
+
+		{name: "equals match", key: "branch-equals", user: backends.User{Attributes: map[string]any{"branch": "main"}}, expected: true},
+		{name: "equals no match", key: "branch-equals", user: backends.User{Attributes: map[string]any{"branch": "dev"}}, expected: false},
+		{name: "missing attribute", key: "branch-equals", user: backends.User{}, expected: false},
+
+		{name: "in list by key", key: "user-in-list", user: backends.User{Key: "bob"}, expected: true},
+		{name: "not in list by key", key: "user-in-list", user: backends.User{Key: "eve"}, expected: false},
+
+		{name: "regex match", key: "email-matches", user: backends.User{Attributes: map[string]any{"email": "dev@example.com"}}, expected: true},
+		{name: "regex no match", key: "email-matches", user: backends.User{Attributes: map[string]any{"email": "dev@example.org"}}, expected: false},
+
+		{name: "negated match", key: "not-main", user: backends.User{Attributes: map[string]any{"branch": "dev"}}, expected: true},
+		{name: "negated no match", key: "not-main", user: backends.User{Attributes: map[string]any{"branch": "main"}}, expected: false},
+
+		{name: "semver less than", key: "versions", user: backends.User{Attributes: map[string]any{"version": "1.9.3"}}, expected: false},
+		{name: "semver greater than", key: "versions", user: backends.User{Attributes: map[string]any{"version": "2.1.0"}}, expected: true},
+		{name: "semver equal", key: "versions", user: backends.User{Attributes: map[string]any{"version": "2.0.0"}}, expected: true},
+		{name: "semver prerelease", key: "versions", user: backends.User{Attributes: map[string]any{"version": "2.0.0-beta.1"}}, expected: false},
+		{name: "semver unparsable", key: "versions", user: backends.User{Attributes: map[string]any{"version": "latest"}}, expected: false},
+
+		{name: "all clauses match", key: "multiple-clauses", user: backends.User{Attributes: map[string]any{"branch": "main", "team": "platform"}}, expected: true},
+		{name: "one clause matches", key: "multiple-clauses", user: backends.User{Attributes: map[string]any{"branch": "main", "team": "mobile"}}, expected: false},
+
+		{name: "list item matches", key: "multiple-clauses", user: backends.User{Attributes: map[string]any{"branch": "main", "team": []any{"mobile", "platform"}}}, expected: true},
+		{name: "negated list item matches", key: "not-main", user: backends.User{Attributes: map[string]any{"branch": []any{"dev", "main"}}}, expected: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			flag, err := backend.State(context.Background(), backends.Flag{Key: tc.key, DefaultValue: tc.defaultValue}, tc.user)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, flag.Value)
+		})
+	}
+}
+
+func TestVariations(t *testing.T) {
+
+	flags, err := parseDefinitions([]byte(`
+flags:
+  strategy:
+    rules:
+      - clauses:
+          - attribute: branch
+            op: equals
+            values: [main]
+        value: blue-green
+    fallthrough: rolling
+
+  parallelism:
+    fallthrough: 4
+
+  config:
+    fallthrough:
+      retries: 3
+      regions: [eu, us]
+
+  no-fallthrough:
+    rules: []
+`))
+	assert.NoError(t, err)
+
+	backend := &FileBackend{flags: flags}
+
+	cases := []struct {
+		name     string
+		flag     backends.Flag
+		user     backends.User
+		expected any
+		err      string
+	}{
+		{
+			name:     "string rule",
+			flag:     backends.Flag{Key: "strategy", Type: backends.TypeString, DefaultValue: "none"},
+			user:     backends.User{Attributes: map[string]any{"branch": "main"}},
+			expected: "blue-green",
+		},
+		{
+			name:     "string fallthrough",
+			flag:     backends.Flag{Key: "strategy", Type: backends.TypeString, DefaultValue: "none"},
+			expected: "rolling",
+		},
+		{
+			name:     "number",
+			flag:     backends.Flag{Key: "parallelism", Type: backends.TypeNumber, DefaultValue: 1.0},
+			expected: 4.0,
+		},
+		{
+			name:     "json",
+			flag:     backends.Flag{Key: "config", Type: backends.TypeJSON},
+			expected: map[string]any{"retries": 3.0, "regions": []any{"eu", "us"}},
+		},
+		{
+			name:     "no fallthrough uses the default",
+			flag:     backends.Flag{Key: "no-fallthrough", Type: backends.TypeString, DefaultValue: "default"},
+			expected: "default",
+		},
+		{
+			name:     "wrong type",
+			flag:     backends.Flag{Key: "strategy", Type: backends.TypeBool, DefaultValue: false},
+			expected: false,
+			err:      "flag strategy has a string value, which cannot be used as a bool",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			flag, err := backend.State(context.Background(), tc.flag, tc.user)
+
+			if tc.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.err)
+			}
+			assert.Equal(t, tc.expected, flag.Value)
+		})
+	}
+}
+
+func TestReasons(t *testing.T) {
+
+	flags, err := parseDefinitions([]byte(`
+flags:
+  deploy:
+    rules:
+      - clauses:
+          - attribute: branch
+            op: equals
+            values: [dev]
+        value: false
+      - id: main-branch
+        clauses:
+          - attribute: branch
+            op: equals
+            values: [main]
+        value: true
+    fallthrough: false
+`))
+	assert.NoError(t, err)
+
+	backend := &FileBackend{flags: flags}
+	ruleIndex := 1
+
+	cases := []struct {
+		name     string
+		key      string
+		user     backends.User
+		expected *backends.Reason
+	}{
+		{
+			name:     "rule match",
+			key:      "deploy",
+			user:     backends.User{Attributes: map[string]any{"branch": "main"}},
+			expected: &backends.Reason{Kind: backends.ReasonRuleMatch, RuleIndex: &ruleIndex, RuleID: "main-branch"},
+		},
+		{
+			name:     "fallthrough",
+			key:      "deploy",
+			user:     backends.User{Attributes: map[string]any{"branch": "feature"}},
+			expected: &backends.Reason{Kind: backends.ReasonFallthrough},
+		},
+		{
+			name:     "not found",
+			key:      "missing",
+			expected: &backends.Reason{Kind: backends.ReasonError, ErrorKind: backends.ErrorFlagNotFound},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			flag, err := backend.State(context.Background(), backends.Flag{Key: tc.key, DefaultValue: false}, tc.user)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, flag.Reason)
+		})
+	}
+}
+
+func TestAllFlags(t *testing.T) {
+
+	flags, err := parseDefinitions([]byte(`
+flags:
+  deploy:
+    rules:
+      - clauses:
+          - attribute: branch
+            op: equals
+            values: [main]
+        value: true
+    fallthrough: false
+  strategy:
+    fallthrough: rolling
+`))
+	assert.NoError(t, err)
+
+	backend := &FileBackend{flags: flags}
+
+	all, err := backend.AllFlags(context.Background(), backends.User{Attributes: map[string]any{"branch": "main"}})
+	assert.NoError(t, err)
+
+	values := map[string]any{}
+	for _, flag := range all {
+		values[flag.Key] = flag.Value
+		assert.Equal(t, backends.TypeOf(flag.Value), flag.Type)
+	}
+
+	assert.Equal(t, map[string]any{"deploy": true, "strategy": "rolling"}, values)
+}
+
+func TestInvalidDefinitions(t *testing.T) {
+
+	cases := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{
+			name:    "unknown operator",
+			content: `{"flags": {"f": {"rules": [{"clauses": [{"attribute": "a", "op": "like", "values": ["x"]}]}]}}}`,
+			err:     "unsupported operator: like",
+		},
+		{
+			name:    "bad regex",
+			content: `{"flags": {"f": {"rules": [{"clauses": [{"attribute": "a", "op": "matches", "values": ["("]}]}]}}}`,
+			err:     "missing closing )",
+		},
+		{
+			name:    "bad version",
+			content: `{"flags": {"f": {"rules": [{"clauses": [{"attribute": "a", "op": "semverEqual", "values": ["one"]}]}]}}}`,
+			err:     "unable to parse one as a semantic version",
+		},
+		{
+			name:    "equals with many values",
+			content: `{"flags": {"f": {"rules": [{"clauses": [{"attribute": "a", "op": "equals", "values": ["x", "y"]}]}]}}}`,
+			err:     "the equals operator takes exactly one value",
+		},
+		{
+			name:    "rule without clauses",
+			content: `{"flags": {"f": {"rules": [{"value": true}]}}}`,
+			err:     "no clauses specified",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parseDefinitions([]byte(tc.content))
+			assert.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestCreateBackend(t *testing.T) {
+
+	filePath := path.Join(t.TempDir(), "flags.json")
+	assert.NoError(t, os.WriteFile(filePath, []byte(`{"flags": {"on": {"fallthrough": true}}}`), 0644))
+
+	backend, err := CreateBackend(context.Background(), FileConfiguration{Path: filePath})
+	assert.NoError(t, err)
+
+	flag, err := backend.State(context.Background(), backends.Flag{Key: "on"}, backends.User{})
+	assert.NoError(t, err)
+	assert.Equal(t, true, flag.Value)
+
+	_, err = CreateBackend(context.Background(), FileConfiguration{Path: path.Join(t.TempDir(), "missing.yaml")})
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
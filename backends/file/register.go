@@ -0,0 +1,18 @@
+package file
+
+import (
+	"context"
+	"flagon/backends"
+)
+
+func init() {
+	backends.Register("file", "File Backend", DefaultConfig, ConfigFromEnvironment,
+		func(ctx context.Context, cfg FileConfiguration) (backends.Backend, error) {
+			backend, err := CreateBackend(ctx, cfg)
+			if err != nil {
+				return nil, err
+			}
+			return backend, nil
+		},
+	)
+}
@@ -0,0 +1,77 @@
+package flagsmith
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+const EnvironmentKeyEnvVar = "FLAGON_FLAGSMITH_ENVIRONMENT_KEY"
+const ApiUrlEnvVar = "FLAGON_FLAGSMITH_API_URL"
+const LocalEvaluationEnvVar = "FLAGON_FLAGSMITH_LOCAL_EVALUATION"
+const TimeoutEnvVar = "FLAGON_FLAGSMITH_TIMEOUT"
+
+type FlagsmithConfiguration struct {
+	EnvironmentKey  string
+	ApiUrl          string
+	LocalEvaluation bool
+	Timeout         time.Duration
+}
+
+func (cfg *FlagsmithConfiguration) OverrideFrom(other FlagsmithConfiguration) {
+	if other.EnvironmentKey != "" {
+		cfg.EnvironmentKey = other.EnvironmentKey
+	}
+
+	if other.ApiUrl != "" {
+		cfg.ApiUrl = other.ApiUrl
+	}
+
+	if other.LocalEvaluation {
+		cfg.LocalEvaluation = other.LocalEvaluation
+	}
+
+	if other.Timeout > 0 {
+		cfg.Timeout = other.Timeout
+	}
+}
+
+func (cfg *FlagsmithConfiguration) Flags() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("Flagsmith Backend", pflag.ContinueOnError)
+
+	flags.StringVar(&cfg.EnvironmentKey, "flagsmith-environment-key", "", "the environment key to use, which must be a server-side key for local evaluation")
+	flags.StringVar(&cfg.ApiUrl, "flagsmith-api-url", "", "the url of the flagsmith api, for self hosted instances")
+	flags.BoolVar(&cfg.LocalEvaluation, "flagsmith-local-evaluation", false, "fetch the environment document once and evaluate flags locally")
+	flags.DurationVar(&cfg.Timeout, "flagsmith-timeout", 0, "timeout before failing to communicate with flagsmith")
+
+	return flags
+}
+
+func ConfigFromEnvironment() FlagsmithConfiguration {
+
+	cfg := FlagsmithConfiguration{}
+	cfg.EnvironmentKey = os.Getenv(EnvironmentKeyEnvVar)
+	cfg.ApiUrl = os.Getenv(ApiUrlEnvVar)
+
+	if val := os.Getenv(LocalEvaluationEnvVar); val != "" {
+		b, err := strconv.ParseBool(val)
+		cfg.LocalEvaluation = err == nil && b
+	}
+
+	if val := os.Getenv(TimeoutEnvVar); val != "" {
+		if timeout, err := time.ParseDuration(val); err == nil {
+			cfg.Timeout = timeout
+		}
+	}
+
+	return cfg
+}
+
+func DefaultConfig() FlagsmithConfiguration {
+	return FlagsmithConfiguration{
+		ApiUrl:  "https://edge.api.flagsmith.com/api/v1/",
+		Timeout: 5 * time.Second,
+	}
+}
@@ -0,0 +1,54 @@
+package flagsmith
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadEnvironment(t *testing.T) {
+
+	os.Setenv(EnvironmentKeyEnvVar, "ser.abc")
+	os.Setenv(ApiUrlEnvVar, "https://flagsmith.example.com/api/v1/")
+	os.Setenv(LocalEvaluationEnvVar, "true")
+	os.Setenv(TimeoutEnvVar, "3s")
+
+	cfg := ConfigFromEnvironment()
+
+	assert.Equal(t, "ser.abc", cfg.EnvironmentKey)
+	assert.Equal(t, "https://flagsmith.example.com/api/v1/", cfg.ApiUrl)
+	assert.True(t, cfg.LocalEvaluation)
+	assert.Equal(t, 3*time.Second, cfg.Timeout)
+}
+
+func TestFlags(t *testing.T) {
+
+	cfg := FlagsmithConfiguration{}
+	flags := cfg.Flags()
+
+	assert.NoError(t, flags.Parse([]string{
+		"--flagsmith-environment-key", "abc",
+		"--flagsmith-api-url", "http://localhost:8000/api/v1/",
+		"--flagsmith-local-evaluation",
+		"--flagsmith-timeout", "1s",
+	}))
+
+	assert.Equal(t, "abc", cfg.EnvironmentKey)
+	assert.Equal(t, "http://localhost:8000/api/v1/", cfg.ApiUrl)
+	assert.True(t, cfg.LocalEvaluation)
+	assert.Equal(t, time.Second, cfg.Timeout)
+}
+
+func TestOverridingValues(t *testing.T) {
+
+	base := DefaultConfig()
+	base.OverrideFrom(FlagsmithConfiguration{})
+	assert.Equal(t, DefaultConfig(), base)
+
+	base.OverrideFrom(FlagsmithConfiguration{EnvironmentKey: "abc", LocalEvaluation: true})
+	assert.Equal(t, "abc", base.EnvironmentKey)
+	assert.Equal(t, "https://edge.api.flagsmith.com/api/v1/", base.ApiUrl)
+	assert.True(t, base.LocalEvaluation)
+}
@@ -0,0 +1,88 @@
+package flagsmith
+
+// featureState is a flag's value in an environment, segment or identity.
+// The api's identity and flags endpoints return the same shape.
+type featureState struct {
+	Feature      feature             `json:"feature"`
+	Enabled      bool                `json:"enabled"`
+	Value        any                 `json:"feature_state_value"`
+	DjangoID     *int                `json:"django_id"`
+	UUID         string              `json:"featurestate_uuid"`
+	Multivariate []multivariateValue `json:"multivariate_feature_state_values"`
+
+	FeatureSegment *featureSegment `json:"feature_segment"`
+}
+
+type feature struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type multivariateValue struct {
+	ID                   *int    `json:"id"`
+	UUID                 string  `json:"mv_fs_value_uuid"`
+	PercentageAllocation float64 `json:"percentage_allocation"`
+	Option               struct {
+		Value any `json:"value"`
+	} `json:"multivariate_feature_option"`
+}
+
+type featureSegment struct {
+	Priority *int `json:"priority"`
+}
+
+// environmentDocument is returned by the `/environment-document/` endpoint,
+// and contains everything needed to evaluate flags locally
+type environmentDocument struct {
+	ApiKey            string             `json:"api_key"`
+	FeatureStates     []featureState     `json:"feature_states"`
+	Project           project            `json:"project"`
+	IdentityOverrides []identityOverride `json:"identity_overrides"`
+}
+
+type project struct {
+	Segments []segment `json:"segments"`
+}
+
+type segment struct {
+	ID            int            `json:"id"`
+	Name          string         `json:"name"`
+	Rules         []segmentRule  `json:"rules"`
+	FeatureStates []featureState `json:"feature_states"`
+}
+
+type segmentRule struct {
+	Type       string             `json:"type"`
+	Rules      []segmentRule      `json:"rules"`
+	Conditions []segmentCondition `json:"conditions"`
+}
+
+type segmentCondition struct {
+	Operator string  `json:"operator"`
+	Property string  `json:"property_"`
+	Value    *string `json:"value"`
+}
+
+type identityOverride struct {
+	Identifier       string         `json:"identifier"`
+	IdentityFeatures []featureState `json:"identity_features"`
+}
+
+type trait struct {
+	Key   string `json:"trait_key"`
+	Value any    `json:"trait_value"`
+
+	// Transient traits are used to evaluate flags, but not stored
+	Transient bool `json:"transient,omitempty"`
+}
+
+type identityRequest struct {
+	Identifier string  `json:"identifier"`
+	Traits     []trait `json:"traits"`
+	Transient  bool    `json:"transient"`
+}
+
+type identityResponse struct {
+	Flags []featureState `json:"flags"`
+}
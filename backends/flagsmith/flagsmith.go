@@ -0,0 +1,388 @@
+package flagsmith
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flagon/backends"
+	"flagon/tracing"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tr = otel.Tracer("backend.flagsmith")
+
+const (
+	flagsPath       = "flags/"
+	identitiesPath  = "identities/"
+	environmentPath = "environment-document/"
+
+	serverKeyPrefix = "ser."
+)
+
+// how long the environment document is used before fetching it again, which
+// matches the flagsmith sdks' default
+const refreshInterval = 60 * time.Second
+
+// FlagsmithBackend evaluates flags with the flagsmith api, or locally from
+// the environment document when using local evaluation
+type FlagsmithBackend struct {
+	cfg     FlagsmithConfiguration
+	client  *http.Client
+	baseUrl string
+
+	document *backends.Refresher[*environmentDocument]
+
+	// identities are the flags fetched for each user when not using local
+	// evaluation, keyed by the user as json
+	lock       sync.Mutex
+	identities map[string]identityFlags
+}
+
+type identityFlags struct {
+	states  []featureState
+	fetched time.Time
+}
+
+func CreateBackend(ctx context.Context, cfg FlagsmithConfiguration) (*FlagsmithBackend, error) {
+	ctx, span := tr.Start(ctx, "create_backend")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("flagsmith.api_url", cfg.ApiUrl),
+		attribute.Bool("flagsmith.local_evaluation", cfg.LocalEvaluation),
+	)
+
+	if cfg.EnvironmentKey == "" {
+		return nil, tracing.Errorf(span, "no flagsmith environment key specified, use --flagsmith-environment-key or $%s", EnvironmentKeyEnvVar)
+	}
+
+	if cfg.LocalEvaluation && !strings.HasPrefix(cfg.EnvironmentKey, serverKeyPrefix) {
+		return nil, tracing.Errorf(span, "local evaluation needs a server-side environment key, which starts with %s", serverKeyPrefix)
+	}
+
+	fb := &FlagsmithBackend{
+		cfg:     cfg,
+		client:  &http.Client{Timeout: cfg.Timeout},
+		baseUrl: strings.TrimSuffix(cfg.ApiUrl, "/") + "/",
+
+		identities: map[string]identityFlags{},
+	}
+	fb.document = &backends.Refresher[*environmentDocument]{Fetch: fb.fetchDocument, Interval: refreshInterval}
+
+	if cfg.LocalEvaluation {
+		if err := fb.document.Refresh(ctx); err != nil {
+			return nil, tracing.Error(span, err)
+		}
+	}
+
+	return fb, nil
+}
+
+func (fb *FlagsmithBackend) Close(ctx context.Context) error {
+	fb.client.CloseIdleConnections()
+	return nil
+}
+
+func (fb *FlagsmithBackend) State(ctx context.Context, flag backends.Flag, user backends.User) (backends.Flag, error) {
+	ctx, span := tr.Start(ctx, "state")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("flag.key", flag.Key))
+
+	flag.Value = flag.DefaultValue
+
+	var eval evaluation
+	var found bool
+	identityKey := ""
+
+	if fb.cfg.LocalEvaluation {
+		doc := fb.current(ctx)
+		eval, found = doc.evaluate(flag.Key, user)
+		identityKey = doc.identityKey(user)
+	} else {
+		states, err := fb.remoteFlags(ctx, user)
+		if err != nil {
+			return flag, tracing.Error(span, err)
+		}
+		eval, found = remoteEvaluation(states, flag.Key)
+	}
+
+	if !found {
+		flag.Reason = &backends.Reason{Kind: backends.ReasonError, ErrorKind: backends.ErrorFlagNotFound}
+		span.SetAttributes(attribute.String("reason", flag.Reason.String()))
+		return flag, nil
+	}
+
+	return resolve(span, flag, eval, user, identityKey)
+}
+
+func (fb *FlagsmithBackend) AllFlags(ctx context.Context, user backends.User) ([]backends.Flag, error) {
+	ctx, span := tr.Start(ctx, "all_flags")
+	defer span.End()
+
+	var keys []string
+	var states []featureState
+
+	if fb.cfg.LocalEvaluation {
+		for _, fs := range fb.current(ctx).FeatureStates {
+			keys = append(keys, fs.Feature.Name)
+		}
+	} else {
+		var err error
+		if states, err = fb.remoteFlags(ctx, user); err != nil {
+			return nil, tracing.Error(span, err)
+		}
+		for _, fs := range states {
+			keys = append(keys, fs.Feature.Name)
+		}
+	}
+
+	flags := make([]backends.Flag, 0, len(keys))
+
+	for _, key := range keys {
+		flag := backends.Flag{Key: key, Type: backends.TypeBool, DefaultValue: false}
+
+		if fb.cfg.LocalEvaluation {
+			var err error
+			if flag, err = fb.State(ctx, flag, user); err != nil {
+				return nil, tracing.Error(span, err)
+			}
+		} else {
+			eval, _ := remoteEvaluation(states, key)
+			flag, _ = resolve(span, flag, eval, user, "")
+		}
+
+		flags = append(flags, flag)
+	}
+
+	span.SetAttributes(attribute.Int("flags.count", len(flags)))
+
+	return flags, nil
+}
+
+// resolve sets the flag's value from the feature state.  Bool flags use
+// whether the feature is enabled, other types use the feature's value.
+func resolve(span trace.Span, flag backends.Flag, eval evaluation, user backends.User, identityKey string) (backends.Flag, error) {
+	flag.Reason = eval.reason
+	span.SetAttributes(
+		attribute.String("reason", flag.Reason.String()),
+		attribute.Bool("enabled", eval.state.Enabled),
+	)
+
+	if flag.ValueType() == backends.TypeBool {
+		flag.Value = eval.state.Enabled
+		return flag, nil
+	}
+
+	if !eval.state.Enabled {
+		return flag, nil
+	}
+
+	value, index := eval.state.Value, -1
+	if identityKey != "" && user.Key != "" {
+		value, index = eval.state.multivariateValue(identityKey)
+	}
+
+	if value == nil {
+		return flag, nil
+	}
+
+	value, err := convertValue(flag.ValueType(), value)
+	if err != nil {
+		flag.Reason = &backends.Reason{Kind: backends.ReasonError, ErrorKind: backends.ErrorWrongType}
+		return flag, tracing.Errorf(span, "flag %s cannot be used as a %s: %w", flag.Key, flag.ValueType(), err)
+	}
+
+	flag.Value = value
+	if index >= 0 {
+		flag.VariationIndex = &index
+	}
+
+	return flag, nil
+}
+
+// convertValue handles flagsmith storing values as strings, numbers or
+// booleans depending on what they look like, and json as strings
+func convertValue(t backends.FlagType, value any) (any, error) {
+	if t == backends.TypeString {
+		switch v := value.(type) {
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		case bool:
+			return strconv.FormatBool(v), nil
+		}
+	}
+
+	if s, ok := value.(string); ok && t != backends.TypeString {
+		return backends.ParseValue(t, s)
+	}
+
+	if !backends.IsType(t, value) {
+		return nil, fmt.Errorf("the value is a %T", value)
+	}
+
+	return value, nil
+}
+
+func remoteEvaluation(states []featureState, key string) (evaluation, bool) {
+	for i := range states {
+		if states[i].Feature.Name != key {
+			continue
+		}
+
+		reason := &backends.Reason{Kind: backends.ReasonFallthrough}
+		if !states[i].Enabled {
+			reason = &backends.Reason{Kind: backends.ReasonOff}
+		}
+
+		return evaluation{state: &states[i], reason: reason}, true
+	}
+
+	return evaluation{}, false
+}
+
+// remoteFlags returns the user's flags, fetching them again if they are older
+// than the refresh interval, so that evaluating many flags for the same user
+// only makes one request.
+func (fb *FlagsmithBackend) remoteFlags(ctx context.Context, user backends.User) ([]featureState, error) {
+	ctx, span := tr.Start(ctx, "remote_flags")
+	defer span.End()
+
+	id, err := json.Marshal(user)
+	if err != nil {
+		return nil, tracing.Error(span, err)
+	}
+
+	fb.lock.Lock()
+	cached, found := fb.identities[string(id)]
+	fb.lock.Unlock()
+
+	fresh := found && time.Since(cached.fetched) < refreshInterval
+	span.SetAttributes(attribute.Bool("cached", fresh))
+
+	if fresh {
+		return cached.states, nil
+	}
+
+	states, err := fb.fetchRemoteFlags(ctx, user)
+	if err != nil {
+		return nil, tracing.Error(span, err)
+	}
+
+	fb.lock.Lock()
+	fb.identities[string(id)] = identityFlags{states: states, fetched: time.Now()}
+	fb.lock.Unlock()
+
+	return states, nil
+}
+
+// fetchRemoteFlags fetches the identity's flags as a transient identity, so
+// that evaluating flags doesn't change the identity in flagsmith, with any
+// private attributes also marked as transient traits.  Without a user key,
+// the environment's flags are used.
+func (fb *FlagsmithBackend) fetchRemoteFlags(ctx context.Context, user backends.User) ([]featureState, error) {
+	ctx, span := tr.Start(ctx, "fetch_remote_flags")
+	defer span.End()
+
+	if user.Key == "" {
+		states := []featureState{}
+		if err := fb.call(ctx, http.MethodGet, flagsPath, nil, &states); err != nil {
+			return nil, tracing.Error(span, err)
+		}
+		return states, nil
+	}
+
+	req := identityRequest{
+		Identifier: user.Key,
+		Traits:     make([]trait, 0, len(user.Attributes)),
+		Transient:  true,
+	}
+
+	private := make(map[string]bool, len(user.PrivateAttributes))
+	for _, name := range user.PrivateAttributes {
+		private[name] = true
+	}
+
+	for k, v := range user.Attributes {
+		req.Traits = append(req.Traits, trait{Key: k, Value: v, Transient: private[k]})
+	}
+
+	res := identityResponse{}
+	if err := fb.call(ctx, http.MethodPost, identitiesPath, req, &res); err != nil {
+		return nil, tracing.Error(span, err)
+	}
+
+	return res.Flags, nil
+}
+
+// current returns the environment document, fetching it again if it is
+// older than the refresh interval
+func (fb *FlagsmithBackend) current(ctx context.Context) *environmentDocument {
+	ctx, span := tr.Start(ctx, "current")
+	defer span.End()
+
+	return fb.document.Current(ctx)
+}
+
+func (fb *FlagsmithBackend) fetchDocument(ctx context.Context, previous *environmentDocument) (*environmentDocument, error) {
+	ctx, span := tr.Start(ctx, "fetch_document")
+	defer span.End()
+
+	doc := &environmentDocument{}
+	if err := fb.call(ctx, http.MethodGet, environmentPath, nil, doc); err != nil {
+		return previous, tracing.Error(span, err)
+	}
+
+	span.SetAttributes(
+		attribute.Int("flagsmith.features", len(doc.FeatureStates)),
+		attribute.Int("flagsmith.segments", len(doc.Project.Segments)),
+	)
+
+	return doc, nil
+}
+
+func (fb *FlagsmithBackend) call(ctx context.Context, method string, path string, body any, response any) error {
+
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, fb.baseUrl+path, reader)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Environment-Key", fb.cfg.EnvironmentKey)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	res, err := fb.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to reach flagsmith: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("flagsmith returned %s: %s", res.Status, bytes.TrimSpace(msg))
+	}
+
+	return json.NewDecoder(res.Body).Decode(response)
+}
@@ -0,0 +1,312 @@
+package flagsmith
+
+import (
+	"context"
+	"encoding/json"
+	"flagon/backends"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const document = `{
+  "api_key": "abc",
+  "feature_states": [
+    { "feature": { "id": 1, "name": "enabled" }, "enabled": true, "feature_state_value": null, "django_id": 1 },
+    { "feature": { "id": 2, "name": "disabled" }, "enabled": false, "feature_state_value": "value", "django_id": 2 },
+    { "feature": { "id": 3, "name": "deploy-strategy" }, "enabled": true, "feature_state_value": "rolling", "django_id": 3 },
+    { "feature": { "id": 4, "name": "build-number" }, "enabled": true, "feature_state_value": 42, "django_id": 4 },
+    { "feature": { "id": 5, "name": "deploy-config" }, "enabled": true, "feature_state_value": "{\"retries\": 1}", "django_id": 6 },
+    { "feature": { "id": 6, "name": "colour" }, "enabled": true, "feature_state_value": "grey", "django_id": 5,
+      "multivariate_feature_state_values": [
+        { "id": 2, "percentage_allocation": 30, "multivariate_feature_option": { "value": "blue" } },
+        { "id": 1, "percentage_allocation": 30, "multivariate_feature_option": { "value": "red" } }
+      ] },
+    { "feature": { "id": 7, "name": "beta" }, "enabled": false, "django_id": 7 }
+  ],
+  "project": {
+    "segments": [
+      { "id": 1, "name": "main-branch",
+        "rules": [ { "type": "ALL", "rules": [ { "type": "ANY", "conditions": [
+          { "operator": "EQUAL", "property_": "branch", "value": "main" },
+          { "operator": "EQUAL", "property_": "branch", "value": "master" }
+        ] } ] } ],
+        "feature_states": [
+          { "feature": { "id": 7, "name": "beta" }, "enabled": true, "feature_state_value": "main", "feature_segment": { "priority": 1 } }
+        ] },
+      { "id": 2, "name": "new-versions",
+        "rules": [ { "type": "ALL", "conditions": [ { "operator": "GREATER_THAN", "property_": "version", "value": "2.0.0:semver" } ] } ],
+        "feature_states": [
+          { "feature": { "id": 7, "name": "beta" }, "enabled": true, "feature_state_value": "new", "feature_segment": { "priority": 0 } }
+        ] },
+      { "id": 3, "name": "ten-percent",
+        "rules": [ { "type": "ALL", "conditions": [ { "operator": "PERCENTAGE_SPLIT", "value": "10" } ] } ],
+        "feature_states": [
+          { "feature": { "id": 3, "name": "deploy-strategy" }, "enabled": true, "feature_state_value": "canary" }
+        ] }
+    ]
+  },
+  "identity_overrides": [
+    { "identifier": "alice", "identity_features": [
+      { "feature": { "id": 2, "name": "disabled" }, "enabled": true, "feature_state_value": "override" }
+    ] }
+  ]
+}`
+
+func createLocalBackend(t *testing.T) *FlagsmithBackend {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/environment-document/" || r.Header.Get("X-Environment-Key") != "ser.secret" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(document))
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := DefaultConfig()
+	cfg.ApiUrl = server.URL + "/api/v1"
+	cfg.EnvironmentKey = "ser.secret"
+	cfg.LocalEvaluation = true
+
+	fb, err := CreateBackend(context.Background(), cfg)
+	assert.NoError(t, err)
+
+	return fb
+}
+
+func TestLocalEvaluation(t *testing.T) {
+
+	fb := createLocalBackend(t)
+	ctx := context.Background()
+
+	cases := []struct {
+		name           string
+		flag           backends.Flag
+		user           backends.User
+		expected       any
+		expectedReason backends.ReasonKind
+	}{
+		{name: "enabled", flag: backends.Flag{Key: "enabled", DefaultValue: false}, user: backends.User{Key: "bob"}, expected: true, expectedReason: backends.ReasonFallthrough},
+		{name: "disabled", flag: backends.Flag{Key: "disabled", DefaultValue: false}, user: backends.User{Key: "bob"}, expected: false, expectedReason: backends.ReasonOff},
+		{name: "identity override", flag: backends.Flag{Key: "disabled", DefaultValue: false}, user: backends.User{Key: "alice"}, expected: true, expectedReason: backends.ReasonTargetMatch},
+		{name: "segment override", flag: backends.Flag{Key: "beta", DefaultValue: false}, user: backends.User{Key: "bob", Attributes: map[string]any{"branch": "master"}}, expected: true, expectedReason: backends.ReasonRuleMatch},
+		{name: "no segment", flag: backends.Flag{Key: "beta", DefaultValue: false}, user: backends.User{Key: "bob", Attributes: map[string]any{"branch": "dev"}}, expected: false, expectedReason: backends.ReasonOff},
+		{name: "segment priority", flag: backends.Flag{Key: "beta", Type: backends.TypeString, DefaultValue: "default"}, user: backends.User{Key: "bob", Attributes: map[string]any{"branch": "main", "version": "2.1.0"}}, expected: "new", expectedReason: backends.ReasonRuleMatch},
+		{name: "percentage split in", flag: backends.Flag{Key: "deploy-strategy", Type: backends.TypeString, DefaultValue: "default"}, user: backends.User{Key: "carol"}, expected: "canary", expectedReason: backends.ReasonRuleMatch},
+		{name: "percentage split out", flag: backends.Flag{Key: "deploy-strategy", Type: backends.TypeString, DefaultValue: "default"}, user: backends.User{Key: "dave"}, expected: "rolling", expectedReason: backends.ReasonFallthrough},
+		{name: "disabled string", flag: backends.Flag{Key: "disabled", Type: backends.TypeString, DefaultValue: "default"}, user: backends.User{Key: "bob"}, expected: "default", expectedReason: backends.ReasonOff},
+		{name: "number", flag: backends.Flag{Key: "build-number", Type: backends.TypeNumber, DefaultValue: 0.0}, user: backends.User{Key: "bob"}, expected: 42.0, expectedReason: backends.ReasonFallthrough},
+		{name: "number as string", flag: backends.Flag{Key: "build-number", Type: backends.TypeString, DefaultValue: "default"}, user: backends.User{Key: "bob"}, expected: "42", expectedReason: backends.ReasonFallthrough},
+		{name: "json", flag: backends.Flag{Key: "deploy-config", Type: backends.TypeJSON}, user: backends.User{Key: "bob"}, expected: map[string]any{"retries": 1.0}, expectedReason: backends.ReasonFallthrough},
+		{name: "multivariate control", flag: backends.Flag{Key: "colour", Type: backends.TypeString, DefaultValue: "default"}, user: backends.User{Key: "alice"}, expected: "grey", expectedReason: backends.ReasonFallthrough},
+		{name: "no identity uses control", flag: backends.Flag{Key: "colour", Type: backends.TypeString, DefaultValue: "default"}, user: backends.User{}, expected: "grey", expectedReason: backends.ReasonFallthrough},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			flag, err := fb.State(ctx, tc.flag, tc.user)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, flag.Value)
+			assert.Equal(t, tc.expectedReason, flag.Reason.Kind)
+		})
+	}
+
+	t.Run("multivariate", func(t *testing.T) {
+		// variations are sorted by id, so red is the first 30%
+		flag, err := fb.State(ctx, backends.Flag{Key: "colour", Type: backends.TypeString, DefaultValue: "default"}, backends.User{Key: "carol"})
+		assert.NoError(t, err)
+		assert.Equal(t, "red", flag.Value)
+		assert.Equal(t, 1, *flag.VariationIndex)
+
+		flag, err = fb.State(ctx, backends.Flag{Key: "colour", Type: backends.TypeString, DefaultValue: "default"}, backends.User{Key: "bob"})
+		assert.NoError(t, err)
+		assert.Equal(t, "blue", flag.Value)
+		assert.Equal(t, 0, *flag.VariationIndex)
+	})
+
+	t.Run("missing flag", func(t *testing.T) {
+		flag, err := fb.State(ctx, backends.Flag{Key: "missing", DefaultValue: true}, backends.User{Key: "bob"})
+		assert.NoError(t, err)
+		assert.Equal(t, true, flag.Value)
+		assert.Equal(t, backends.ErrorFlagNotFound, flag.Reason.ErrorKind)
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		flag, err := fb.State(ctx, backends.Flag{Key: "deploy-strategy", Type: backends.TypeNumber, DefaultValue: 1.0}, backends.User{Key: "dave"})
+		assert.Error(t, err)
+		assert.Equal(t, 1.0, flag.Value)
+		assert.Equal(t, backends.ErrorWrongType, flag.Reason.ErrorKind)
+	})
+
+	t.Run("all flags", func(t *testing.T) {
+		flags, err := fb.AllFlags(ctx, backends.User{Key: "alice"})
+		assert.NoError(t, err)
+		assert.Len(t, flags, 7)
+	})
+}
+
+func TestRemoteEvaluation(t *testing.T) {
+
+	requests := []identityRequest{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Environment-Key") != "client-key" {
+			http.Error(w, `{"detail":"Invalid or missing API key"}`, http.StatusForbidden)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/api/v1/flags/":
+			w.Write([]byte(`[ { "feature": { "name": "enabled" }, "enabled": false, "feature_state_value": null } ]`))
+
+		case "/api/v1/identities/":
+			req := identityRequest{}
+			json.NewDecoder(r.Body).Decode(&req)
+			requests = append(requests, req)
+
+			w.Write([]byte(`{ "flags": [
+			  { "feature": { "name": "enabled" }, "enabled": true, "feature_state_value": null },
+			  { "feature": { "name": "deploy-strategy" }, "enabled": true, "feature_state_value": "blue-green" }
+			] }`))
+
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := DefaultConfig()
+	cfg.ApiUrl = server.URL + "/api/v1/"
+	cfg.EnvironmentKey = "client-key"
+
+	fb, err := CreateBackend(context.Background(), cfg)
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	t.Run("identity", func(t *testing.T) {
+		flag, err := fb.State(ctx, backends.Flag{Key: "enabled", DefaultValue: false}, backends.User{Key: "alice", Attributes: map[string]any{"branch": "main"}})
+		assert.NoError(t, err)
+		assert.Equal(t, true, flag.Value)
+
+		assert.Equal(t, identityRequest{
+			Identifier: "alice",
+			Traits:     []trait{{Key: "branch", Value: "main"}},
+			Transient:  true,
+		}, requests[len(requests)-1])
+	})
+
+	t.Run("private attributes are transient traits", func(t *testing.T) {
+		_, err := fb.State(ctx, backends.Flag{Key: "enabled", DefaultValue: false}, backends.User{Key: "alice", Attributes: map[string]any{"email": "alice@example.com"}, PrivateAttributes: []string{"email"}})
+		assert.NoError(t, err)
+
+		assert.Equal(t, []trait{{Key: "email", Value: "alice@example.com", Transient: true}}, requests[len(requests)-1].Traits)
+	})
+
+	t.Run("environment flags without an identity", func(t *testing.T) {
+		flag, err := fb.State(ctx, backends.Flag{Key: "enabled", DefaultValue: false}, backends.User{})
+		assert.NoError(t, err)
+		assert.Equal(t, false, flag.Value)
+		assert.Equal(t, backends.ReasonOff, flag.Reason.Kind)
+	})
+
+	t.Run("string value", func(t *testing.T) {
+		flag, err := fb.State(ctx, backends.Flag{Key: "deploy-strategy", Type: backends.TypeString, DefaultValue: "default"}, backends.User{Key: "alice"})
+		assert.NoError(t, err)
+		assert.Equal(t, "blue-green", flag.Value)
+	})
+
+	t.Run("missing flag", func(t *testing.T) {
+		flag, err := fb.State(ctx, backends.Flag{Key: "missing", DefaultValue: true}, backends.User{Key: "alice"})
+		assert.NoError(t, err)
+		assert.Equal(t, true, flag.Value)
+		assert.Equal(t, backends.ErrorFlagNotFound, flag.Reason.ErrorKind)
+	})
+
+	t.Run("all flags", func(t *testing.T) {
+		flags, err := fb.AllFlags(ctx, backends.User{Key: "alice"})
+		assert.NoError(t, err)
+		assert.Equal(t, []backends.Flag{
+			{Key: "enabled", Type: backends.TypeBool, DefaultValue: false, Value: true, Reason: &backends.Reason{Kind: backends.ReasonFallthrough}},
+			{Key: "deploy-strategy", Type: backends.TypeBool, DefaultValue: false, Value: true, Reason: &backends.Reason{Kind: backends.ReasonFallthrough}},
+		}, flags)
+	})
+
+	t.Run("one request per user", func(t *testing.T) {
+		before := len(requests)
+
+		fb.State(ctx, backends.Flag{Key: "enabled", DefaultValue: false}, backends.User{Key: "erin"})
+		fb.State(ctx, backends.Flag{Key: "deploy-strategy", Type: backends.TypeString, DefaultValue: "default"}, backends.User{Key: "erin"})
+		fb.AllFlags(ctx, backends.User{Key: "erin"})
+		assert.Len(t, requests, before+1)
+
+		fb.State(ctx, backends.Flag{Key: "enabled", DefaultValue: false}, backends.User{Key: "erin", Attributes: map[string]any{"branch": "main"}})
+		assert.Len(t, requests, before+2)
+	})
+
+	t.Run("bad key", func(t *testing.T) {
+		cfg.EnvironmentKey = "wrong"
+		other, err := CreateBackend(ctx, cfg)
+		assert.NoError(t, err)
+
+		flag, err := other.State(ctx, backends.Flag{Key: "enabled", DefaultValue: false}, backends.User{Key: "alice"})
+		assert.EqualError(t, err, `flagsmith returned 403 Forbidden: {"detail":"Invalid or missing API key"}`)
+		assert.Equal(t, false, flag.Value)
+	})
+}
+
+func TestCreateBackendErrors(t *testing.T) {
+
+	_, err := CreateBackend(context.Background(), DefaultConfig())
+	assert.ErrorContains(t, err, "no flagsmith environment key specified")
+
+	_, err = CreateBackend(context.Background(), FlagsmithConfiguration{EnvironmentKey: "client-key", LocalEvaluation: true})
+	assert.EqualError(t, err, "local evaluation needs a server-side environment key, which starts with ser.")
+}
+
+func TestConditions(t *testing.T) {
+
+	u := backends.User{Key: "alice", Attributes: map[string]any{"build": "42", "branch": "feature/login", "version": "1.4.0"}}
+
+	cases := []struct {
+		operator string
+		property string
+		value    string
+		expected bool
+	}{
+		{"EQUAL", "build", "42", true},
+		{"NOT_EQUAL", "build", "42", false},
+		{"GREATER_THAN", "build", "9", true},
+		{"LESS_THAN_INCLUSIVE", "build", "42", true},
+		{"GREATER_THAN_INCLUSIVE", "build", "43", false},
+		{"LESS_THAN", "version", "1.10.0:semver", true},
+		{"EQUAL", "version", "1.4.0:semver", true},
+		{"CONTAINS", "branch", "login", true},
+		{"NOT_CONTAINS", "branch", "login", false},
+		{"REGEX", "branch", "^feature/.*", true},
+		{"IN", "branch", "main, feature/login", true},
+		{"MODULO", "build", "10|2", true},
+		{"MODULO", "build", "10|3", false},
+		{"IS_SET", "branch", "", true},
+		{"IS_NOT_SET", "branch", "", false},
+		{"IS_NOT_SET", "missing", "", true},
+		{"NOT_EQUAL", "missing", "42", false},
+		{"EQUAL", "$.identity.identifier", "alice", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.operator, func(t *testing.T) {
+			value := tc.value
+			c := segmentCondition{Operator: tc.operator, Property: tc.property, Value: &value}
+			assert.Equal(t, tc.expected, c.matches(1, "abc_alice", u))
+		})
+	}
+}
+
+func TestHashedPercentage(t *testing.T) {
+	// values from the flagsmith engine's implementation
+	assert.Equal(t, 27.655531106221243, hashedPercentage("12", "93"))
+	assert.Equal(t, 3.800760152030406, hashedPercentage("7", "ser.abc_alice"))
+}
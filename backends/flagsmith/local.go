@@ -0,0 +1,312 @@
+package flagsmith
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"flagon/backends"
+	"math"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/launchdarkly/go-semver"
+)
+
+const (
+	ruleAll  = "ALL"
+	ruleAny  = "ANY"
+	ruleNone = "NONE"
+)
+
+// identifierProperty lets segment conditions match the identity itself
+// rather than a trait
+const identifierProperty = "$.identity.identifier"
+
+// evaluation is the feature state which applies to a user, and why
+type evaluation struct {
+	state  *featureState
+	reason *backends.Reason
+}
+
+// evaluate finds the feature state for a user in the same order as the
+// flagsmith engine: identity overrides, then segment overrides by priority,
+// then the environment's default
+func (d *environmentDocument) evaluate(key string, user backends.User) (evaluation, bool) {
+	var result evaluation
+
+	for i := range d.FeatureStates {
+		if d.FeatureStates[i].Feature.Name == key {
+			result = evaluation{
+				state:  &d.FeatureStates[i],
+				reason: &backends.Reason{Kind: backends.ReasonFallthrough},
+			}
+			break
+		}
+	}
+
+	if result.state == nil {
+		return result, false
+	}
+
+	if !result.state.Enabled {
+		result.reason = &backends.Reason{Kind: backends.ReasonOff}
+	}
+
+	var segmentOverride *featureState
+
+	for _, s := range d.Project.Segments {
+		if !s.matches(d.identityKey(user), user) {
+			continue
+		}
+
+		for i := range s.FeatureStates {
+			fs := &s.FeatureStates[i]
+			if fs.Feature.Name != key {
+				continue
+			}
+
+			if segmentOverride == nil || fs.priority() < segmentOverride.priority() {
+				segmentOverride = fs
+				result = evaluation{
+					state:  fs,
+					reason: &backends.Reason{Kind: backends.ReasonRuleMatch, RuleID: s.Name},
+				}
+			}
+		}
+	}
+
+	for _, o := range d.IdentityOverrides {
+		if o.Identifier != user.Key || user.Key == "" {
+			continue
+		}
+
+		for i := range o.IdentityFeatures {
+			if o.IdentityFeatures[i].Feature.Name == key {
+				result = evaluation{
+					state:  &o.IdentityFeatures[i],
+					reason: &backends.Reason{Kind: backends.ReasonTargetMatch},
+				}
+			}
+		}
+	}
+
+	return result, true
+}
+
+// identityKey is how the flagsmith sdks identify a user which only exists
+// locally, for percentage splits
+func (d *environmentDocument) identityKey(user backends.User) string {
+	return d.ApiKey + "_" + user.Key
+}
+
+func (fs *featureState) priority() int {
+	if fs.FeatureSegment == nil || fs.FeatureSegment.Priority == nil {
+		return math.MaxInt
+	}
+
+	return *fs.FeatureSegment.Priority
+}
+
+// multivariateValue picks one of the feature's variations for the identity,
+// returning the control value and -1 if the identity is not in a variation
+func (fs *featureState) multivariateValue(identityKey string) (any, int) {
+	if len(fs.Multivariate) == 0 {
+		return fs.Value, -1
+	}
+
+	id := fs.UUID
+	if fs.DjangoID != nil {
+		id = strconv.Itoa(*fs.DjangoID)
+	}
+
+	values := make([]multivariateValue, len(fs.Multivariate))
+	copy(values, fs.Multivariate)
+
+	sort.SliceStable(values, func(i, j int) bool {
+		if values[i].ID != nil && values[j].ID != nil {
+			return *values[i].ID < *values[j].ID
+		}
+		return values[i].UUID < values[j].UUID
+	})
+
+	percentage := hashedPercentage(id, identityKey)
+	start := 0.0
+
+	for _, v := range values {
+		limit := start + v.PercentageAllocation
+		if start <= percentage && percentage < limit {
+			for i := range fs.Multivariate {
+				if fs.Multivariate[i].ID == v.ID && fs.Multivariate[i].UUID == v.UUID {
+					return v.Option.Value, i
+				}
+			}
+		}
+		start = limit
+	}
+
+	return fs.Value, -1
+}
+
+func (s *segment) matches(identityKey string, user backends.User) bool {
+	if len(s.Rules) == 0 {
+		return false
+	}
+
+	for _, r := range s.Rules {
+		if !r.matches(s.ID, identityKey, user) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r *segmentRule) matches(segmentID int, identityKey string, user backends.User) bool {
+	if len(r.Conditions) > 0 {
+		matched := 0
+		for _, c := range r.Conditions {
+			if c.matches(segmentID, identityKey, user) {
+				matched++
+			}
+		}
+
+		switch r.Type {
+		case ruleAll:
+			if matched != len(r.Conditions) {
+				return false
+			}
+		case ruleAny:
+			if matched == 0 {
+				return false
+			}
+		case ruleNone:
+			if matched != 0 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	for _, nested := range r.Rules {
+		if !nested.matches(segmentID, identityKey, user) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c *segmentCondition) matches(segmentID int, identityKey string, user backends.User) bool {
+	expected := ""
+	if c.Value != nil {
+		expected = *c.Value
+	}
+
+	if c.Operator == "PERCENTAGE_SPLIT" {
+		split, err := strconv.ParseFloat(expected, 64)
+		return err == nil && hashedPercentage(strconv.Itoa(segmentID), identityKey) <= split
+	}
+
+	value, found := user.Attributes[c.Property]
+	actual := backends.AttributeString(value)
+	if c.Property == identifierProperty {
+		actual, found = user.Key, user.Key != ""
+	}
+
+	switch c.Operator {
+	case "IS_SET":
+		return found
+	case "IS_NOT_SET":
+		return !found
+	}
+
+	if !found {
+		return false
+	}
+
+	switch c.Operator {
+	case "EQUAL":
+		return compare(actual, expected) == 0
+	case "NOT_EQUAL":
+		return compare(actual, expected) != 0
+	case "GREATER_THAN":
+		return compare(actual, expected) > 0
+	case "GREATER_THAN_INCLUSIVE":
+		return compare(actual, expected) >= 0
+	case "LESS_THAN":
+		return compare(actual, expected) < 0
+	case "LESS_THAN_INCLUSIVE":
+		return compare(actual, expected) <= 0
+	case "CONTAINS":
+		return strings.Contains(actual, expected)
+	case "NOT_CONTAINS":
+		return !strings.Contains(actual, expected)
+	case "REGEX":
+		matched, err := regexp.MatchString(expected, actual)
+		return err == nil && matched
+	case "IN":
+		for _, v := range strings.Split(expected, ",") {
+			if strings.TrimSpace(v) == actual {
+				return true
+			}
+		}
+		return false
+	case "MODULO":
+		divisor, remainder, ok := strings.Cut(expected, "|")
+		d, errD := strconv.ParseFloat(divisor, 64)
+		r, errR := strconv.ParseFloat(remainder, 64)
+		a, errA := strconv.ParseFloat(actual, 64)
+		return ok && errD == nil && errR == nil && errA == nil && d != 0 && math.Mod(a, d) == r
+	default:
+		return false
+	}
+}
+
+// compare treats values ending with `:semver` as versions, and values which
+// are both numbers as numbers, otherwise they are compared as strings
+func compare(actual string, expected string) int {
+	if strings.HasSuffix(expected, ":semver") {
+		a, errA := semver.Parse(actual)
+		e, errE := semver.Parse(strings.TrimSuffix(expected, ":semver"))
+		if errA == nil && errE == nil {
+			return a.ComparePrecedence(e)
+		}
+	}
+
+	a, errA := strconv.ParseFloat(actual, 64)
+	e, errE := strconv.ParseFloat(expected, 64)
+	if errA == nil && errE == nil {
+		switch {
+		case a < e:
+			return -1
+		case a > e:
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	return strings.Compare(actual, expected)
+}
+
+var hashModulus = big.NewInt(9999)
+
+// hashedPercentage is the flagsmith engine's stable 0-100 value for a set of
+// ids, so flagon puts identities in the same splits as the flagsmith sdks
+func hashedPercentage(ids ...string) float64 {
+	joined := strings.Join(ids, ",")
+
+	for iterations := 1; ; iterations++ {
+		sum := md5.Sum([]byte(strings.Repeat(","+joined, iterations)[1:]))
+
+		hashed, _ := new(big.Int).SetString(hex.EncodeToString(sum[:]), 16)
+		value := float64(new(big.Int).Mod(hashed, hashModulus).Int64()) / 9998 * 100
+
+		if value != 100 {
+			return value
+		}
+	}
+}
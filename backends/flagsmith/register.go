@@ -0,0 +1,18 @@
+package flagsmith
+
+import (
+	"context"
+	"flagon/backends"
+)
+
+func init() {
+	backends.Register("flagsmith", "Flagsmith Backend", DefaultConfig, ConfigFromEnvironment,
+		func(ctx context.Context, cfg FlagsmithConfiguration) (backends.Backend, error) {
+			backend, err := CreateBackend(ctx, cfg)
+			if err != nil {
+				return nil, err
+			}
+			return backend, nil
+		},
+	)
+}
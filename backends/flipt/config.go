@@ -0,0 +1,73 @@
+package flipt
+
+import (
+	"os"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+const UrlEnvVar = "FLAGON_FLIPT_URL"
+const TokenEnvVar = "FLAGON_FLIPT_TOKEN"
+const NamespaceEnvVar = "FLAGON_FLIPT_NAMESPACE"
+const TimeoutEnvVar = "FLAGON_FLIPT_TIMEOUT"
+
+type FliptConfiguration struct {
+	Url       string
+	Token     string
+	Namespace string
+	Timeout   time.Duration
+}
+
+func (cfg *FliptConfiguration) OverrideFrom(other FliptConfiguration) {
+	if other.Url != "" {
+		cfg.Url = other.Url
+	}
+
+	if other.Token != "" {
+		cfg.Token = other.Token
+	}
+
+	if other.Namespace != "" {
+		cfg.Namespace = other.Namespace
+	}
+
+	if other.Timeout > 0 {
+		cfg.Timeout = other.Timeout
+	}
+}
+
+func (cfg *FliptConfiguration) Flags() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("Flipt Backend", pflag.ContinueOnError)
+
+	flags.StringVar(&cfg.Url, "flipt-url", "", "the url of the flipt server")
+	flags.StringVar(&cfg.Token, "flipt-token", "", "a client token to authenticate with")
+	flags.StringVar(&cfg.Namespace, "flipt-namespace", "", "the namespace to evaluate flags in")
+	flags.DurationVar(&cfg.Timeout, "flipt-timeout", 0, "timeout before failing to communicate with flipt")
+
+	return flags
+}
+
+func ConfigFromEnvironment() FliptConfiguration {
+
+	cfg := FliptConfiguration{}
+	cfg.Url = os.Getenv(UrlEnvVar)
+	cfg.Token = os.Getenv(TokenEnvVar)
+	cfg.Namespace = os.Getenv(NamespaceEnvVar)
+
+	if val := os.Getenv(TimeoutEnvVar); val != "" {
+		if timeout, err := time.ParseDuration(val); err == nil {
+			cfg.Timeout = timeout
+		}
+	}
+
+	return cfg
+}
+
+func DefaultConfig() FliptConfiguration {
+	return FliptConfiguration{
+		Url:       "http://localhost:8080",
+		Namespace: "default",
+		Timeout:   5 * time.Second,
+	}
+}
@@ -0,0 +1,53 @@
+package flipt
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadEnvironment(t *testing.T) {
+
+	os.Setenv(UrlEnvVar, "https://flipt.example.com")
+	os.Setenv(TokenEnvVar, "secret")
+	os.Setenv(NamespaceEnvVar, "tooling")
+	os.Setenv(TimeoutEnvVar, "3s")
+
+	cfg := ConfigFromEnvironment()
+
+	assert.Equal(t, "https://flipt.example.com", cfg.Url)
+	assert.Equal(t, "secret", cfg.Token)
+	assert.Equal(t, "tooling", cfg.Namespace)
+	assert.Equal(t, 3*time.Second, cfg.Timeout)
+}
+
+func TestFlags(t *testing.T) {
+
+	cfg := FliptConfiguration{}
+	flags := cfg.Flags()
+
+	assert.NoError(t, flags.Parse([]string{
+		"--flipt-url", "http://flipt:8080",
+		"--flipt-token", "token",
+		"--flipt-namespace", "pipelines",
+		"--flipt-timeout", "1s",
+	}))
+
+	assert.Equal(t, "http://flipt:8080", cfg.Url)
+	assert.Equal(t, "token", cfg.Token)
+	assert.Equal(t, "pipelines", cfg.Namespace)
+	assert.Equal(t, time.Second, cfg.Timeout)
+}
+
+func TestOverridingValues(t *testing.T) {
+
+	base := DefaultConfig()
+	base.OverrideFrom(FliptConfiguration{})
+	assert.Equal(t, DefaultConfig(), base)
+
+	base.OverrideFrom(FliptConfiguration{Namespace: "tooling"})
+	assert.Equal(t, "http://localhost:8080", base.Url)
+	assert.Equal(t, "tooling", base.Namespace)
+}
@@ -0,0 +1,336 @@
+package flipt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flagon/backends"
+	"flagon/tracing"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+var tr = otel.Tracer("backend.flipt")
+
+const (
+	booleanPath = "/evaluate/v1/boolean"
+	variantPath = "/evaluate/v1/variant"
+	batchPath   = "/evaluate/v1/batch"
+	flagsPath   = "/api/v1/namespaces/%s/flags"
+)
+
+const (
+	reasonMatch        = "MATCH_EVALUATION_REASON"
+	reasonFlagDisabled = "FLAG_DISABLED_EVALUATION_REASON"
+
+	flagTypeBoolean = "BOOLEAN_FLAG_TYPE"
+
+	responseTypeBoolean = "BOOLEAN_EVALUATION_RESPONSE_TYPE"
+	responseTypeVariant = "VARIANT_EVALUATION_RESPONSE_TYPE"
+)
+
+var errFlagNotFound = errors.New("flag not found")
+
+type evaluationRequest struct {
+	NamespaceKey string            `json:"namespaceKey"`
+	FlagKey      string            `json:"flagKey"`
+	EntityID     string            `json:"entityId"`
+	Context      map[string]string `json:"context"`
+}
+
+type booleanResponse struct {
+	Enabled bool   `json:"enabled"`
+	Reason  string `json:"reason"`
+}
+
+type variantResponse struct {
+	Match             bool     `json:"match"`
+	SegmentKeys       []string `json:"segmentKeys"`
+	Reason            string   `json:"reason"`
+	VariantKey        string   `json:"variantKey"`
+	VariantAttachment string   `json:"variantAttachment"`
+}
+
+type batchRequest struct {
+	Requests []evaluationRequest `json:"requests"`
+}
+
+type batchResponse struct {
+	Responses []struct {
+		Type            string           `json:"type"`
+		BooleanResponse *booleanResponse `json:"booleanResponse"`
+		VariantResponse *variantResponse `json:"variantResponse"`
+	} `json:"responses"`
+}
+
+type flagList struct {
+	Flags []struct {
+		Key  string `json:"key"`
+		Type string `json:"type"`
+	} `json:"flags"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+// errorResponse is returned by flipt's grpc gateway when a request fails
+type errorResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// FliptBackend evaluates flags with flipt's evaluation api, using boolean
+// evaluation for bool flags, and variant evaluation for all other types
+type FliptBackend struct {
+	cfg     FliptConfiguration
+	client  *http.Client
+	baseUrl string
+}
+
+func CreateBackend(ctx context.Context, cfg FliptConfiguration) (*FliptBackend, error) {
+	ctx, span := tr.Start(ctx, "create_backend")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("flipt.url", cfg.Url),
+		attribute.String("flipt.namespace", cfg.Namespace),
+	)
+
+	if cfg.Url == "" {
+		return nil, tracing.Errorf(span, "no flipt url specified, use --flipt-url or $%s", UrlEnvVar)
+	}
+
+	return &FliptBackend{
+		cfg:     cfg,
+		client:  &http.Client{Timeout: cfg.Timeout},
+		baseUrl: strings.TrimSuffix(cfg.Url, "/"),
+	}, nil
+}
+
+func (fb *FliptBackend) Close(ctx context.Context) error {
+	fb.client.CloseIdleConnections()
+	return nil
+}
+
+func (fb *FliptBackend) State(ctx context.Context, flag backends.Flag, user backends.User) (backends.Flag, error) {
+	ctx, span := tr.Start(ctx, "state")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("flag.key", flag.Key))
+
+	flag.Value = flag.DefaultValue
+
+	var err error
+	if flag.ValueType() == backends.TypeBool {
+		res := booleanResponse{}
+		if err = fb.call(ctx, http.MethodPost, booleanPath, fb.createRequest(flag.Key, user), &res); err == nil {
+			flag = applyBoolean(flag, res)
+		}
+	} else {
+		res := variantResponse{}
+		if err = fb.call(ctx, http.MethodPost, variantPath, fb.createRequest(flag.Key, user), &res); err == nil {
+			flag, err = applyVariant(flag, res)
+		}
+	}
+
+	if errors.Is(err, errFlagNotFound) {
+		flag.Reason = &backends.Reason{Kind: backends.ReasonError, ErrorKind: backends.ErrorFlagNotFound}
+		span.SetAttributes(attribute.String("reason", flag.Reason.String()))
+		return flag, nil
+	}
+
+	if err != nil {
+		if flag.Reason == nil {
+			flag.Reason = &backends.Reason{Kind: backends.ReasonError}
+		}
+		return flag, tracing.Error(span, err)
+	}
+
+	span.SetAttributes(attribute.String("reason", flag.Reason.String()))
+
+	return flag, nil
+}
+
+func (fb *FliptBackend) AllFlags(ctx context.Context, user backends.User) ([]backends.Flag, error) {
+	ctx, span := tr.Start(ctx, "all_flags")
+	defer span.End()
+
+	list := flagList{}
+	requests := []evaluationRequest{}
+	flags := []backends.Flag{}
+
+	for {
+		path := fmt.Sprintf(flagsPath, url.PathEscape(fb.cfg.Namespace))
+		if list.NextPageToken != "" {
+			path += "?pageToken=" + url.QueryEscape(list.NextPageToken)
+		}
+
+		list = flagList{}
+		if err := fb.call(ctx, http.MethodGet, path, nil, &list); err != nil {
+			if errors.Is(err, errFlagNotFound) {
+				return nil, tracing.Errorf(span, "the flipt namespace %s was not found", fb.cfg.Namespace)
+			}
+			return nil, tracing.Error(span, err)
+		}
+
+		for _, f := range list.Flags {
+			flag := backends.Flag{Key: f.Key, Type: backends.TypeString}
+			if f.Type == flagTypeBoolean {
+				flag.Type = backends.TypeBool
+			}
+
+			flags = append(flags, flag)
+			requests = append(requests, fb.createRequest(f.Key, user))
+		}
+
+		if list.NextPageToken == "" {
+			break
+		}
+	}
+
+	if len(requests) == 0 {
+		return flags, nil
+	}
+
+	res := batchResponse{}
+	if err := fb.call(ctx, http.MethodPost, batchPath, batchRequest{Requests: requests}, &res); err != nil {
+		return nil, tracing.Error(span, err)
+	}
+
+	for i, r := range res.Responses {
+		if i >= len(flags) {
+			break
+		}
+
+		switch {
+		case r.Type == responseTypeBoolean && r.BooleanResponse != nil:
+			flags[i] = applyBoolean(flags[i], *r.BooleanResponse)
+		case r.Type == responseTypeVariant && r.VariantResponse != nil:
+			flags[i], _ = applyVariant(flags[i], *r.VariantResponse)
+		default:
+			flags[i].Reason = &backends.Reason{Kind: backends.ReasonError}
+		}
+	}
+
+	span.SetAttributes(attribute.Int("flags.count", len(flags)))
+
+	return flags, nil
+}
+
+// createRequest uses the user's key as the entity id, which flipt uses for
+// percentage rollouts and distributions, and the attributes as the context
+func (fb *FliptBackend) createRequest(key string, user backends.User) evaluationRequest {
+	// flipt's context only has string values, and flipt has no private
+	// attributes, so they are left out rather than sent to flipt
+	context := backends.StringAttributes(backends.PublicAttributes(user.Attributes, user.PrivateAttributes))
+
+	return evaluationRequest{
+		NamespaceKey: fb.cfg.Namespace,
+		FlagKey:      key,
+		EntityID:     user.Key,
+		Context:      context,
+	}
+}
+
+func applyBoolean(flag backends.Flag, res booleanResponse) backends.Flag {
+	flag.Value = res.Enabled
+	flag.Reason = createReason(res.Reason, nil)
+
+	return flag
+}
+
+// applyVariant uses the variant key for string flags, and the variant's
+// attachment for other types
+func applyVariant(flag backends.Flag, res variantResponse) (backends.Flag, error) {
+	flag.Reason = createReason(res.Reason, res.SegmentKeys)
+
+	if res.VariantKey == "" {
+		return flag, nil
+	}
+
+	if flag.ValueType() == backends.TypeString {
+		flag.Value = res.VariantKey
+		return flag, nil
+	}
+
+	if res.VariantAttachment == "" {
+		return flag, nil
+	}
+
+	value, err := backends.ParseValue(flag.ValueType(), res.VariantAttachment)
+	if err != nil {
+		flag.Reason = &backends.Reason{Kind: backends.ReasonError, ErrorKind: backends.ErrorWrongType}
+		return flag, fmt.Errorf("the attachment of variant %s cannot be used as a %s: %w", res.VariantKey, flag.ValueType(), err)
+	}
+
+	flag.Value = value
+	return flag, nil
+}
+
+func createReason(reason string, segmentKeys []string) *backends.Reason {
+	switch reason {
+	case reasonMatch:
+		r := &backends.Reason{Kind: backends.ReasonRuleMatch}
+		if len(segmentKeys) > 0 {
+			r.RuleID = strings.Join(segmentKeys, ",")
+		}
+		return r
+	case reasonFlagDisabled:
+		return &backends.Reason{Kind: backends.ReasonOff}
+	default:
+		return &backends.Reason{Kind: backends.ReasonFallthrough}
+	}
+}
+
+func (fb *FliptBackend) call(ctx context.Context, method string, path string, body any, response any) error {
+
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, fb.baseUrl+path, reader)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	if fb.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+fb.cfg.Token)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	res, err := fb.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to reach flipt: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return errFlagNotFound
+	}
+
+	if res.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(res.Body)
+
+		e := errorResponse{}
+		if json.Unmarshal(msg, &e) == nil && e.Message != "" {
+			return fmt.Errorf("flipt returned %s: %s", res.Status, e.Message)
+		}
+
+		return fmt.Errorf("flipt returned %s: %s", res.Status, bytes.TrimSpace(msg))
+	}
+
+	return json.NewDecoder(res.Body).Decode(response)
+}
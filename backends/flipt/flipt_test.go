@@ -0,0 +1,237 @@
+package flipt
+
+import (
+	"context"
+	"encoding/json"
+	"flagon/backends"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeFlipt evaluates flags in the "tooling" namespace, enabling them for
+// entities on the main branch
+type fakeFlipt struct {
+	requests []evaluationRequest
+}
+
+func (f *fakeFlipt) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		writeJson(w, http.StatusUnauthorized, errorResponse{Code: 16, Message: "request was not authenticated"})
+		return
+	}
+
+	switch r.URL.Path {
+	case "/api/v1/namespaces/tooling/flags":
+		if r.URL.Query().Get("pageToken") == "" {
+			writeJson(w, http.StatusOK, map[string]any{
+				"flags":         []map[string]any{{"key": "enabled", "type": "BOOLEAN_FLAG_TYPE"}},
+				"nextPageToken": "page2",
+			})
+			return
+		}
+		writeJson(w, http.StatusOK, map[string]any{
+			"flags": []map[string]any{{"key": "deploy-strategy", "type": "VARIANT_FLAG_TYPE"}},
+		})
+
+	case booleanPath, variantPath:
+		req := evaluationRequest{}
+		json.NewDecoder(r.Body).Decode(&req)
+		f.requests = append(f.requests, req)
+
+		if res, found := f.evaluate(r.URL.Path, req); found {
+			writeJson(w, http.StatusOK, res)
+		} else {
+			writeJson(w, http.StatusNotFound, errorResponse{Code: 5, Message: "flag not found"})
+		}
+
+	case batchPath:
+		req := batchRequest{}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		responses := []map[string]any{}
+		for _, br := range req.Requests {
+			if br.FlagKey == "enabled" {
+				res, _ := f.evaluate(booleanPath, br)
+				responses = append(responses, map[string]any{"type": responseTypeBoolean, "booleanResponse": res})
+			} else {
+				res, _ := f.evaluate(variantPath, br)
+				responses = append(responses, map[string]any{"type": responseTypeVariant, "variantResponse": res})
+			}
+		}
+		writeJson(w, http.StatusOK, map[string]any{"responses": responses})
+
+	default:
+		writeJson(w, http.StatusNotFound, errorResponse{Code: 5, Message: "not found"})
+	}
+}
+
+func (f *fakeFlipt) evaluate(path string, req evaluationRequest) (any, bool) {
+	onMain := req.Context["branch"] == "main"
+
+	if req.NamespaceKey != "tooling" {
+		return nil, false
+	}
+
+	switch req.FlagKey {
+	case "enabled":
+		if onMain {
+			return booleanResponse{Enabled: true, Reason: reasonMatch}, true
+		}
+		return booleanResponse{Enabled: false, Reason: "DEFAULT_EVALUATION_REASON"}, true
+
+	case "disabled":
+		if path == booleanPath {
+			return booleanResponse{Enabled: false, Reason: "DEFAULT_EVALUATION_REASON"}, true
+		}
+		return variantResponse{Reason: reasonFlagDisabled}, true
+
+	case "deploy-strategy":
+		if onMain {
+			return variantResponse{Match: true, Reason: reasonMatch, SegmentKeys: []string{"main-branch"}, VariantKey: "blue-green", VariantAttachment: `{"retries": 3}`}, true
+		}
+		return variantResponse{Reason: "DEFAULT_EVALUATION_REASON"}, true
+
+	default:
+		return nil, false
+	}
+}
+
+func writeJson(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func createBackend(t *testing.T, server *fakeFlipt, token string) *FliptBackend {
+	s := httptest.NewServer(server)
+	t.Cleanup(s.Close)
+
+	cfg := DefaultConfig()
+	cfg.Url = s.URL + "/"
+	cfg.Token = token
+	cfg.Namespace = "tooling"
+
+	fb, err := CreateBackend(context.Background(), cfg)
+	assert.NoError(t, err)
+
+	return fb
+}
+
+func TestState(t *testing.T) {
+
+	server := &fakeFlipt{}
+	fb := createBackend(t, server, "secret")
+	ctx := context.Background()
+
+	main := backends.User{Key: "alice", Attributes: map[string]any{"branch": "main"}}
+	dev := backends.User{Key: "alice", Attributes: map[string]any{"branch": "dev"}}
+
+	t.Run("boolean match", func(t *testing.T) {
+		flag, err := fb.State(ctx, backends.Flag{Key: "enabled", DefaultValue: false}, main)
+		assert.NoError(t, err)
+		assert.Equal(t, true, flag.Value)
+		assert.Equal(t, backends.ReasonRuleMatch, flag.Reason.Kind)
+
+		assert.Equal(t, evaluationRequest{
+			NamespaceKey: "tooling",
+			FlagKey:      "enabled",
+			EntityID:     "alice",
+			Context:      map[string]string{"branch": "main"},
+		}, server.requests[len(server.requests)-1])
+	})
+
+	t.Run("private attributes are not sent", func(t *testing.T) {
+		user := backends.User{Key: "alice", Attributes: map[string]any{"branch": "main", "email": "alice@example.com"}, PrivateAttributes: []string{"email"}}
+
+		_, err := fb.State(ctx, backends.Flag{Key: "enabled", DefaultValue: false}, user)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"branch": "main"}, server.requests[len(server.requests)-1].Context)
+	})
+
+	t.Run("boolean default", func(t *testing.T) {
+		flag, err := fb.State(ctx, backends.Flag{Key: "enabled", DefaultValue: true}, dev)
+		assert.NoError(t, err)
+		assert.Equal(t, false, flag.Value)
+		assert.Equal(t, backends.ReasonFallthrough, flag.Reason.Kind)
+	})
+
+	t.Run("variant key", func(t *testing.T) {
+		flag, err := fb.State(ctx, backends.Flag{Key: "deploy-strategy", Type: backends.TypeString, DefaultValue: "rolling"}, main)
+		assert.NoError(t, err)
+		assert.Equal(t, "blue-green", flag.Value)
+		assert.Equal(t, &backends.Reason{Kind: backends.ReasonRuleMatch, RuleID: "main-branch"}, flag.Reason)
+	})
+
+	t.Run("variant attachment", func(t *testing.T) {
+		flag, err := fb.State(ctx, backends.Flag{Key: "deploy-strategy", Type: backends.TypeJSON}, main)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"retries": 3.0}, flag.Value)
+	})
+
+	t.Run("attachment of the wrong type", func(t *testing.T) {
+		flag, err := fb.State(ctx, backends.Flag{Key: "deploy-strategy", Type: backends.TypeNumber, DefaultValue: 1.0}, main)
+		assert.Error(t, err)
+		assert.Equal(t, 1.0, flag.Value)
+		assert.Equal(t, backends.ErrorWrongType, flag.Reason.ErrorKind)
+	})
+
+	t.Run("no variant match", func(t *testing.T) {
+		flag, err := fb.State(ctx, backends.Flag{Key: "deploy-strategy", Type: backends.TypeString, DefaultValue: "rolling"}, dev)
+		assert.NoError(t, err)
+		assert.Equal(t, "rolling", flag.Value)
+		assert.Equal(t, backends.ReasonFallthrough, flag.Reason.Kind)
+	})
+
+	t.Run("disabled variant flag", func(t *testing.T) {
+		flag, err := fb.State(ctx, backends.Flag{Key: "disabled", Type: backends.TypeString, DefaultValue: "rolling"}, main)
+		assert.NoError(t, err)
+		assert.Equal(t, "rolling", flag.Value)
+		assert.Equal(t, backends.ReasonOff, flag.Reason.Kind)
+	})
+
+	t.Run("missing flag", func(t *testing.T) {
+		flag, err := fb.State(ctx, backends.Flag{Key: "missing", DefaultValue: true}, main)
+		assert.NoError(t, err)
+		assert.Equal(t, true, flag.Value)
+		assert.Equal(t, backends.ErrorFlagNotFound, flag.Reason.ErrorKind)
+	})
+}
+
+func TestAllFlags(t *testing.T) {
+
+	fb := createBackend(t, &fakeFlipt{}, "secret")
+
+	flags, err := fb.AllFlags(context.Background(), backends.User{Key: "alice", Attributes: map[string]any{"branch": "main"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []backends.Flag{
+		{Key: "enabled", Type: backends.TypeBool, Value: true, Reason: &backends.Reason{Kind: backends.ReasonRuleMatch}},
+		{Key: "deploy-strategy", Type: backends.TypeString, Value: "blue-green", Reason: &backends.Reason{Kind: backends.ReasonRuleMatch, RuleID: "main-branch"}},
+	}, flags)
+}
+
+func TestErrors(t *testing.T) {
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		fb := createBackend(t, &fakeFlipt{}, "wrong")
+
+		flag, err := fb.State(context.Background(), backends.Flag{Key: "enabled", DefaultValue: true}, backends.User{Key: "alice"})
+		assert.EqualError(t, err, "flipt returned 401 Unauthorized: request was not authenticated")
+		assert.Equal(t, true, flag.Value)
+	})
+
+	t.Run("missing namespace", func(t *testing.T) {
+		fb := createBackend(t, &fakeFlipt{}, "secret")
+		fb.cfg.Namespace = "other"
+
+		_, err := fb.AllFlags(context.Background(), backends.User{Key: "alice"})
+		assert.EqualError(t, err, "the flipt namespace other was not found")
+	})
+
+	t.Run("no url", func(t *testing.T) {
+		_, err := CreateBackend(context.Background(), FliptConfiguration{})
+		assert.ErrorContains(t, err, "no flipt url specified")
+	})
+}
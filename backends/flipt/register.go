@@ -0,0 +1,18 @@
+package flipt
+
+import (
+	"context"
+	"flagon/backends"
+)
+
+func init() {
+	backends.Register("flipt", "Flipt Backend", DefaultConfig, ConfigFromEnvironment,
+		func(ctx context.Context, cfg FliptConfiguration) (backends.Backend, error) {
+			backend, err := CreateBackend(ctx, cfg)
+			if err != nil {
+				return nil, err
+			}
+			return backend, nil
+		},
+	)
+}
@@ -0,0 +1,335 @@
+package growthbook
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// evalCondition evaluates growthbook's mongo style targeting conditions.
+// Attributes from the command line are often strings, so they are converted to
+// the type of the value they are compared with when possible.
+func evalCondition(attributes map[string]any, condition map[string]any) bool {
+	for key, value := range condition {
+		switch key {
+		case "$or":
+			if !evalOr(attributes, value) {
+				return false
+			}
+		case "$nor":
+			if evalOr(attributes, value) {
+				return false
+			}
+		case "$and":
+			for _, c := range asConditions(value) {
+				if !evalCondition(attributes, c) {
+					return false
+				}
+			}
+		case "$not":
+			if c, ok := value.(map[string]any); !ok || evalCondition(attributes, c) {
+				return false
+			}
+		default:
+			if !evalConditionValue(value, getPath(attributes, key)) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func evalOr(attributes map[string]any, value any) bool {
+	conditions := asConditions(value)
+	if len(conditions) == 0 {
+		return true
+	}
+
+	for _, c := range conditions {
+		if evalCondition(attributes, c) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func asConditions(value any) []map[string]any {
+	list, _ := value.([]any)
+	conditions := make([]map[string]any, 0, len(list))
+
+	for _, item := range list {
+		if c, ok := item.(map[string]any); ok {
+			conditions = append(conditions, c)
+		}
+	}
+
+	return conditions
+}
+
+// getPath supports dotted paths into json attributes
+func getPath(attributes map[string]any, path string) any {
+	var current any = attributes
+
+	for _, part := range strings.Split(path, ".") {
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil
+		}
+		current = m[part]
+	}
+
+	return current
+}
+
+func evalConditionValue(expected any, actual any) bool {
+	if ops, ok := expected.(map[string]any); ok && isOperatorObject(ops) {
+		for op, value := range ops {
+			if !evalOperator(op, actual, value) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return equal(actual, expected)
+}
+
+func isOperatorObject(m map[string]any) bool {
+	if len(m) == 0 {
+		return false
+	}
+
+	for key := range m {
+		if !strings.HasPrefix(key, "$") {
+			return false
+		}
+	}
+
+	return true
+}
+
+func evalOperator(op string, actual any, expected any) bool {
+	switch op {
+	case "$eq":
+		return equal(actual, expected)
+	case "$ne":
+		return !equal(actual, expected)
+	case "$lt", "$lte", "$gt", "$gte":
+		c, ok := compare(actual, expected)
+		if !ok {
+			return false
+		}
+		switch op {
+		case "$lt":
+			return c < 0
+		case "$lte":
+			return c <= 0
+		case "$gt":
+			return c > 0
+		default:
+			return c >= 0
+		}
+	case "$veq", "$vne", "$vlt", "$vlte", "$vgt", "$vgte":
+		a, ok1 := actual.(string)
+		e, ok2 := expected.(string)
+		if !ok1 || !ok2 {
+			return false
+		}
+		c := strings.Compare(paddedVersion(a), paddedVersion(e))
+		switch op {
+		case "$veq":
+			return c == 0
+		case "$vne":
+			return c != 0
+		case "$vlt":
+			return c < 0
+		case "$vlte":
+			return c <= 0
+		case "$vgt":
+			return c > 0
+		default:
+			return c >= 0
+		}
+	case "$regex":
+		pattern, ok1 := expected.(string)
+		value, ok2 := actual.(string)
+		if !ok1 || !ok2 {
+			return false
+		}
+		matched, err := regexp.MatchString(pattern, value)
+		return err == nil && matched
+	case "$in":
+		return in(actual, expected)
+	case "$nin":
+		return !in(actual, expected)
+	case "$exists":
+		exists, _ := expected.(bool)
+		return (actual != nil) == exists
+	case "$type":
+		return typeOf(actual) == expected
+	case "$not":
+		return !evalConditionValue(expected, actual)
+	case "$size":
+		list, ok := actual.([]any)
+		return ok && evalConditionValue(expected, float64(len(list)))
+	case "$elemMatch":
+		list, ok := actual.([]any)
+		if !ok {
+			return false
+		}
+		for _, item := range list {
+			if c, isCondition := expected.(map[string]any); isCondition && !isOperatorObject(c) {
+				if m, isMap := item.(map[string]any); isMap && evalCondition(m, c) {
+					return true
+				}
+			} else if evalConditionValue(expected, item) {
+				return true
+			}
+		}
+		return false
+	case "$all":
+		list, ok1 := actual.([]any)
+		values, ok2 := expected.([]any)
+		if !ok1 || !ok2 {
+			return false
+		}
+		for _, v := range values {
+			found := false
+			for _, item := range list {
+				found = found || evalConditionValue(v, item)
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+func in(actual any, expected any) bool {
+	values, ok := expected.([]any)
+	if !ok {
+		return false
+	}
+
+	// an array attribute matches if any of its items are in the values
+	if list, isList := actual.([]any); isList {
+		for _, item := range list {
+			if in(item, values) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, v := range values {
+		if equal(actual, v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// convert changes a string attribute into the type of the value it is
+// being compared with
+func convert(actual any, expected any) any {
+	s, ok := actual.(string)
+	if !ok {
+		return actual
+	}
+
+	switch expected.(type) {
+	case float64:
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	case bool:
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	}
+
+	return actual
+}
+
+func equal(actual any, expected any) bool {
+	return reflect.DeepEqual(convert(actual, expected), expected)
+}
+
+func compare(actual any, expected any) (int, bool) {
+	switch e := expected.(type) {
+	case float64:
+		a, ok := convert(actual, expected).(float64)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case a < e:
+			return -1, true
+		case a > e:
+			return 1, true
+		default:
+			return 0, true
+		}
+	case string:
+		a, ok := actual.(string)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(a, e), true
+	default:
+		return 0, false
+	}
+}
+
+func typeOf(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return "unknown"
+	}
+}
+
+var (
+	versionPrefix = regexp.MustCompile(`^[vV]`)
+	versionBuild  = regexp.MustCompile(`\+.*$`)
+	versionSplit  = regexp.MustCompile(`[-.]`)
+	versionNumber = regexp.MustCompile(`^[0-9]+$`)
+)
+
+// paddedVersion makes versions comparable as strings, in the same way as the
+// growthbook sdks
+func paddedVersion(version string) string {
+	version = versionBuild.ReplaceAllString(versionPrefix.ReplaceAllString(version, ""), "")
+	parts := versionSplit.Split(version, -1)
+
+	if len(parts) == 3 {
+		parts = append(parts, "~")
+	}
+
+	for i, p := range parts {
+		if versionNumber.MatchString(p) {
+			parts[i] = fmt.Sprintf("%5s", p)
+		}
+	}
+
+	return strings.Join(parts, "-")
+}
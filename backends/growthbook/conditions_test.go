@@ -0,0 +1,70 @@
+package growthbook
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConditions(t *testing.T) {
+
+	attributes := map[string]any{
+		"id":      "alice",
+		"branch":  "feature/login",
+		"build":   "42",
+		"version": "1.4.0",
+		"beta":    "true",
+		"tags":    []any{"mobile", "ios"},
+		"company": map[string]any{"plan": "enterprise"},
+	}
+
+	cases := []struct {
+		condition string
+		expected  bool
+	}{
+		{`{}`, true},
+		{`{ "id": "alice" }`, true},
+		{`{ "id": "bob" }`, false},
+		{`{ "build": 42 }`, true},
+		{`{ "build": { "$gt": 40, "$lte": 42 } }`, true},
+		{`{ "build": { "$lt": 42 } }`, false},
+		{`{ "beta": true }`, true},
+		{`{ "branch": { "$regex": "^feature/" } }`, true},
+		{`{ "branch": { "$in": [ "main", "develop" ] } }`, false},
+		{`{ "branch": { "$nin": [ "main", "develop" ] } }`, true},
+		{`{ "tags": { "$in": [ "ios", "android" ] } }`, true},
+		{`{ "tags": { "$all": [ "ios", "mobile" ] } }`, true},
+		{`{ "tags": { "$size": 2 } }`, true},
+		{`{ "tags": { "$elemMatch": { "$eq": "ios" } } }`, true},
+		{`{ "missing": { "$exists": false } }`, true},
+		{`{ "branch": { "$exists": true } }`, true},
+		{`{ "branch": { "$type": "string" } }`, true},
+		{`{ "company.plan": "enterprise" }`, true},
+		{`{ "version": { "$vgt": "1.3.10" } }`, true},
+		{`{ "version": { "$vlt": "1.4.0-beta" } }`, false},
+		{`{ "version": { "$veq": "v1.4.0+build.1" } }`, true},
+		{`{ "$or": [ { "id": "bob" }, { "build": 42 } ] }`, true},
+		{`{ "$nor": [ { "id": "bob" }, { "build": 41 } ] }`, true},
+		{`{ "$and": [ { "id": "alice" }, { "build": 41 } ] }`, false},
+		{`{ "$not": { "id": "alice" } }`, false},
+		{`{ "branch": { "$not": { "$regex": "^main" } } }`, true},
+		{`{ "id": { "$unknown": "alice" } }`, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.condition, func(t *testing.T) {
+			condition := map[string]any{}
+			assert.NoError(t, json.Unmarshal([]byte(tc.condition), &condition))
+
+			assert.Equal(t, tc.expected, evalCondition(attributes, condition))
+		})
+	}
+}
+
+func TestPaddedVersion(t *testing.T) {
+	assert.Less(t, paddedVersion("1.2.3-alpha"), paddedVersion("1.2.3"))
+	assert.Less(t, paddedVersion("1.2.3-alpha"), paddedVersion("1.2.3-beta"))
+	assert.Less(t, paddedVersion("1.9.0"), paddedVersion("1.10.0"))
+	assert.Equal(t, paddedVersion("v1.2.3"), paddedVersion("1.2.3+build"))
+}
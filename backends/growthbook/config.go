@@ -0,0 +1,80 @@
+package growthbook
+
+import (
+	"os"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+const ApiHostEnvVar = "FLAGON_GROWTHBOOK_API_HOST"
+const ClientKeyEnvVar = "FLAGON_GROWTHBOOK_CLIENT_KEY"
+const DecryptionKeyEnvVar = "FLAGON_GROWTHBOOK_DECRYPTION_KEY"
+const FileEnvVar = "FLAGON_GROWTHBOOK_FILE"
+const TimeoutEnvVar = "FLAGON_GROWTHBOOK_TIMEOUT"
+
+type GrowthbookConfiguration struct {
+	ApiHost       string
+	ClientKey     string
+	DecryptionKey string
+	File          string
+	Timeout       time.Duration
+}
+
+func (cfg *GrowthbookConfiguration) OverrideFrom(other GrowthbookConfiguration) {
+	if other.ApiHost != "" {
+		cfg.ApiHost = other.ApiHost
+	}
+
+	if other.ClientKey != "" {
+		cfg.ClientKey = other.ClientKey
+	}
+
+	if other.DecryptionKey != "" {
+		cfg.DecryptionKey = other.DecryptionKey
+	}
+
+	if other.File != "" {
+		cfg.File = other.File
+	}
+
+	if other.Timeout > 0 {
+		cfg.Timeout = other.Timeout
+	}
+}
+
+func (cfg *GrowthbookConfiguration) Flags() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("GrowthBook Backend", pflag.ContinueOnError)
+
+	flags.StringVar(&cfg.ApiHost, "growthbook-api-host", "", "the growthbook api host to download features from")
+	flags.StringVar(&cfg.ClientKey, "growthbook-client-key", "", "the sdk connection's client key")
+	flags.StringVar(&cfg.DecryptionKey, "growthbook-decryption-key", "", "the key to decrypt an encrypted features payload with")
+	flags.StringVar(&cfg.File, "growthbook-file", "", "read the features payload from this file rather than the api")
+	flags.DurationVar(&cfg.Timeout, "growthbook-timeout", 0, "timeout before failing to communicate with growthbook")
+
+	return flags
+}
+
+func ConfigFromEnvironment() GrowthbookConfiguration {
+
+	cfg := GrowthbookConfiguration{}
+	cfg.ApiHost = os.Getenv(ApiHostEnvVar)
+	cfg.ClientKey = os.Getenv(ClientKeyEnvVar)
+	cfg.DecryptionKey = os.Getenv(DecryptionKeyEnvVar)
+	cfg.File = os.Getenv(FileEnvVar)
+
+	if val := os.Getenv(TimeoutEnvVar); val != "" {
+		if timeout, err := time.ParseDuration(val); err == nil {
+			cfg.Timeout = timeout
+		}
+	}
+
+	return cfg
+}
+
+func DefaultConfig() GrowthbookConfiguration {
+	return GrowthbookConfiguration{
+		ApiHost: "https://cdn.growthbook.io",
+		Timeout: 5 * time.Second,
+	}
+}
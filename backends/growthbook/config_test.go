@@ -0,0 +1,58 @@
+package growthbook
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadEnvironment(t *testing.T) {
+
+	os.Setenv(ApiHostEnvVar, "https://growthbook.example.com")
+	os.Setenv(ClientKeyEnvVar, "sdk-abc")
+	os.Setenv(DecryptionKeyEnvVar, "secret")
+	os.Setenv(FileEnvVar, "features.json")
+	os.Setenv(TimeoutEnvVar, "3s")
+
+	cfg := ConfigFromEnvironment()
+
+	assert.Equal(t, "https://growthbook.example.com", cfg.ApiHost)
+	assert.Equal(t, "sdk-abc", cfg.ClientKey)
+	assert.Equal(t, "secret", cfg.DecryptionKey)
+	assert.Equal(t, "features.json", cfg.File)
+	assert.Equal(t, 3*time.Second, cfg.Timeout)
+}
+
+func TestFlags(t *testing.T) {
+
+	cfg := GrowthbookConfiguration{}
+	flags := cfg.Flags()
+
+	assert.NoError(t, flags.Parse([]string{
+		"--growthbook-api-host", "http://localhost:3100",
+		"--growthbook-client-key", "sdk-123",
+		"--growthbook-decryption-key", "key",
+		"--growthbook-file", "other.json",
+		"--growthbook-timeout", "1s",
+	}))
+
+	assert.Equal(t, "http://localhost:3100", cfg.ApiHost)
+	assert.Equal(t, "sdk-123", cfg.ClientKey)
+	assert.Equal(t, "key", cfg.DecryptionKey)
+	assert.Equal(t, "other.json", cfg.File)
+	assert.Equal(t, time.Second, cfg.Timeout)
+}
+
+func TestOverridingValues(t *testing.T) {
+
+	base := DefaultConfig()
+	base.OverrideFrom(GrowthbookConfiguration{})
+	assert.Equal(t, DefaultConfig(), base)
+
+	base.OverrideFrom(GrowthbookConfiguration{ClientKey: "sdk-abc", File: "features.json"})
+	assert.Equal(t, "https://cdn.growthbook.io", base.ApiHost)
+	assert.Equal(t, "sdk-abc", base.ClientKey)
+	assert.Equal(t, "features.json", base.File)
+}
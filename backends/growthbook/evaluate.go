@@ -0,0 +1,289 @@
+package growthbook
+
+import (
+	"encoding/json"
+	"flagon/backends"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+)
+
+// result is the value of a feature for a set of attributes, and why
+type result struct {
+	value     any
+	reason    *backends.Reason
+	variation *int
+}
+
+// evaluate follows growthbook's sdk specification: the first rule whose
+// condition matches and which includes the user decides the value,
+// otherwise the feature's default value is used
+func evaluate(features map[string]*feature, key string, attributes map[string]any, seen map[string]bool) (result, bool) {
+	f, found := features[key]
+	if !found {
+		return result{}, false
+	}
+
+	// cyclic prerequisites can't be evaluated
+	if seen[key] {
+		return result{reason: &backends.Reason{Kind: backends.ReasonPrerequisiteFailed, PrerequisiteKey: key}}, true
+	}
+	seen[key] = true
+	defer delete(seen, key)
+
+	for i, r := range f.Rules {
+		index := i
+
+		if failed, gate := r.prerequisitesFail(features, attributes, seen); failed != "" {
+			if gate {
+				return result{reason: &backends.Reason{Kind: backends.ReasonPrerequisiteFailed, PrerequisiteKey: failed}}, true
+			}
+			continue
+		}
+
+		if r.Condition != nil && !evalCondition(attributes, r.Condition) {
+			continue
+		}
+
+		if r.isFiltered(attributes) {
+			continue
+		}
+
+		if len(r.Force) > 0 {
+			if !r.includesUser(key, attributes) {
+				continue
+			}
+
+			var value any
+			json.Unmarshal(r.Force, &value)
+
+			return result{
+				value:  value,
+				reason: &backends.Reason{Kind: backends.ReasonRuleMatch, RuleIndex: &index, RuleID: r.ID},
+			}, true
+		}
+
+		if len(r.Variations) == 0 {
+			continue
+		}
+
+		if variation := r.assignVariation(key, attributes); variation >= 0 {
+			return result{
+				value:     r.Variations[variation],
+				reason:    &backends.Reason{Kind: backends.ReasonRuleMatch, RuleIndex: &index, RuleID: r.ID, InExperiment: true},
+				variation: &variation,
+			}, true
+		}
+	}
+
+	return result{
+		value:  f.DefaultValue,
+		reason: &backends.Reason{Kind: backends.ReasonFallthrough},
+	}, true
+}
+
+// prerequisitesFail returns the key of the first parent feature whose value
+// doesn't match its condition, and whether that parent gates the feature
+func (r *rule) prerequisitesFail(features map[string]*feature, attributes map[string]any, seen map[string]bool) (string, bool) {
+	for _, parent := range r.ParentConditions {
+		res, _ := evaluate(features, parent.ID, attributes, seen)
+
+		if !evalCondition(map[string]any{"value": res.value}, parent.Condition) {
+			return parent.ID, parent.Gate
+		}
+	}
+
+	return "", false
+}
+
+// includesUser checks a force rule's percentage rollout
+func (r *rule) includesUser(key string, attributes map[string]any) bool {
+	if r.Range == nil && r.Coverage == nil {
+		return true
+	}
+
+	id := hashValue(attributes, r.hashAttribute())
+	if id == "" {
+		return false
+	}
+
+	n, ok := hash(r.seed(key), id, r.HashVersion)
+	if !ok {
+		return false
+	}
+
+	if r.Range != nil {
+		return r.Range.contains(n)
+	}
+
+	return n <= *r.Coverage
+}
+
+// assignVariation buckets the user into one of an experiment's variations,
+// returning -1 if they are not in the experiment
+func (r *rule) assignVariation(key string, attributes map[string]any) int {
+	id := hashValue(attributes, r.hashAttribute())
+	if id == "" {
+		return -1
+	}
+
+	if len(r.Namespace) == 3 && !inNamespace(id, r.Namespace) {
+		return -1
+	}
+
+	ranges := r.Ranges
+	if len(ranges) == 0 {
+		coverage := 1.0
+		if r.Coverage != nil {
+			coverage = *r.Coverage
+		}
+		ranges = bucketRanges(len(r.Variations), coverage, r.Weights)
+	}
+
+	experimentKey := r.Key
+	if experimentKey == "" {
+		experimentKey = key
+	}
+
+	seed := r.Seed
+	if seed == "" {
+		seed = experimentKey
+	}
+
+	n, ok := hash(seed, id, r.HashVersion)
+	if !ok {
+		return -1
+	}
+
+	for i, br := range ranges {
+		if br.contains(n) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (r *rule) isFiltered(attributes map[string]any) bool {
+	for _, f := range r.Filters {
+		attribute := f.Attribute
+		if attribute == "" {
+			attribute = "id"
+		}
+
+		id := hashValue(attributes, attribute)
+		if id == "" {
+			return true
+		}
+
+		n, ok := hash(f.Seed, id, f.HashVersion)
+		if !ok {
+			return true
+		}
+
+		in := false
+		for _, br := range f.Ranges {
+			in = in || br.contains(n)
+		}
+
+		if !in {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *rule) hashAttribute() string {
+	if r.HashAttribute == "" {
+		return "id"
+	}
+
+	return r.HashAttribute
+}
+
+func (r *rule) seed(key string) string {
+	if r.Seed == "" {
+		return key
+	}
+
+	return r.Seed
+}
+
+func inNamespace(id string, namespace []any) bool {
+	name, ok1 := namespace[0].(string)
+	start, ok2 := namespace[1].(float64)
+	end, ok3 := namespace[2].(float64)
+
+	if !ok1 || !ok2 || !ok3 {
+		return false
+	}
+
+	n, _ := hash("__"+name, id, 1)
+	return n >= start && n < end
+}
+
+// bucketRanges splits 0-1 between the variations by weight, with each range
+// shrunk by the coverage
+func bucketRanges(count int, coverage float64, weights []float64) []bucketRange {
+	if coverage < 0 {
+		coverage = 0
+	}
+	if coverage > 1 {
+		coverage = 1
+	}
+
+	total := 0.0
+	for _, w := range weights {
+		total += w
+	}
+
+	if len(weights) != count || total < 0.99 || total > 1.01 {
+		weights = make([]float64, count)
+		for i := range weights {
+			weights[i] = 1 / float64(count)
+		}
+	}
+
+	ranges := make([]bucketRange, count)
+	cumulative := 0.0
+
+	for i, w := range weights {
+		ranges[i] = bucketRange{cumulative, cumulative + coverage*w}
+		cumulative += w
+	}
+
+	return ranges
+}
+
+// hash is growthbook's deterministic 0-1 value for an id and seed
+func hash(seed string, value string, version int) (float64, bool) {
+	switch version {
+	case 0, 1:
+		return float64(fnv32a(value+seed)%1000) / 1000, true
+	case 2:
+		return float64(fnv32a(strconv.FormatUint(uint64(fnv32a(seed+value)), 10))%10000) / 10000, true
+	default:
+		return 0, false
+	}
+}
+
+func fnv32a(s string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(s))
+	return h.Sum32()
+}
+
+// hashValue formats the attribute used for bucketing as a string
+func hashValue(attributes map[string]any, name string) string {
+	switch v := attributes[name].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
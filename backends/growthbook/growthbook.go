@@ -0,0 +1,209 @@
+package growthbook
+
+import (
+	"context"
+	"flagon/backends"
+	"flagon/tracing"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+var tr = otel.Tracer("backend.growthbook")
+
+const featuresPath = "/api/features/"
+
+// how long downloaded features are used before fetching them again, which
+// matches the growthbook sdks' default cache time
+const refreshInterval = 60 * time.Second
+
+// GrowthbookBackend evaluates a growthbook features payload locally, which
+// is either downloaded from the api, or read from a file
+type GrowthbookBackend struct {
+	cfg    GrowthbookConfiguration
+	client *http.Client
+
+	features *backends.Refresher[map[string]*feature]
+}
+
+func CreateBackend(ctx context.Context, cfg GrowthbookConfiguration) (*GrowthbookBackend, error) {
+	ctx, span := tr.Start(ctx, "create_backend")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("growthbook.api_host", cfg.ApiHost),
+		attribute.String("growthbook.file", cfg.File),
+		attribute.Bool("growthbook.encrypted", cfg.DecryptionKey != ""),
+	)
+
+	gb := &GrowthbookBackend{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}
+
+	if cfg.File != "" {
+		content, err := os.ReadFile(cfg.File)
+		if err != nil {
+			return nil, tracing.Error(span, err)
+		}
+
+		features, err := parsePayload(content, cfg.DecryptionKey)
+		if err != nil {
+			return nil, tracing.Errorf(span, "error reading %s: %w", cfg.File, err)
+		}
+
+		// the file is only read once, so refreshing keeps the same features
+		gb.features = &backends.Refresher[map[string]*feature]{
+			Fetch: func(ctx context.Context, previous map[string]*feature) (map[string]*feature, error) {
+				return features, nil
+			},
+			Interval: refreshInterval,
+		}
+		span.SetAttributes(attribute.Int("growthbook.features", len(features)))
+
+		return gb, nil
+	}
+
+	if cfg.ClientKey == "" {
+		return nil, tracing.Errorf(span, "no growthbook client key or file specified, use --growthbook-client-key or --growthbook-file")
+	}
+
+	gb.features = &backends.Refresher[map[string]*feature]{Fetch: gb.fetch, Interval: refreshInterval}
+
+	if err := gb.features.Refresh(ctx); err != nil {
+		return nil, tracing.Error(span, err)
+	}
+
+	span.SetAttributes(attribute.Int("growthbook.features", len(gb.features.Current(ctx))))
+
+	return gb, nil
+}
+
+func (gb *GrowthbookBackend) Close(ctx context.Context) error {
+	gb.client.CloseIdleConnections()
+	return nil
+}
+
+func (gb *GrowthbookBackend) State(ctx context.Context, flag backends.Flag, user backends.User) (backends.Flag, error) {
+	ctx, span := tr.Start(ctx, "state")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("flag.key", flag.Key))
+
+	flag.Value = flag.DefaultValue
+
+	res, found := evaluate(gb.current(ctx), flag.Key, createAttributes(user), map[string]bool{})
+	if !found {
+		flag.Reason = &backends.Reason{Kind: backends.ReasonError, ErrorKind: backends.ErrorFlagNotFound}
+		span.SetAttributes(attribute.String("reason", flag.Reason.String()))
+		return flag, nil
+	}
+
+	if res.value != nil && !backends.IsType(flag.ValueType(), res.value) {
+		flag.Reason = &backends.Reason{Kind: backends.ReasonError, ErrorKind: backends.ErrorWrongType}
+		span.SetAttributes(attribute.String("reason", flag.Reason.String()))
+		return flag, tracing.Errorf(span, "flag %s has a %T value, which cannot be used as a %s", flag.Key, res.value, flag.ValueType())
+	}
+
+	flag.Reason = res.reason
+	flag.VariationIndex = res.variation
+	span.SetAttributes(attribute.String("reason", flag.Reason.String()))
+
+	if res.value != nil {
+		flag.Value = res.value
+	}
+
+	return flag, nil
+}
+
+func (gb *GrowthbookBackend) AllFlags(ctx context.Context, user backends.User) ([]backends.Flag, error) {
+	ctx, span := tr.Start(ctx, "all_flags")
+	defer span.End()
+
+	features := gb.current(ctx)
+	flags := make([]backends.Flag, 0, len(features))
+
+	for key := range features {
+		flag, err := gb.State(ctx, backends.Flag{Key: key, Type: backends.TypeJSON}, user)
+		if err != nil {
+			return nil, tracing.Error(span, err)
+		}
+
+		flag.Type = backends.TypeOf(flag.Value)
+		flags = append(flags, flag)
+	}
+
+	span.SetAttributes(attribute.Int("flags.count", len(flags)))
+
+	return flags, nil
+}
+
+// createAttributes uses the user's key as the `id` attribute, which is what
+// growthbook hashes by default
+func createAttributes(user backends.User) map[string]any {
+	attributes := make(map[string]any, len(user.Attributes)+1)
+
+	for k, v := range user.Attributes {
+		attributes[k] = v
+	}
+
+	if _, found := attributes["id"]; !found && user.Key != "" {
+		attributes["id"] = user.Key
+	}
+
+	return attributes
+}
+
+// current returns the features, downloading them again if they are older
+// than the refresh interval
+func (gb *GrowthbookBackend) current(ctx context.Context) map[string]*feature {
+	ctx, span := tr.Start(ctx, "current")
+	defer span.End()
+
+	return gb.features.Current(ctx)
+}
+
+func (gb *GrowthbookBackend) fetch(ctx context.Context, previous map[string]*feature) (map[string]*feature, error) {
+	ctx, span := tr.Start(ctx, "fetch")
+	defer span.End()
+
+	u := strings.TrimSuffix(gb.cfg.ApiHost, "/") + featuresPath + url.PathEscape(gb.cfg.ClientKey)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return previous, tracing.Error(span, err)
+	}
+	req.Header.Set("Accept", "application/json")
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	res, err := gb.client.Do(req)
+	if err != nil {
+		return previous, tracing.Errorf(span, "unable to fetch features from growthbook: %w", err)
+	}
+	defer res.Body.Close()
+
+	span.SetAttributes(attribute.Int("http.status_code", res.StatusCode))
+
+	if res.StatusCode != http.StatusOK {
+		return previous, tracing.Errorf(span, "unable to fetch features from growthbook: %s", res.Status)
+	}
+
+	content, err := io.ReadAll(res.Body)
+	if err != nil {
+		return previous, tracing.Error(span, err)
+	}
+
+	features, err := parsePayload(content, gb.cfg.DecryptionKey)
+	if err != nil {
+		return previous, tracing.Errorf(span, "unable to read features from growthbook: %w", err)
+	}
+
+	return features, nil
+}
@@ -0,0 +1,231 @@
+package growthbook
+
+import (
+	"context"
+	"flagon/backends"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const features = `{
+  "status": 200,
+  "features": {
+    "enabled": { "defaultValue": true },
+    "disabled": { "defaultValue": false },
+    "build-number": { "defaultValue": 42 },
+    "deploy-strategy": {
+      "defaultValue": "rolling",
+      "rules": [
+        { "id": "fr_main", "condition": { "branch": "main" }, "force": "blue-green" },
+        { "id": "fr_new", "condition": { "version": { "$vgte": "2.0.0" } }, "force": "canary" }
+      ]
+    },
+    "rollout": {
+      "defaultValue": false,
+      "rules": [ { "id": "fr_half", "force": true, "coverage": 0.5, "hashAttribute": "id" } ]
+    },
+    "experiment": {
+      "defaultValue": "control",
+      "rules": [ { "id": "exp", "key": "exp1", "variations": [ "a", "b" ], "weights": [ 0.5, 0.5 ], "hashVersion": 2 } ]
+    },
+    "gated": {
+      "defaultValue": true,
+      "rules": [ { "parentConditions": [ { "id": "disabled", "condition": { "value": true }, "gate": true } ] } ]
+    },
+    "forced-null": {
+      "defaultValue": "value",
+      "rules": [ { "force": null } ]
+    }
+  }
+}`
+
+func TestState(t *testing.T) {
+
+	gb := createFileBackend(t, features, "")
+	ctx := context.Background()
+
+	cases := []struct {
+		name           string
+		flag           backends.Flag
+		user           backends.User
+		expected       any
+		expectedReason backends.ReasonKind
+	}{
+		{name: "default value", flag: backends.Flag{Key: "enabled", DefaultValue: false}, user: backends.User{Key: "alice"}, expected: true, expectedReason: backends.ReasonFallthrough},
+		{name: "number", flag: backends.Flag{Key: "build-number", Type: backends.TypeNumber, DefaultValue: 0.0}, user: backends.User{Key: "alice"}, expected: 42.0, expectedReason: backends.ReasonFallthrough},
+		{name: "force rule", flag: backends.Flag{Key: "deploy-strategy", Type: backends.TypeString, DefaultValue: "default"}, user: backends.User{Key: "alice", Attributes: map[string]any{"branch": "main"}}, expected: "blue-green", expectedReason: backends.ReasonRuleMatch},
+		{name: "version condition", flag: backends.Flag{Key: "deploy-strategy", Type: backends.TypeString, DefaultValue: "default"}, user: backends.User{Key: "alice", Attributes: map[string]any{"version": "2.1.0"}}, expected: "canary", expectedReason: backends.ReasonRuleMatch},
+		{name: "no rules match", flag: backends.Flag{Key: "deploy-strategy", Type: backends.TypeString, DefaultValue: "default"}, user: backends.User{Key: "alice", Attributes: map[string]any{"version": "1.9.0"}}, expected: "rolling", expectedReason: backends.ReasonFallthrough},
+		{name: "in rollout", flag: backends.Flag{Key: "rollout", DefaultValue: false}, user: backends.User{Key: "bob"}, expected: true, expectedReason: backends.ReasonRuleMatch},
+		{name: "not in rollout", flag: backends.Flag{Key: "rollout", DefaultValue: false}, user: backends.User{Key: "alice"}, expected: false, expectedReason: backends.ReasonFallthrough},
+		{name: "rollout without an id", flag: backends.Flag{Key: "rollout", DefaultValue: false}, user: backends.User{}, expected: false, expectedReason: backends.ReasonFallthrough},
+		{name: "prerequisite failed", flag: backends.Flag{Key: "gated", DefaultValue: false}, user: backends.User{Key: "alice"}, expected: false, expectedReason: backends.ReasonPrerequisiteFailed},
+		{name: "forced null uses the default", flag: backends.Flag{Key: "forced-null", Type: backends.TypeString, DefaultValue: "default"}, user: backends.User{Key: "alice"}, expected: "default", expectedReason: backends.ReasonRuleMatch},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			flag, err := gb.State(ctx, tc.flag, tc.user)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, flag.Value)
+			assert.Equal(t, tc.expectedReason, flag.Reason.Kind)
+		})
+	}
+
+	t.Run("experiment", func(t *testing.T) {
+		flag, err := gb.State(ctx, backends.Flag{Key: "experiment", Type: backends.TypeString, DefaultValue: "default"}, backends.User{Key: "dave"})
+		assert.NoError(t, err)
+		assert.Equal(t, "a", flag.Value)
+		assert.Equal(t, 0, *flag.VariationIndex)
+		assert.True(t, flag.Reason.InExperiment)
+
+		flag, err = gb.State(ctx, backends.Flag{Key: "experiment", Type: backends.TypeString, DefaultValue: "default"}, backends.User{Key: "alice"})
+		assert.NoError(t, err)
+		assert.Equal(t, "b", flag.Value)
+		assert.Equal(t, 1, *flag.VariationIndex)
+	})
+
+	t.Run("missing flag", func(t *testing.T) {
+		flag, err := gb.State(ctx, backends.Flag{Key: "missing", DefaultValue: true}, backends.User{Key: "alice"})
+		assert.NoError(t, err)
+		assert.Equal(t, true, flag.Value)
+		assert.Equal(t, backends.ErrorFlagNotFound, flag.Reason.ErrorKind)
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		flag, err := gb.State(ctx, backends.Flag{Key: "deploy-strategy", DefaultValue: false}, backends.User{Key: "alice"})
+		assert.Error(t, err)
+		assert.Equal(t, false, flag.Value)
+		assert.Equal(t, backends.ErrorWrongType, flag.Reason.ErrorKind)
+	})
+
+	t.Run("all flags", func(t *testing.T) {
+		flags, err := gb.AllFlags(ctx, backends.User{Key: "bob", Attributes: map[string]any{"branch": "main"}})
+		assert.NoError(t, err)
+		assert.Len(t, flags, 8)
+
+		for _, f := range flags {
+			if f.Key == "deploy-strategy" {
+				assert.Equal(t, "blue-green", f.Value)
+				assert.Equal(t, backends.TypeString, f.Type)
+			}
+		}
+	})
+}
+
+// encrypted with `openssl enc -aes-128-cbc`, in the same way as growthbook
+const encryptedFeatures = `{
+  "status": 200,
+  "features": {},
+  "encryptedFeatures": "AAECAwQFBgcICQoLDA0ODw==.A6FoVIepZ04o5TKuYVXxheVdvUSSNh3eAmUGnq9Do/pf0zo+XO7qDkzaJm3oRfyW"
+}`
+
+const decryptionKey = "Ns04T5n9+59rl2x3SlNHtQ=="
+
+func TestEncryptedPayload(t *testing.T) {
+
+	gb := createFileBackend(t, encryptedFeatures, decryptionKey)
+
+	flag, err := gb.State(context.Background(), backends.Flag{Key: "enabled", DefaultValue: false}, backends.User{Key: "alice"})
+	assert.NoError(t, err)
+	assert.Equal(t, true, flag.Value)
+
+	_, err = parsePayload([]byte(encryptedFeatures), "")
+	assert.EqualError(t, err, "the features are encrypted, but no decryption key was given")
+
+	_, err = parsePayload([]byte(encryptedFeatures), "AAAAAAAAAAAAAAAAAAAAAA==")
+	assert.ErrorContains(t, err, "unable to decrypt the features")
+}
+
+func TestApiHost(t *testing.T) {
+
+	requested := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.Path
+		if r.URL.Path != "/api/features/sdk-abc" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(encryptedFeatures))
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := DefaultConfig()
+	cfg.ApiHost = server.URL + "/"
+	cfg.ClientKey = "sdk-abc"
+	cfg.DecryptionKey = decryptionKey
+
+	gb, err := CreateBackend(context.Background(), cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, "/api/features/sdk-abc", requested)
+
+	flag, err := gb.State(context.Background(), backends.Flag{Key: "enabled", DefaultValue: false}, backends.User{Key: "alice"})
+	assert.NoError(t, err)
+	assert.Equal(t, true, flag.Value)
+
+	cfg.ClientKey = "sdk-wrong"
+	_, err = CreateBackend(context.Background(), cfg)
+	assert.EqualError(t, err, "unable to fetch features from growthbook: 404 Not Found")
+}
+
+func TestCreateBackendErrors(t *testing.T) {
+
+	_, err := CreateBackend(context.Background(), DefaultConfig())
+	assert.ErrorContains(t, err, "no growthbook client key or file specified")
+
+	_, err = CreateBackend(context.Background(), GrowthbookConfiguration{File: path.Join(t.TempDir(), "missing.json")})
+	assert.Error(t, err)
+}
+
+func TestHash(t *testing.T) {
+	// cases from the growthbook sdk specification
+	cases := []struct {
+		seed     string
+		value    string
+		version  int
+		expected float64
+	}{
+		{"", "a", 1, 0.22},
+		{"", "b", 1, 0.077},
+		{"b", "a", 1, 0.946},
+		{"ef", "d", 1, 0.652},
+		{"asdf", "8952klfjas09ujkasdf", 1, 0.335},
+		{"", "a", 2, 0.0216},
+		{"", "b", 2, 0.9054},
+		{"b", "a", 2, 0.665},
+		{"ef", "d", 2, 0.8601},
+		{"asdf", "8952klfjas09ujkasdf", 2, 0.5491},
+	}
+
+	for _, tc := range cases {
+		n, ok := hash(tc.seed, tc.value, tc.version)
+		assert.True(t, ok)
+		assert.Equal(t, tc.expected, n)
+	}
+
+	_, ok := hash("", "a", 99)
+	assert.False(t, ok)
+}
+
+func TestBucketRanges(t *testing.T) {
+	assert.Equal(t, []bucketRange{{0, 0.5}, {0.5, 1}}, bucketRanges(2, 1, nil))
+	assert.Equal(t, []bucketRange{{0, 0.25}, {0.5, 0.75}}, bucketRanges(2, 0.5, nil))
+	assert.Equal(t, []bucketRange{{0, 0.2}, {0.2, 0.5}, {0.5, 1}}, bucketRanges(3, 1, []float64{0.2, 0.3, 0.5}))
+	assert.Equal(t, []bucketRange{{0, 0.5}, {0.5, 1}}, bucketRanges(2, 1, []float64{0.9, 0.9}))
+}
+
+func createFileBackend(t *testing.T, content string, key string) *GrowthbookBackend {
+	file := path.Join(t.TempDir(), "features.json")
+	assert.NoError(t, os.WriteFile(file, []byte(content), 0644))
+
+	gb, err := CreateBackend(context.Background(), GrowthbookConfiguration{File: file, DecryptionKey: key})
+	assert.NoError(t, err)
+
+	return gb
+}
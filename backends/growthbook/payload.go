@@ -0,0 +1,146 @@
+package growthbook
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// payload is the body of the `/api/features/<clientKey>` endpoint, which is
+// also what is read from a features file
+type payload struct {
+	Features          map[string]*feature `json:"features"`
+	EncryptedFeatures string              `json:"encryptedFeatures"`
+}
+
+type feature struct {
+	DefaultValue any    `json:"defaultValue"`
+	Rules        []rule `json:"rules"`
+}
+
+type rule struct {
+	ID        string         `json:"id"`
+	Condition map[string]any `json:"condition"`
+
+	// Force is kept raw so that forcing a null value can be told apart from
+	// an experiment rule, which has no force at all
+	Force json.RawMessage `json:"force"`
+
+	ParentConditions []parentCondition `json:"parentConditions"`
+	Filters          []filter          `json:"filters"`
+
+	Variations    []any         `json:"variations"`
+	Weights       []float64     `json:"weights"`
+	Key           string        `json:"key"`
+	HashAttribute string        `json:"hashAttribute"`
+	HashVersion   int           `json:"hashVersion"`
+	Seed          string        `json:"seed"`
+	Coverage      *float64      `json:"coverage"`
+	Range         *bucketRange  `json:"range"`
+	Ranges        []bucketRange `json:"ranges"`
+	Namespace     []any         `json:"namespace"`
+}
+
+type parentCondition struct {
+	ID        string         `json:"id"`
+	Condition map[string]any `json:"condition"`
+	Gate      bool           `json:"gate"`
+}
+
+type filter struct {
+	Seed        string        `json:"seed"`
+	Ranges      []bucketRange `json:"ranges"`
+	Attribute   string        `json:"attribute"`
+	HashVersion int           `json:"hashVersion"`
+}
+
+type bucketRange [2]float64
+
+func (r bucketRange) contains(n float64) bool {
+	return n >= r[0] && n < r[1]
+}
+
+// parsePayload reads a features payload, decrypting the features if they
+// are encrypted
+func parsePayload(content []byte, decryptionKey string) (map[string]*feature, error) {
+	p := payload{}
+	if err := json.Unmarshal(content, &p); err != nil {
+		return nil, err
+	}
+
+	if p.EncryptedFeatures == "" {
+		if p.Features == nil {
+			return nil, errors.New("the payload has no features")
+		}
+		return p.Features, nil
+	}
+
+	if decryptionKey == "" {
+		return nil, errors.New("the features are encrypted, but no decryption key was given")
+	}
+
+	decrypted, err := decrypt(p.EncryptedFeatures, decryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt the features: %w", err)
+	}
+
+	features := map[string]*feature{}
+	if err := json.Unmarshal(decrypted, &features); err != nil {
+		return nil, fmt.Errorf("unable to decrypt the features: %w", err)
+	}
+
+	return features, nil
+}
+
+// decrypt reverses growthbook's payload encryption, which is AES-CBC with a
+// base64 key, formatted as `base64(iv).base64(ciphertext)`
+func decrypt(encrypted string, decryptionKey string) ([]byte, error) {
+	ivText, cipherText, found := strings.Cut(encrypted, ".")
+	if !found {
+		return nil, errors.New("the encrypted features are not in the iv.ciphertext format")
+	}
+
+	key, err := base64.StdEncoding.DecodeString(decryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("the decryption key is not valid base64: %w", err)
+	}
+
+	iv, err := base64.StdEncoding.DecodeString(ivText)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(cipherText)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(iv) != block.BlockSize() || len(data) == 0 || len(data)%block.BlockSize() != 0 {
+		return nil, errors.New("the encrypted features are the wrong length")
+	}
+
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, data)
+
+	padding := int(plain[len(plain)-1])
+	if padding == 0 || padding > block.BlockSize() || padding > len(plain) {
+		return nil, errors.New("the decryption key is wrong")
+	}
+
+	for _, b := range plain[len(plain)-padding:] {
+		if int(b) != padding {
+			return nil, errors.New("the decryption key is wrong")
+		}
+	}
+
+	return plain[:len(plain)-padding], nil
+}
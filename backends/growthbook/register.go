@@ -0,0 +1,18 @@
+package growthbook
+
+import (
+	"context"
+	"flagon/backends"
+)
+
+func init() {
+	backends.Register("growthbook", "GrowthBook Backend", DefaultConfig, ConfigFromEnvironment,
+		func(ctx context.Context, cfg GrowthbookConfiguration) (backends.Backend, error) {
+			backend, err := CreateBackend(ctx, cfg)
+			if err != nil {
+				return nil, err
+			}
+			return backend, nil
+		},
+	)
+}
@@ -11,11 +11,41 @@ import (
 const SdkKeyEnvVar = "FLAGON_LD_SDKKEY"
 const TimeoutEnvVar = "FLAGON_LD_TIMEOUT"
 const DebugEnvVar = "FLAGON_LD_DEBUG"
+const DataFileEnvVar = "FLAGON_LD_DATA_FILE"
+const BaseUriEnvVar = "FLAGON_LD_BASE_URI"
+const StreamUriEnvVar = "FLAGON_LD_STREAM_URI"
+const EventsUriEnvVar = "FLAGON_LD_EVENTS_URI"
+const RelayUriEnvVar = "FLAGON_LD_RELAY_URI"
+const DaemonModeEnvVar = "FLAGON_LD_DAEMON_MODE"
+const RedisUrlEnvVar = "FLAGON_LD_REDIS_URL"
+const RedisPrefixEnvVar = "FLAGON_LD_REDIS_PREFIX"
+const ProxyUrlEnvVar = "FLAGON_LD_PROXY_URL"
+const CaBundleEnvVar = "FLAGON_LD_CA_BUNDLE"
 
 type LaunchDarklyConfiguration struct {
 	SdkKey  string
 	Timeout time.Duration
 	Debug   bool
+
+	// DataFile is a launchdarkly flag data file to evaluate flags from,
+	// without connecting to launchdarkly
+	DataFile string
+
+	// BaseUri, StreamUri and EventsUri replace launchdarkly's endpoints, and
+	// take precedence over the RelayUri, which sets all three
+	BaseUri   string
+	StreamUri string
+	EventsUri string
+	RelayUri  string
+
+	// DaemonMode reads flags from the redis store which a relay proxy in
+	// daemon mode keeps up to date, rather than connecting to launchdarkly
+	DaemonMode  bool
+	RedisUrl    string
+	RedisPrefix string
+
+	ProxyUrl string
+	CaBundle string
 }
 
 func (cfg *LaunchDarklyConfiguration) OverrideFrom(other LaunchDarklyConfiguration) {
@@ -30,6 +60,46 @@ func (cfg *LaunchDarklyConfiguration) OverrideFrom(other LaunchDarklyConfigurati
 	if other.Timeout > 0 {
 		cfg.Timeout = other.Timeout
 	}
+
+	if other.DataFile != "" {
+		cfg.DataFile = other.DataFile
+	}
+
+	if other.BaseUri != "" {
+		cfg.BaseUri = other.BaseUri
+	}
+
+	if other.StreamUri != "" {
+		cfg.StreamUri = other.StreamUri
+	}
+
+	if other.EventsUri != "" {
+		cfg.EventsUri = other.EventsUri
+	}
+
+	if other.RelayUri != "" {
+		cfg.RelayUri = other.RelayUri
+	}
+
+	if other.DaemonMode {
+		cfg.DaemonMode = other.DaemonMode
+	}
+
+	if other.RedisUrl != "" {
+		cfg.RedisUrl = other.RedisUrl
+	}
+
+	if other.RedisPrefix != "" {
+		cfg.RedisPrefix = other.RedisPrefix
+	}
+
+	if other.ProxyUrl != "" {
+		cfg.ProxyUrl = other.ProxyUrl
+	}
+
+	if other.CaBundle != "" {
+		cfg.CaBundle = other.CaBundle
+	}
 }
 
 func (cfg *LaunchDarklyConfiguration) Flags() *pflag.FlagSet {
@@ -38,6 +108,16 @@ func (cfg *LaunchDarklyConfiguration) Flags() *pflag.FlagSet {
 	flags.BoolVar(&cfg.Debug, "ld-debug", false, "enable debug logging for launchdarkly")
 	flags.StringVar(&cfg.SdkKey, "ld-sdk-key", "", "the sdk-key to use")
 	flags.DurationVar(&cfg.Timeout, "ld-timeout", 0, "timeout before failing to communicate with launchdarkly")
+	flags.StringVar(&cfg.DataFile, "ld-data-file", "", "evaluate flags offline from a json or yaml launchdarkly data file, rather than connecting to launchdarkly")
+	flags.StringVar(&cfg.BaseUri, "ld-base-uri", "", "the base uri for polling launchdarkly")
+	flags.StringVar(&cfg.StreamUri, "ld-stream-uri", "", "the base uri for streaming from launchdarkly")
+	flags.StringVar(&cfg.EventsUri, "ld-events-uri", "", "the base uri to send events to")
+	flags.StringVar(&cfg.RelayUri, "ld-relay-uri", "", "the uri of a relay proxy to use for all launchdarkly endpoints")
+	flags.BoolVar(&cfg.DaemonMode, "ld-daemon-mode", false, "read flags from the redis store of a relay proxy in daemon mode, rather than connecting to launchdarkly")
+	flags.StringVar(&cfg.RedisUrl, "ld-redis-url", "", "the redis url used by the relay proxy in daemon mode, for example redis://localhost:6379")
+	flags.StringVar(&cfg.RedisPrefix, "ld-redis-prefix", "", "the prefix of the relay proxy's redis keys")
+	flags.StringVar(&cfg.ProxyUrl, "ld-proxy-url", "", "an http proxy to connect to launchdarkly through")
+	flags.StringVar(&cfg.CaBundle, "ld-ca-bundle", "", "a pem file of additional certificate authorities to trust, such as for a tls intercepting proxy")
 
 	return flags
 }
@@ -46,6 +126,15 @@ func ConfigFromEnvironment() LaunchDarklyConfiguration {
 
 	cfg := LaunchDarklyConfiguration{}
 	cfg.SdkKey = os.Getenv(SdkKeyEnvVar)
+	cfg.DataFile = os.Getenv(DataFileEnvVar)
+	cfg.BaseUri = os.Getenv(BaseUriEnvVar)
+	cfg.StreamUri = os.Getenv(StreamUriEnvVar)
+	cfg.EventsUri = os.Getenv(EventsUriEnvVar)
+	cfg.RelayUri = os.Getenv(RelayUriEnvVar)
+	cfg.RedisUrl = os.Getenv(RedisUrlEnvVar)
+	cfg.RedisPrefix = os.Getenv(RedisPrefixEnvVar)
+	cfg.ProxyUrl = os.Getenv(ProxyUrlEnvVar)
+	cfg.CaBundle = os.Getenv(CaBundleEnvVar)
 
 	if val := os.Getenv(TimeoutEnvVar); val != "" {
 		if timeout, err := time.ParseDuration(val); err == nil {
@@ -59,6 +148,11 @@ func ConfigFromEnvironment() LaunchDarklyConfiguration {
 		cfg.Debug = err == nil && b
 	}
 
+	if val := os.Getenv(DaemonModeEnvVar); val != "" {
+		b, err := strconv.ParseBool(val)
+		cfg.DaemonMode = err == nil && b
+	}
+
 	return cfg
 }
 
@@ -67,5 +161,7 @@ func DefaultConfig() LaunchDarklyConfiguration {
 		SdkKey:  "",
 		Timeout: 2 * time.Second,
 		Debug:   false,
+
+		RedisPrefix: "launchdarkly",
 	}
 }
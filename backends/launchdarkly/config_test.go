@@ -13,12 +13,32 @@ func TestReadEnvironment(t *testing.T) {
 	os.Setenv(SdkKeyEnvVar, "test-key")
 	os.Setenv(TimeoutEnvVar, "17s")
 	os.Setenv(DebugEnvVar, "true")
+	os.Setenv(DataFileEnvVar, "ld-flags.json")
+	os.Setenv(BaseUriEnvVar, "https://base.internal")
+	os.Setenv(StreamUriEnvVar, "https://stream.internal")
+	os.Setenv(EventsUriEnvVar, "https://events.internal")
+	os.Setenv(RelayUriEnvVar, "https://relay.internal")
+	os.Setenv(DaemonModeEnvVar, "1")
+	os.Setenv(RedisUrlEnvVar, "redis://redis.internal:6379")
+	os.Setenv(RedisPrefixEnvVar, "relay")
+	os.Setenv(ProxyUrlEnvVar, "http://proxy.internal:3128")
+	os.Setenv(CaBundleEnvVar, "/etc/ssl/corporate.pem")
 
 	cfg := ConfigFromEnvironment()
 
 	assert.Equal(t, "test-key", cfg.SdkKey)
 	assert.Equal(t, 17*time.Second, cfg.Timeout)
 	assert.Equal(t, true, cfg.Debug)
+	assert.Equal(t, "ld-flags.json", cfg.DataFile)
+	assert.Equal(t, "https://base.internal", cfg.BaseUri)
+	assert.Equal(t, "https://stream.internal", cfg.StreamUri)
+	assert.Equal(t, "https://events.internal", cfg.EventsUri)
+	assert.Equal(t, "https://relay.internal", cfg.RelayUri)
+	assert.Equal(t, true, cfg.DaemonMode)
+	assert.Equal(t, "redis://redis.internal:6379", cfg.RedisUrl)
+	assert.Equal(t, "relay", cfg.RedisPrefix)
+	assert.Equal(t, "http://proxy.internal:3128", cfg.ProxyUrl)
+	assert.Equal(t, "/etc/ssl/corporate.pem", cfg.CaBundle)
 }
 
 func TestFlags(t *testing.T) {
@@ -30,11 +50,31 @@ func TestFlags(t *testing.T) {
 		"--ld-debug",
 		"--ld-sdk-key", "some-key",
 		"--ld-timeout", "23s",
+		"--ld-data-file", "other.yaml",
+		"--ld-base-uri", "https://base.example.com",
+		"--ld-stream-uri", "https://stream.example.com",
+		"--ld-events-uri", "https://events.example.com",
+		"--ld-relay-uri", "https://relay.example.com",
+		"--ld-daemon-mode",
+		"--ld-redis-url", "redis://localhost:6379",
+		"--ld-redis-prefix", "ld",
+		"--ld-proxy-url", "http://localhost:3128",
+		"--ld-ca-bundle", "ca.pem",
 	}))
 
 	assert.Equal(t, "some-key", cfg.SdkKey)
 	assert.Equal(t, 23*time.Second, cfg.Timeout)
 	assert.Equal(t, true, cfg.Debug)
+	assert.Equal(t, "other.yaml", cfg.DataFile)
+	assert.Equal(t, "https://base.example.com", cfg.BaseUri)
+	assert.Equal(t, "https://stream.example.com", cfg.StreamUri)
+	assert.Equal(t, "https://events.example.com", cfg.EventsUri)
+	assert.Equal(t, "https://relay.example.com", cfg.RelayUri)
+	assert.Equal(t, true, cfg.DaemonMode)
+	assert.Equal(t, "redis://localhost:6379", cfg.RedisUrl)
+	assert.Equal(t, "ld", cfg.RedisPrefix)
+	assert.Equal(t, "http://localhost:3128", cfg.ProxyUrl)
+	assert.Equal(t, "ca.pem", cfg.CaBundle)
 }
 
 func TestOverridingValues(t *testing.T) {
@@ -84,6 +124,37 @@ func TestOverridingValues(t *testing.T) {
 				Debug:   false,
 			},
 		},
+
+		{
+			Override: LaunchDarklyConfiguration{
+				DataFile: "ld-flags.json",
+			},
+			Expected: LaunchDarklyConfiguration{
+				SdkKey:   "base-key",
+				Timeout:  10 * time.Second,
+				Debug:    false,
+				DataFile: "ld-flags.json",
+			},
+		},
+
+		{
+			Override: LaunchDarklyConfiguration{
+				RelayUri:   "https://relay.internal",
+				DaemonMode: true,
+				RedisUrl:   "redis://localhost:6379",
+				ProxyUrl:   "http://proxy.internal:3128",
+				CaBundle:   "corporate.pem",
+			},
+			Expected: LaunchDarklyConfiguration{
+				SdkKey:     "base-key",
+				Timeout:    10 * time.Second,
+				RelayUri:   "https://relay.internal",
+				DaemonMode: true,
+				RedisUrl:   "redis://localhost:6379",
+				ProxyUrl:   "http://proxy.internal:3128",
+				CaBundle:   "corporate.pem",
+			},
+		},
 	}
 
 	for _, tc := range cases {
@@ -4,16 +4,23 @@ import (
 	"context"
 	"flagon/backends"
 	"flagon/tracing"
+	"fmt"
+	"os"
 	"strings"
 	"time"
 
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	ldredis "github.com/launchdarkly/go-server-sdk-redis-redigo/v3"
+	ld "github.com/launchdarkly/go-server-sdk/v7"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces/flagstate"
+	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v7/ldfiledata"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
-	"gopkg.in/launchdarkly/go-sdk-common.v2/lduser"
-	"gopkg.in/launchdarkly/go-sdk-common.v2/ldvalue"
-	ld "gopkg.in/launchdarkly/go-server-sdk.v5"
-	"gopkg.in/launchdarkly/go-server-sdk.v5/interfaces"
-	"gopkg.in/launchdarkly/go-server-sdk.v5/ldcomponents"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var tr = otel.Tracer("backend.launch_darkly")
@@ -36,6 +43,52 @@ func CreateBackend(ctx context.Context, cfg LaunchDarklyConfiguration) (*LaunchD
 		ldConfig.Logging = ldcomponents.NoLogging()
 	}
 
+	ldConfig.ServiceEndpoints = serviceEndpoints(cfg)
+
+	if cfg.ProxyUrl != "" || cfg.CaBundle != "" {
+		span.SetAttributes(
+			attribute.String("ld.proxy_url", cfg.ProxyUrl),
+			attribute.String("ld.ca_bundle", cfg.CaBundle),
+		)
+
+		httpConfig := ldcomponents.HTTPConfiguration()
+		if cfg.ProxyUrl != "" {
+			httpConfig.ProxyURL(cfg.ProxyUrl)
+		}
+		if cfg.CaBundle != "" {
+			httpConfig.CACertFile(cfg.CaBundle)
+		}
+
+		ldConfig.HTTP = httpConfig
+	}
+
+	if cfg.DaemonMode {
+		if cfg.DataFile != "" {
+			return nil, tracing.Errorf(span, "daemon mode cannot be used with a data file")
+		}
+
+		if cfg.RedisUrl == "" {
+			return nil, tracing.Errorf(span, "daemon mode needs the relay proxy's redis url, use --ld-redis-url")
+		}
+
+		span.SetAttributes(attribute.String("ld.redis_prefix", cfg.RedisPrefix))
+
+		ldConfig.DataSource = ldcomponents.ExternalUpdatesOnly()
+		ldConfig.DataStore = ldcomponents.PersistentDataStore(ldredis.DataStore().URL(cfg.RedisUrl).Prefix(cfg.RedisPrefix))
+	}
+
+	if cfg.DataFile != "" {
+		span.SetAttributes(attribute.String("ld.data_file", cfg.DataFile))
+
+		// the sdk only logs a missing file, so check here to give a useful error
+		if _, err := os.Stat(cfg.DataFile); err != nil {
+			return nil, tracing.Error(span, err)
+		}
+
+		ldConfig.DataSource = ldfiledata.DataSource().FilePaths(cfg.DataFile)
+		ldConfig.Events = ldcomponents.NoEvents()
+	}
+
 	client, err := ld.MakeCustomClient(cfg.SdkKey, ldConfig, cfg.Timeout)
 	if err != nil {
 		return nil, tracing.Error(span, err)
@@ -47,6 +100,30 @@ func CreateBackend(ctx context.Context, cfg LaunchDarklyConfiguration) (*LaunchD
 
 }
 
+// serviceEndpoints uses the relay proxy for every endpoint, except those
+// which are configured individually
+func serviceEndpoints(cfg LaunchDarklyConfiguration) interfaces.ServiceEndpoints {
+	endpoints := interfaces.ServiceEndpoints{}
+
+	if cfg.RelayUri != "" {
+		endpoints = ldcomponents.RelayProxyEndpoints(cfg.RelayUri)
+	}
+
+	if cfg.BaseUri != "" {
+		endpoints.Polling = cfg.BaseUri
+	}
+
+	if cfg.StreamUri != "" {
+		endpoints.Streaming = cfg.StreamUri
+	}
+
+	if cfg.EventsUri != "" {
+		endpoints.Events = cfg.EventsUri
+	}
+
+	return endpoints
+}
+
 func (ldb *LaunchDarklyBackend) Close(ctx context.Context) error {
 	_, span := tr.Start(ctx, "close")
 	defer span.End()
@@ -58,63 +135,242 @@ func (ldb *LaunchDarklyBackend) State(ctx context.Context, flag backends.Flag, u
 	ctx, span := tr.Start(ctx, "state")
 	defer span.End()
 
-	u := createUser(ctx, user)
+	c := createContext(ctx, user)
 
 	span.SetAttributes(attribute.String("flag.key", flag.Key))
 
 	flag.Value = flag.DefaultValue
 
-	variation, detail, err := ldb.client.BoolVariationDetail(flag.Key, u, flag.DefaultValue)
+	value, detail, err := ldb.variation(flag, c)
+
+	flag.VariationIndex = detail.VariationIndex.AsPointer()
+	flag.Reason = createReason(detail.Reason)
+
 	if err != nil {
 		return flag, tracing.Error(span, err)
 	}
 
 	span.SetAttributes(attribute.String("reason", detail.Reason.String()))
-	span.SetAttributes(attribute.Bool("variation", variation))
+	span.SetAttributes(attribute.String("variation", value.JSONString()))
 
-	flag.Value = variation
+	flag.Value = value.AsArbitraryValue()
 
 	return flag, nil
 }
 
-func createUser(ctx context.Context, user backends.User) lduser.User {
-	ctx, span := tr.Start(ctx, "create_user")
+func (ldb *LaunchDarklyBackend) AllFlags(ctx context.Context, user backends.User) ([]backends.Flag, error) {
+	ctx, span := tr.Start(ctx, "all_flags")
+	defer span.End()
+
+	c := createContext(ctx, user)
+
+	state := ldb.client.AllFlagsState(c, flagstate.OptionWithReasons())
+	if !state.IsValid() {
+		return nil, tracing.Errorf(span, "unable to evaluate all flags, the client is not ready")
+	}
+
+	values := state.ToValuesMap()
+	flags := make([]backends.Flag, 0, len(values))
+
+	for key := range values {
+		fs, _ := state.GetFlag(key)
+		value := fs.Value.AsArbitraryValue()
+
+		flags = append(flags, backends.Flag{
+			Key:            key,
+			Type:           backends.TypeOf(value),
+			Value:          value,
+			VariationIndex: fs.Variation.AsPointer(),
+			Reason:         createReason(fs.Reason),
+		})
+	}
+
+	span.SetAttributes(attribute.Int("flags.count", len(flags)))
+
+	return flags, nil
+}
+
+func (ldb *LaunchDarklyBackend) Watch(ctx context.Context, flag backends.Flag, user backends.User) (<-chan backends.Flag, error) {
+	ctx, span := tr.Start(ctx, "watch")
 	defer span.End()
 
-	span.SetAttributes(attribute.String("attr.key", user.Key))
+	span.SetAttributes(attribute.String("flag.key", flag.Key))
 
-	builder := lduser.NewUserBuilder(user.Key)
+	current, err := ldb.State(ctx, flag, user)
+	if err != nil {
+		return nil, tracing.Error(span, err)
+	}
+
+	tracker := ldb.client.GetFlagTracker()
+	events := tracker.AddFlagValueChangeListener(flag.Key, createContext(ctx, user), ldvalue.CopyArbitraryValue(flag.DefaultValue))
+
+	changes := make(chan backends.Flag, 1)
+	changes <- current
+
+	go func() {
+		defer close(changes)
+		defer tracker.RemoveFlagValueChangeListener(events)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case _, ok := <-events:
+				if !ok {
+					return
+				}
+
+				// evaluate again rather than using the event's value, so the
+				// reason is populated too
+				next, err := ldb.State(ctx, flag, user)
+				if err != nil {
+					continue
+				}
+
+				select {
+				case changes <- next:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return changes, nil
+}
+
+func createReason(r ldreason.EvaluationReason) *backends.Reason {
+	if !r.IsDefined() {
+		return nil
+	}
+
+	reason := &backends.Reason{
+		Kind:            backends.ReasonKind(r.GetKind()),
+		RuleID:          r.GetRuleID(),
+		PrerequisiteKey: r.GetPrerequisiteKey(),
+		InExperiment:    r.IsInExperiment(),
+		ErrorKind:       string(r.GetErrorKind()),
+	}
+
+	if r.GetKind() == ldreason.EvalReasonRuleMatch {
+		index := r.GetRuleIndex()
+		reason.RuleIndex = &index
+	}
+
+	return reason
+}
+
+func (ldb *LaunchDarklyBackend) variation(flag backends.Flag, c ldcontext.Context) (ldvalue.Value, ldreason.EvaluationDetail, error) {
+
+	switch flag.ValueType() {
+	case backends.TypeBool:
+		defaultValue, _ := flag.DefaultValue.(bool)
+		value, detail, err := ldb.client.BoolVariationDetail(flag.Key, c, defaultValue)
+		return ldvalue.Bool(value), detail, err
+
+	case backends.TypeString:
+		defaultValue, _ := flag.DefaultValue.(string)
+		value, detail, err := ldb.client.StringVariationDetail(flag.Key, c, defaultValue)
+		return ldvalue.String(value), detail, err
+
+	case backends.TypeNumber:
+		defaultValue, _ := flag.DefaultValue.(float64)
+		value, detail, err := ldb.client.Float64VariationDetail(flag.Key, c, defaultValue)
+		return ldvalue.Float64(value), detail, err
+
+	case backends.TypeJSON:
+		defaultValue := ldvalue.CopyArbitraryValue(flag.DefaultValue)
+		return ldb.client.JSONVariationDetail(flag.Key, c, defaultValue)
+
+	default:
+		return ldvalue.Null(), ldreason.EvaluationDetail{}, fmt.Errorf("unsupported flag type: %s", flag.Type)
+	}
+}
+
+// createContext builds a user context, or a multi-kind context when the user
+// has other contexts.  The user is left out of a multi-kind context when it
+// has no key, so flags can be evaluated for only a repository, for example.
+func createContext(ctx context.Context, user backends.User) ldcontext.Context {
+	ctx, span := tr.Start(ctx, "create_context")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("attr.key", user.Key),
+		attribute.Bool("attr.anonymous", user.Anonymous),
+	)
+
+	if len(user.Contexts) == 0 {
+		return createUserContext(ctx, user)
+	}
+
+	multi := ldcontext.NewMultiBuilder()
+
+	if user.Key != "" {
+		multi.Add(createUserContext(ctx, user))
+	}
+
+	for _, c := range user.Contexts {
+		span.SetAttributes(attribute.String("context."+c.Kind+".key", c.Key))
+
+		builder := ldcontext.NewBuilder(c.Key).Kind(ldcontext.Kind(c.Kind))
+		public := backends.PublicAttributes(c.Attributes, c.PrivateAttributes)
+
+		for key, value := range c.Attributes {
+			if _, found := public[key]; found {
+				span.SetAttributes(attribute.String("context."+c.Kind+"."+key, backends.AttributeString(value)))
+			}
+
+			if strings.ToLower(key) == "name" {
+				builder.Name(backends.AttributeString(value))
+			} else {
+				builder.SetValue(key, ldvalue.CopyArbitraryValue(value))
+			}
+		}
+
+		builder.Private(c.PrivateAttributes...)
+
+		multi.Add(builder.Build())
+	}
+
+	return multi.Build()
+}
+
+func createUserContext(ctx context.Context, user backends.User) ldcontext.Context {
+	span := trace.SpanFromContext(ctx)
+
+	builder := ldcontext.NewBuilder(user.Key).Anonymous(user.Anonymous)
+	public := backends.PublicAttributes(user.Attributes, user.PrivateAttributes)
 
 	for key, value := range user.Attributes {
 
-		span.SetAttributes(attribute.String("attr."+key, value))
 		cleanKey := strings.ToLower(strings.ReplaceAll(key, "_", ""))
 
+		// the user's built in attributes are custom attributes of a context,
+		// but keep the names they had as built in attributes.  Note, the
+		// default is the key as passed in, not the cleankey used for switch
+		name := key
 		switch cleanKey {
 		case "name":
-			builder.Name(value)
-
+			name = "name"
 		case "firstname":
-			builder.FirstName(value)
-
+			name = "firstName"
 		case "lastname":
-			builder.LastName(value)
-
-		case "email":
-			builder.Email(value)
-
-		case "country":
-			builder.Country(value)
-
-		case "ip":
-			builder.IP(value)
+			name = "lastName"
+		case "email", "country", "ip", "avatar":
+			name = cleanKey
+		}
 
-		case "secondary":
-			builder.Secondary(value)
+		if name == "name" {
+			builder.Name(backends.AttributeString(value))
+		} else {
+			builder.SetValue(name, ldvalue.CopyArbitraryValue(value))
+		}
 
-		default:
-			// note, this is the key as passed in, not the cleankey used for switch
-			builder.Custom(key, ldvalue.String(value))
+		if _, found := public[key]; found {
+			span.SetAttributes(attribute.String("attr."+key, backends.AttributeString(value)))
+		} else {
+			builder.Private(name)
 		}
 	}
 
@@ -0,0 +1,386 @@
+package launchdarkly
+
+import (
+	"context"
+	"flagon/backends"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+	"github.com/stretchr/testify/assert"
+)
+
+const dataFile = `{
+  "flags": {
+    "ci-replacement-deploy": {
+      "key": "ci-replacement-deploy",
+      "version": 1,
+      "on": true,
+      "variations": [ true, false ],
+      "offVariation": 1,
+      "fallthrough": { "variation": 1 },
+      "targets": [ { "variation": 0, "values": [ "alice" ] } ],
+      "rules": [
+        {
+          "id": "main-branch",
+          "variation": 0,
+          "clauses": [ { "attribute": "branch", "op": "in", "values": [ "main" ] } ]
+        },
+        {
+          "id": "platform-team",
+          "variation": 0,
+          "clauses": [ { "attribute": "", "op": "segmentMatch", "values": [ "platform" ] } ]
+        },
+        {
+          "id": "later-pipelines",
+          "variation": 0,
+          "clauses": [ { "attribute": "pipelineNumber", "op": "greaterThan", "values": [ 1000 ] } ]
+        },
+        {
+          "id": "deploy-label",
+          "variation": 0,
+          "clauses": [ { "attribute": "labels", "op": "in", "values": [ "deploy" ] } ]
+        }
+      ],
+      "salt": "abc"
+    },
+    "repository-deploy": {
+      "key": "repository-deploy",
+      "version": 1,
+      "on": true,
+      "variations": [ true, false ],
+      "offVariation": 1,
+      "fallthrough": { "variation": 1 },
+      "contextTargets": [ { "contextKind": "organisation", "variation": 0, "values": [ "pondidum" ] } ],
+      "rules": [
+        {
+          "id": "main-branch",
+          "variation": 0,
+          "clauses": [ { "contextKind": "repository", "attribute": "branch", "op": "in", "values": [ "main" ] } ]
+        }
+      ],
+      "salt": "jkl"
+    },
+    "deploy-strategy": {
+      "key": "deploy-strategy",
+      "version": 1,
+      "on": false,
+      "variations": [ "rolling", "blue-green" ],
+      "offVariation": 1,
+      "fallthrough": { "variation": 0 },
+      "salt": "def"
+    }
+  },
+  "segments": {
+    "platform": { "key": "platform", "version": 1, "included": [ "bob" ], "salt": "ghi" }
+  },
+  "flagValues": {
+    "log-level": "debug"
+  }
+}`
+
+func createOfflineBackend(t *testing.T) *LaunchDarklyBackend {
+	filePath := path.Join(t.TempDir(), "flags.json")
+	assert.NoError(t, os.WriteFile(filePath, []byte(dataFile), 0644))
+
+	cfg := DefaultConfig()
+	cfg.DataFile = filePath
+
+	ldb, err := CreateBackend(context.Background(), cfg)
+	assert.NoError(t, err)
+	t.Cleanup(func() { ldb.Close(context.Background()) })
+
+	return ldb
+}
+
+func TestDataFile(t *testing.T) {
+
+	ldb := createOfflineBackend(t)
+	ctx := context.Background()
+
+	cases := []struct {
+		name   string
+		flag   backends.Flag
+		user   backends.User
+		value  any
+		reason backends.ReasonKind
+		ruleID string
+	}{
+		{
+			name:   "target",
+			flag:   backends.Flag{Key: "ci-replacement-deploy"},
+			user:   backends.User{Key: "alice"},
+			value:  true,
+			reason: backends.ReasonTargetMatch,
+		},
+		{
+			name:   "rule",
+			flag:   backends.Flag{Key: "ci-replacement-deploy"},
+			user:   backends.User{Key: "carol", Attributes: map[string]any{"branch": "main"}},
+			value:  true,
+			reason: backends.ReasonRuleMatch,
+			ruleID: "main-branch",
+		},
+		{
+			name:   "segment",
+			flag:   backends.Flag{Key: "ci-replacement-deploy"},
+			user:   backends.User{Key: "bob"},
+			value:  true,
+			reason: backends.ReasonRuleMatch,
+			ruleID: "platform-team",
+		},
+		{
+			name:   "number rule",
+			flag:   backends.Flag{Key: "ci-replacement-deploy"},
+			user:   backends.User{Key: "carol", Attributes: map[string]any{"pipelineNumber": 1001.0}},
+			value:  true,
+			reason: backends.ReasonRuleMatch,
+			ruleID: "later-pipelines",
+		},
+		{
+			name:   "number rule with a string",
+			flag:   backends.Flag{Key: "ci-replacement-deploy"},
+			user:   backends.User{Key: "carol", Attributes: map[string]any{"pipelineNumber": "1001"}},
+			value:  false,
+			reason: backends.ReasonFallthrough,
+		},
+		{
+			name:   "list rule",
+			flag:   backends.Flag{Key: "ci-replacement-deploy"},
+			user:   backends.User{Key: "carol", Attributes: map[string]any{"labels": []any{"backend", "deploy"}}},
+			value:  true,
+			reason: backends.ReasonRuleMatch,
+			ruleID: "deploy-label",
+		},
+		{
+			name:   "fallthrough",
+			flag:   backends.Flag{Key: "ci-replacement-deploy"},
+			user:   backends.User{Key: "carol", Attributes: map[string]any{"branch": "dev"}},
+			value:  false,
+			reason: backends.ReasonFallthrough,
+		},
+		{
+			name:   "off",
+			flag:   backends.Flag{Key: "deploy-strategy", Type: backends.TypeString, DefaultValue: "recreate"},
+			user:   backends.User{Key: "alice"},
+			value:  "blue-green",
+			reason: backends.ReasonOff,
+		},
+		{
+			name:  "flag value",
+			flag:  backends.Flag{Key: "log-level", Type: backends.TypeString, DefaultValue: "info"},
+			user:  backends.User{Key: "alice"},
+			value: "debug",
+			// the sdk stores simple flag values as a flag which is off
+			reason: backends.ReasonOff,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			flag, err := ldb.State(ctx, tc.flag, tc.user)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.value, flag.Value)
+			assert.Equal(t, tc.reason, flag.Reason.Kind)
+			assert.Equal(t, tc.ruleID, flag.Reason.RuleID)
+		})
+	}
+
+	t.Run("missing flag", func(t *testing.T) {
+		flag, err := ldb.State(ctx, backends.Flag{Key: "missing", DefaultValue: true}, backends.User{Key: "alice"})
+		assert.Error(t, err)
+		assert.Equal(t, true, flag.Value)
+		assert.Equal(t, backends.ErrorFlagNotFound, flag.Reason.ErrorKind)
+	})
+}
+
+func TestContexts(t *testing.T) {
+
+	ldb := createOfflineBackend(t)
+	ctx := context.Background()
+
+	cases := []struct {
+		name   string
+		user   backends.User
+		value  any
+		reason backends.ReasonKind
+	}{
+		{
+			name:   "user only",
+			user:   backends.User{Key: "alice", Attributes: map[string]any{"branch": "main"}},
+			value:  false,
+			reason: backends.ReasonFallthrough,
+		},
+		{
+			name: "repository rule",
+			user: backends.User{Key: "alice", Contexts: []backends.Context{
+				{Kind: "repository", Key: "flagon", Attributes: map[string]any{"branch": "main"}},
+			}},
+			value:  true,
+			reason: backends.ReasonRuleMatch,
+		},
+		{
+			name: "organisation target without a user",
+			user: backends.User{Contexts: []backends.Context{
+				{Kind: "repository", Key: "flagon", Attributes: map[string]any{"branch": "dev"}},
+				{Kind: "organisation", Key: "pondidum"},
+			}},
+			value:  true,
+			reason: backends.ReasonTargetMatch,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			flag, err := ldb.State(ctx, backends.Flag{Key: "repository-deploy"}, tc.user)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.value, flag.Value)
+			assert.Equal(t, tc.reason, flag.Reason.Kind)
+		})
+	}
+
+	t.Run("invalid context kind", func(t *testing.T) {
+		flag, err := ldb.State(ctx, backends.Flag{Key: "repository-deploy", DefaultValue: true}, backends.User{Key: "alice", Contexts: []backends.Context{{Kind: "kind", Key: "flagon"}}})
+		assert.Error(t, err)
+		assert.Equal(t, true, flag.Value)
+	})
+}
+
+func TestPrivateAttributes(t *testing.T) {
+
+	private := func(c ldcontext.Context) []string {
+		names := []string{}
+		for i := 0; i < c.PrivateAttributeCount(); i++ {
+			ref, _ := c.PrivateAttributeByIndex(i)
+			names = append(names, ref.String())
+		}
+		return names
+	}
+
+	t.Run("user", func(t *testing.T) {
+		c := createContext(context.Background(), backends.User{
+			Key:               "alice",
+			Attributes:        map[string]any{"Email": "alice@example.com", "branch": "main"},
+			PrivateAttributes: []string{"Email"},
+			Anonymous:         true,
+		})
+
+		assert.Equal(t, "alice", c.Key())
+		assert.True(t, c.Anonymous())
+		assert.Equal(t, []string{"email"}, private(c))
+		assert.Equal(t, "alice@example.com", c.GetValue("email").StringValue())
+	})
+
+	t.Run("context", func(t *testing.T) {
+		c := createContext(context.Background(), backends.User{
+			Key: "alice",
+			Contexts: []backends.Context{
+				{Kind: "repository", Key: "flagon", Attributes: map[string]any{"owner": "pondidum"}, PrivateAttributes: []string{"owner"}},
+			},
+		})
+
+		repository := c.IndividualContextByKind("repository")
+		assert.Equal(t, []string{"owner"}, private(repository))
+		assert.Equal(t, []string{}, private(c.IndividualContextByKind(ldcontext.DefaultKind)))
+	})
+}
+
+func TestDataFileAllFlags(t *testing.T) {
+
+	ldb := createOfflineBackend(t)
+
+	flags, err := ldb.AllFlags(context.Background(), backends.User{Key: "alice"})
+	assert.NoError(t, err)
+
+	values := map[string]any{}
+	for _, f := range flags {
+		values[f.Key] = f.Value
+	}
+
+	assert.Equal(t, map[string]any{
+		"ci-replacement-deploy": true,
+		"repository-deploy":     false,
+		"deploy-strategy":       "blue-green",
+		"log-level":             "debug",
+	}, values)
+}
+
+func TestMissingDataFile(t *testing.T) {
+
+	cfg := DefaultConfig()
+	cfg.DataFile = path.Join(t.TempDir(), "missing.json")
+	cfg.Timeout = time.Second
+
+	_, err := CreateBackend(context.Background(), cfg)
+	assert.ErrorContains(t, err, "missing.json")
+}
+
+func TestServiceEndpoints(t *testing.T) {
+
+	assert.Equal(t, interfaces.ServiceEndpoints{}, serviceEndpoints(LaunchDarklyConfiguration{}))
+
+	assert.Equal(t, interfaces.ServiceEndpoints{
+		Streaming: "https://relay.internal",
+		Polling:   "https://relay.internal",
+		Events:    "https://relay.internal",
+	}, serviceEndpoints(LaunchDarklyConfiguration{RelayUri: "https://relay.internal"}))
+
+	assert.Equal(t, interfaces.ServiceEndpoints{
+		Streaming: "https://relay.internal",
+		Polling:   "https://relay.internal",
+		Events:    "https://events.launchdarkly.com",
+	}, serviceEndpoints(LaunchDarklyConfiguration{RelayUri: "https://relay.internal", EventsUri: "https://events.launchdarkly.com"}))
+
+	assert.Equal(t, interfaces.ServiceEndpoints{
+		Streaming: "https://stream.internal",
+		Polling:   "https://base.internal",
+		Events:    "https://events.internal",
+	}, serviceEndpoints(LaunchDarklyConfiguration{BaseUri: "https://base.internal", StreamUri: "https://stream.internal", EventsUri: "https://events.internal"}))
+}
+
+func TestDaemonMode(t *testing.T) {
+
+	server := miniredis.RunT(t)
+	server.HSet("relay:features", "ci-replacement-deploy", `{
+		"key": "ci-replacement-deploy", "version": 3, "on": true, "salt": "abc",
+		"variations": [ true, false ], "offVariation": 1, "fallthrough": { "variation": 1 },
+		"rules": [ { "id": "main-branch", "variation": 0, "clauses": [ { "attribute": "branch", "op": "in", "values": [ "main" ] } ] } ]
+	}`)
+	server.Set("relay:$inited", "")
+
+	cfg := DefaultConfig()
+	cfg.DaemonMode = true
+	cfg.RedisUrl = "redis://" + server.Addr()
+	cfg.RedisPrefix = "relay"
+
+	ldb, err := CreateBackend(context.Background(), cfg)
+	assert.NoError(t, err)
+	defer ldb.Close(context.Background())
+
+	flag, err := ldb.State(context.Background(), backends.Flag{Key: "ci-replacement-deploy"}, backends.User{Key: "alice", Attributes: map[string]any{"branch": "main"}})
+	assert.NoError(t, err)
+	assert.Equal(t, true, flag.Value)
+	assert.Equal(t, "main-branch", flag.Reason.RuleID)
+
+	flags, err := ldb.AllFlags(context.Background(), backends.User{Key: "alice"})
+	assert.NoError(t, err)
+	assert.Len(t, flags, 1)
+
+	t.Run("without a redis url", func(t *testing.T) {
+		_, err := CreateBackend(context.Background(), LaunchDarklyConfiguration{DaemonMode: true})
+		assert.ErrorContains(t, err, "--ld-redis-url")
+	})
+}
+
+func TestInvalidCaBundle(t *testing.T) {
+
+	cfg := DefaultConfig()
+	cfg.CaBundle = path.Join(t.TempDir(), "missing.pem")
+
+	_, err := CreateBackend(context.Background(), cfg)
+	assert.Error(t, err)
+}
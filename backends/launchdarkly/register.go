@@ -0,0 +1,18 @@
+package launchdarkly
+
+import (
+	"context"
+	"flagon/backends"
+)
+
+func init() {
+	backends.Register("launchdarkly", "LaunchDarkly Backend", DefaultConfig, ConfigFromEnvironment,
+		func(ctx context.Context, cfg LaunchDarklyConfiguration) (backends.Backend, error) {
+			backend, err := CreateBackend(ctx, cfg)
+			if err != nil {
+				return nil, err
+			}
+			return backend, nil
+		},
+	)
+}
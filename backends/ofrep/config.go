@@ -0,0 +1,88 @@
+package ofrep
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+const UrlEnvVar = "FLAGON_OFREP_URL"
+const HeadersEnvVar = "FLAGON_OFREP_HEADERS"
+const TimeoutEnvVar = "FLAGON_OFREP_TIMEOUT"
+
+type OfrepConfiguration struct {
+	Url     string
+	Headers []string
+	Timeout time.Duration
+}
+
+func (cfg *OfrepConfiguration) OverrideFrom(other OfrepConfiguration) {
+	if other.Url != "" {
+		cfg.Url = other.Url
+	}
+
+	if len(other.Headers) > 0 {
+		cfg.Headers = other.Headers
+	}
+
+	if other.Timeout > 0 {
+		cfg.Timeout = other.Timeout
+	}
+}
+
+func (cfg *OfrepConfiguration) Flags() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("OFREP Backend", pflag.ContinueOnError)
+
+	flags.StringVar(&cfg.Url, "ofrep-url", "", "the base url of the ofrep api, for example http://localhost:8016")
+	flags.StringArrayVar(&cfg.Headers, "ofrep-header", nil, "a 'Key: Value' header to send with each request, such as Authorization.  Can be specified multiple times")
+	flags.DurationVar(&cfg.Timeout, "ofrep-timeout", 0, "timeout before failing to communicate with the ofrep api")
+
+	return flags
+}
+
+func ConfigFromEnvironment() OfrepConfiguration {
+
+	cfg := OfrepConfiguration{}
+	cfg.Url = os.Getenv(UrlEnvVar)
+
+	// one header per line, as header values can contain commas
+	if val := os.Getenv(HeadersEnvVar); val != "" {
+		for _, line := range strings.Split(val, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				cfg.Headers = append(cfg.Headers, line)
+			}
+		}
+	}
+
+	if val := os.Getenv(TimeoutEnvVar); val != "" {
+		if timeout, err := time.ParseDuration(val); err == nil {
+			cfg.Timeout = timeout
+		}
+	}
+
+	return cfg
+}
+
+func DefaultConfig() OfrepConfiguration {
+	return OfrepConfiguration{
+		Timeout: 5 * time.Second,
+	}
+}
+
+func parseHeaders(pairs []string) (map[string]string, error) {
+	headers := make(map[string]string, len(pairs))
+
+	for _, pair := range pairs {
+		index := strings.Index(pair, ":")
+		if index == -1 {
+			return nil, fmt.Errorf("unable to parse '%s' as a header, missing a ':'", pair)
+		}
+
+		headers[strings.TrimSpace(pair[:index])] = strings.TrimSpace(pair[index+1:])
+	}
+
+	return headers, nil
+}
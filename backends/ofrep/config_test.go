@@ -0,0 +1,61 @@
+package ofrep
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadEnvironment(t *testing.T) {
+
+	os.Setenv(UrlEnvVar, "http://localhost:8016")
+	os.Setenv(HeadersEnvVar, "Authorization: Bearer abc\nAccept: application/json, text/plain\n")
+	os.Setenv(TimeoutEnvVar, "3s")
+
+	cfg := ConfigFromEnvironment()
+
+	assert.Equal(t, "http://localhost:8016", cfg.Url)
+	assert.Equal(t, []string{"Authorization: Bearer abc", "Accept: application/json, text/plain"}, cfg.Headers)
+	assert.Equal(t, 3*time.Second, cfg.Timeout)
+}
+
+func TestFlags(t *testing.T) {
+
+	cfg := OfrepConfiguration{}
+	flags := cfg.Flags()
+
+	assert.NoError(t, flags.Parse([]string{
+		"--ofrep-url", "http://flagd:8016",
+		"--ofrep-header", "Authorization: Bearer abc",
+		"--ofrep-header", "Accept: application/json, text/plain",
+		"--ofrep-timeout", "1s",
+	}))
+
+	assert.Equal(t, "http://flagd:8016", cfg.Url)
+	assert.Equal(t, []string{"Authorization: Bearer abc", "Accept: application/json, text/plain"}, cfg.Headers)
+	assert.Equal(t, time.Second, cfg.Timeout)
+}
+
+func TestOverridingValues(t *testing.T) {
+
+	base := DefaultConfig()
+	base.OverrideFrom(OfrepConfiguration{})
+	assert.Equal(t, DefaultConfig(), base)
+
+	base.OverrideFrom(OfrepConfiguration{Url: "http://other", Headers: []string{"A: b"}})
+	assert.Equal(t, "http://other", base.Url)
+	assert.Equal(t, []string{"A: b"}, base.Headers)
+	assert.Equal(t, 5*time.Second, base.Timeout)
+}
+
+func TestParseHeaders(t *testing.T) {
+
+	headers, err := parseHeaders([]string{"Authorization: Bearer a:b", " X-Api-Key :123 "})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"Authorization": "Bearer a:b", "X-Api-Key": "123"}, headers)
+
+	_, err = parseHeaders([]string{"no-colon"})
+	assert.EqualError(t, err, "unable to parse 'no-colon' as a header, missing a ':'")
+}
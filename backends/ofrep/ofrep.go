@@ -0,0 +1,251 @@
+package ofrep
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flagon/backends"
+	"flagon/tracing"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+var tr = otel.Tracer("backend.ofrep")
+
+const (
+	evaluatePath = "/ofrep/v1/evaluate/flags"
+
+	errorFlagNotFound = "FLAG_NOT_FOUND"
+)
+
+// the reasons defined by openfeature, which are mapped onto flagon's reasons
+const (
+	reasonTargetingMatch = "TARGETING_MATCH"
+	reasonDisabled       = "DISABLED"
+	reasonError          = "ERROR"
+)
+
+type evaluationRequest struct {
+	Context map[string]any `json:"context"`
+}
+
+// evaluationResponse is both the success and failure response of the single
+// flag endpoint, and each item of the bulk endpoint's response
+type evaluationResponse struct {
+	Key          string `json:"key"`
+	Value        any    `json:"value"`
+	Reason       string `json:"reason"`
+	Variant      string `json:"variant"`
+	ErrorCode    string `json:"errorCode"`
+	ErrorDetails string `json:"errorDetails"`
+}
+
+type bulkEvaluationResponse struct {
+	Flags        []evaluationResponse `json:"flags"`
+	ErrorCode    string               `json:"errorCode"`
+	ErrorDetails string               `json:"errorDetails"`
+}
+
+// OfrepBackend evaluates flags using the OpenFeature Remote Evaluation
+// Protocol, which is served by flagd, go-feature-flag's relay proxy, and
+// other openfeature compatible providers
+type OfrepBackend struct {
+	client  *http.Client
+	baseUrl string
+	headers map[string]string
+}
+
+func CreateBackend(ctx context.Context, cfg OfrepConfiguration) (*OfrepBackend, error) {
+	ctx, span := tr.Start(ctx, "create_backend")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("ofrep.url", cfg.Url))
+
+	if cfg.Url == "" {
+		return nil, tracing.Errorf(span, "no ofrep url specified, use --ofrep-url or $%s", UrlEnvVar)
+	}
+
+	headers, err := parseHeaders(cfg.Headers)
+	if err != nil {
+		return nil, tracing.Error(span, err)
+	}
+
+	return &OfrepBackend{
+		client:  &http.Client{Timeout: cfg.Timeout},
+		baseUrl: strings.TrimSuffix(cfg.Url, "/"),
+		headers: headers,
+	}, nil
+}
+
+func (ob *OfrepBackend) Close(ctx context.Context) error {
+	ob.client.CloseIdleConnections()
+	return nil
+}
+
+func (ob *OfrepBackend) State(ctx context.Context, flag backends.Flag, user backends.User) (backends.Flag, error) {
+	ctx, span := tr.Start(ctx, "state")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("flag.key", flag.Key))
+
+	flag.Value = flag.DefaultValue
+
+	res := evaluationResponse{}
+	status, err := ob.call(ctx, evaluatePath+"/"+url.PathEscape(flag.Key), createContext(user), &res)
+	if err != nil {
+		return flag, tracing.Error(span, err)
+	}
+
+	span.SetAttributes(
+		attribute.String("ofrep.reason", res.Reason),
+		attribute.String("ofrep.variant", res.Variant),
+	)
+
+	if res.ErrorCode != "" {
+		flag.Reason = &backends.Reason{Kind: backends.ReasonError, ErrorKind: res.ErrorCode}
+		span.SetAttributes(attribute.String("reason", flag.Reason.String()))
+
+		// a missing flag is not a failure, it just uses the default
+		if res.ErrorCode == errorFlagNotFound {
+			return flag, nil
+		}
+
+		return flag, tracing.Errorf(span, "error evaluating %s: %s: %s", flag.Key, res.ErrorCode, res.ErrorDetails)
+	}
+
+	if status != http.StatusOK {
+		return flag, tracing.Errorf(span, "error evaluating %s: %s", flag.Key, http.StatusText(status))
+	}
+
+	if res.Value != nil && !backends.IsType(flag.ValueType(), res.Value) {
+		flag.Reason = &backends.Reason{Kind: backends.ReasonError, ErrorKind: backends.ErrorWrongType}
+		span.SetAttributes(attribute.String("reason", flag.Reason.String()))
+		return flag, tracing.Errorf(span, "flag %s has a %T value, which cannot be used as a %s", flag.Key, res.Value, flag.ValueType())
+	}
+
+	flag.Reason = createReason(res)
+	span.SetAttributes(attribute.String("reason", flag.Reason.String()))
+
+	if res.Value != nil {
+		flag.Value = res.Value
+	}
+
+	return flag, nil
+}
+
+func (ob *OfrepBackend) AllFlags(ctx context.Context, user backends.User) ([]backends.Flag, error) {
+	ctx, span := tr.Start(ctx, "all_flags")
+	defer span.End()
+
+	res := bulkEvaluationResponse{}
+	status, err := ob.call(ctx, evaluatePath, createContext(user), &res)
+	if err != nil {
+		return nil, tracing.Error(span, err)
+	}
+
+	if res.ErrorCode != "" {
+		return nil, tracing.Errorf(span, "error evaluating flags: %s: %s", res.ErrorCode, res.ErrorDetails)
+	}
+
+	if status != http.StatusOK {
+		return nil, tracing.Errorf(span, "error evaluating flags: %s", http.StatusText(status))
+	}
+
+	flags := make([]backends.Flag, 0, len(res.Flags))
+
+	for _, r := range res.Flags {
+		flag := backends.Flag{
+			Key:    r.Key,
+			Type:   backends.TypeOf(r.Value),
+			Value:  r.Value,
+			Reason: createReason(r),
+		}
+
+		if r.ErrorCode != "" {
+			flag.Reason = &backends.Reason{Kind: backends.ReasonError, ErrorKind: r.ErrorCode}
+		}
+
+		flags = append(flags, flag)
+	}
+
+	span.SetAttributes(attribute.Int("flags.count", len(flags)))
+
+	return flags, nil
+}
+
+// createContext uses the user's key as the openfeature targetingKey, and the
+// attributes as the rest of the evaluation context.  OFREP has no private
+// attributes, so they are left out rather than sent to the provider.
+func createContext(user backends.User) evaluationRequest {
+	ctx := backends.PublicAttributes(user.Attributes, user.PrivateAttributes)
+	ctx["targetingKey"] = user.Key
+
+	return evaluationRequest{Context: ctx}
+}
+
+func createReason(res evaluationResponse) *backends.Reason {
+	switch res.Reason {
+	case reasonDisabled:
+		return &backends.Reason{Kind: backends.ReasonOff}
+	case reasonTargetingMatch:
+		return &backends.Reason{Kind: backends.ReasonRuleMatch}
+	case reasonError:
+		return &backends.Reason{Kind: backends.ReasonError, ErrorKind: res.ErrorCode}
+	default:
+		// static, default, split, and any provider specific reasons
+		return &backends.Reason{Kind: backends.ReasonFallthrough}
+	}
+}
+
+// call posts the body to the api, and decodes any json response, returning
+// the status code so callers can handle ofrep's error responses
+func (ob *OfrepBackend) call(ctx context.Context, path string, body any, response any) (int, error) {
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ob.baseUrl+path, bytes.NewReader(b))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range ob.headers {
+		req.Header.Set(k, v)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	res, err := ob.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return res.StatusCode, fmt.Errorf("the ofrep api returned %s, check the --ofrep-header values", res.Status)
+
+	case http.StatusTooManyRequests:
+		return res.StatusCode, fmt.Errorf("the ofrep api is rate limiting requests, retry after %s", res.Header.Get("Retry-After"))
+	}
+
+	if !strings.HasPrefix(res.Header.Get("Content-Type"), "application/json") {
+		if res.StatusCode == http.StatusOK {
+			return res.StatusCode, fmt.Errorf("the ofrep api returned %s content", res.Header.Get("Content-Type"))
+		}
+
+		msg, _ := io.ReadAll(res.Body)
+		return res.StatusCode, fmt.Errorf("the ofrep api returned %s: %s", res.Status, bytes.TrimSpace(msg))
+	}
+
+	return res.StatusCode, json.NewDecoder(res.Body).Decode(response)
+}
@@ -0,0 +1,224 @@
+package ofrep
+
+import (
+	"context"
+	"encoding/json"
+	"flagon/backends"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeProvider answers like an ofrep provider, evaluating flags which are
+// on for users on the main branch
+type fakeProvider struct {
+	flags    map[string]any
+	requests []*http.Request
+	contexts []map[string]any
+}
+
+func (p *fakeProvider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.requests = append(p.requests, r)
+
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	req := evaluationRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJson(w, http.StatusBadRequest, evaluationResponse{ErrorCode: "PARSE_ERROR", ErrorDetails: err.Error()})
+		return
+	}
+	p.contexts = append(p.contexts, req.Context)
+
+	if r.URL.Path == evaluatePath {
+		flags := []evaluationResponse{}
+		for key := range p.flags {
+			flags = append(flags, p.evaluate(key, req.Context))
+		}
+		writeJson(w, http.StatusOK, bulkEvaluationResponse{Flags: flags})
+		return
+	}
+
+	key := strings.TrimPrefix(r.URL.Path, evaluatePath+"/")
+
+	if key == "rate-limited" {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	}
+
+	if _, found := req.Context["targetingKey"].(string); !found || req.Context["targetingKey"] == "" {
+		writeJson(w, http.StatusBadRequest, evaluationResponse{Key: key, ErrorCode: "TARGETING_KEY_MISSING", ErrorDetails: "no targeting key"})
+		return
+	}
+
+	if _, found := p.flags[key]; !found {
+		writeJson(w, http.StatusNotFound, evaluationResponse{Key: key, ErrorCode: "FLAG_NOT_FOUND", ErrorDetails: "flag not found"})
+		return
+	}
+
+	writeJson(w, http.StatusOK, p.evaluate(key, req.Context))
+}
+
+func (p *fakeProvider) evaluate(key string, ctx map[string]any) evaluationResponse {
+	if key == "disabled" {
+		return evaluationResponse{Key: key, Value: false, Reason: "DISABLED"}
+	}
+
+	if ctx["branch"] == "main" {
+		return evaluationResponse{Key: key, Value: p.flags[key], Reason: "TARGETING_MATCH", Variant: "on"}
+	}
+
+	return evaluationResponse{Key: key, Reason: "DEFAULT"}
+}
+
+func writeJson(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func createBackend(t *testing.T, provider *fakeProvider, headers ...string) *OfrepBackend {
+	server := httptest.NewServer(provider)
+	t.Cleanup(server.Close)
+
+	cfg := DefaultConfig()
+	cfg.Url = server.URL + "/"
+	cfg.Headers = headers
+
+	ob, err := CreateBackend(context.Background(), cfg)
+	assert.NoError(t, err)
+
+	return ob
+}
+
+func TestState(t *testing.T) {
+
+	provider := &fakeProvider{flags: map[string]any{
+		"enabled":       true,
+		"strategy":      "blue-green",
+		"deploy-config": map[string]any{"retries": 3.0},
+		"disabled":      true,
+	}}
+	ob := createBackend(t, provider, "Authorization: Bearer secret")
+	ctx := context.Background()
+
+	main := backends.User{Key: "alice", Attributes: map[string]any{"branch": "main"}}
+	dev := backends.User{Key: "alice", Attributes: map[string]any{"branch": "dev"}}
+
+	t.Run("targeting match", func(t *testing.T) {
+		flag, err := ob.State(ctx, backends.Flag{Key: "enabled", DefaultValue: false}, main)
+		assert.NoError(t, err)
+		assert.Equal(t, true, flag.Value)
+		assert.Equal(t, backends.ReasonRuleMatch, flag.Reason.Kind)
+		assert.Equal(t, map[string]any{"targetingKey": "alice", "branch": "main"}, provider.contexts[len(provider.contexts)-1])
+	})
+
+	t.Run("private attributes are not sent", func(t *testing.T) {
+		user := backends.User{Key: "alice", Attributes: map[string]any{"branch": "main", "email": "alice@example.com"}, PrivateAttributes: []string{"email"}}
+
+		_, err := ob.State(ctx, backends.Flag{Key: "enabled", DefaultValue: false}, user)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"targetingKey": "alice", "branch": "main"}, provider.contexts[len(provider.contexts)-1])
+	})
+
+	t.Run("default value", func(t *testing.T) {
+		flag, err := ob.State(ctx, backends.Flag{Key: "enabled", DefaultValue: false}, dev)
+		assert.NoError(t, err)
+		assert.Equal(t, false, flag.Value)
+		assert.Equal(t, backends.ReasonFallthrough, flag.Reason.Kind)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		flag, err := ob.State(ctx, backends.Flag{Key: "disabled", DefaultValue: true}, main)
+		assert.NoError(t, err)
+		assert.Equal(t, false, flag.Value)
+		assert.Equal(t, backends.ReasonOff, flag.Reason.Kind)
+	})
+
+	t.Run("string flag", func(t *testing.T) {
+		flag, err := ob.State(ctx, backends.Flag{Key: "strategy", Type: backends.TypeString, DefaultValue: "rolling"}, main)
+		assert.NoError(t, err)
+		assert.Equal(t, "blue-green", flag.Value)
+	})
+
+	t.Run("json flag", func(t *testing.T) {
+		flag, err := ob.State(ctx, backends.Flag{Key: "deploy-config", Type: backends.TypeJSON}, main)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"retries": 3.0}, flag.Value)
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		flag, err := ob.State(ctx, backends.Flag{Key: "strategy", DefaultValue: false}, main)
+		assert.Error(t, err)
+		assert.Equal(t, false, flag.Value)
+		assert.Equal(t, backends.ErrorWrongType, flag.Reason.ErrorKind)
+	})
+
+	t.Run("missing flag", func(t *testing.T) {
+		flag, err := ob.State(ctx, backends.Flag{Key: "missing", DefaultValue: true}, main)
+		assert.NoError(t, err)
+		assert.Equal(t, true, flag.Value)
+		assert.Equal(t, backends.ErrorFlagNotFound, flag.Reason.ErrorKind)
+	})
+
+	t.Run("evaluation error", func(t *testing.T) {
+		flag, err := ob.State(ctx, backends.Flag{Key: "enabled", DefaultValue: true}, backends.User{})
+		assert.EqualError(t, err, "error evaluating enabled: TARGETING_KEY_MISSING: no targeting key")
+		assert.Equal(t, true, flag.Value)
+		assert.Equal(t, "TARGETING_KEY_MISSING", flag.Reason.ErrorKind)
+	})
+
+	t.Run("rate limited", func(t *testing.T) {
+		flag, err := ob.State(ctx, backends.Flag{Key: "rate-limited", DefaultValue: true}, main)
+		assert.EqualError(t, err, "the ofrep api is rate limiting requests, retry after 30")
+		assert.Equal(t, true, flag.Value)
+	})
+
+	t.Run("key is escaped", func(t *testing.T) {
+		ob.State(ctx, backends.Flag{Key: "some/flag", DefaultValue: false}, main)
+		assert.Equal(t, evaluatePath+"/some%2Fflag", provider.requests[len(provider.requests)-1].URL.EscapedPath())
+	})
+}
+
+func TestAllFlags(t *testing.T) {
+
+	provider := &fakeProvider{flags: map[string]any{
+		"enabled":  true,
+		"strategy": "blue-green",
+	}}
+	ob := createBackend(t, provider, "Authorization: Bearer secret")
+
+	flags, err := ob.AllFlags(context.Background(), backends.User{Key: "alice", Attributes: map[string]any{"branch": "main"}})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []backends.Flag{
+		{Key: "enabled", Type: backends.TypeBool, Value: true, Reason: &backends.Reason{Kind: backends.ReasonRuleMatch}},
+		{Key: "strategy", Type: backends.TypeString, Value: "blue-green", Reason: &backends.Reason{Kind: backends.ReasonRuleMatch}},
+	}, flags)
+}
+
+func TestUnauthorized(t *testing.T) {
+
+	ob := createBackend(t, &fakeProvider{})
+
+	flag, err := ob.State(context.Background(), backends.Flag{Key: "enabled", DefaultValue: true}, backends.User{Key: "alice"})
+	assert.EqualError(t, err, "the ofrep api returned 401 Unauthorized, check the --ofrep-header values")
+	assert.Equal(t, true, flag.Value)
+
+	_, err = ob.AllFlags(context.Background(), backends.User{Key: "alice"})
+	assert.Error(t, err)
+}
+
+func TestCreateBackendErrors(t *testing.T) {
+
+	_, err := CreateBackend(context.Background(), DefaultConfig())
+	assert.ErrorContains(t, err, "no ofrep url specified")
+
+	_, err = CreateBackend(context.Background(), OfrepConfiguration{Url: "http://localhost", Headers: []string{"broken"}})
+	assert.ErrorContains(t, err, "unable to parse 'broken' as a header")
+}
@@ -0,0 +1,18 @@
+package ofrep
+
+import (
+	"context"
+	"flagon/backends"
+)
+
+func init() {
+	backends.Register("ofrep", "OFREP Backend", DefaultConfig, ConfigFromEnvironment,
+		func(ctx context.Context, cfg OfrepConfiguration) (backends.Backend, error) {
+			backend, err := CreateBackend(ctx, cfg)
+			if err != nil {
+				return nil, err
+			}
+			return backend, nil
+		},
+	)
+}
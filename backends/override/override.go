@@ -0,0 +1,154 @@
+package override
+
+import (
+	"context"
+	"errors"
+	"flagon/backends"
+	"flagon/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var tr = otel.Tracer("backend.override")
+
+// OverrideBackend forces the value of some flags, regardless of what the
+// backend it wraps would return.  Overridden flags have the reason OVERRIDE,
+// and all other flags are evaluated by the wrapped backend.
+type OverrideBackend struct {
+	backend   backends.Backend
+	overrides map[string]string
+}
+
+// watchingBackend keeps the wrapped backend's change notifications available
+// to `flagon watch` and `flagon wait`
+type watchingBackend struct {
+	*OverrideBackend
+	watcher backends.WatchBackend
+}
+
+// Wrap returns a backend which uses the overrides, which are raw values
+// parsed as the type of the flag being evaluated, before asking the backend.
+func Wrap(backend backends.Backend, overrides map[string]string) backends.Backend {
+	ob := &OverrideBackend{
+		backend:   backend,
+		overrides: overrides,
+	}
+
+	if watcher, ok := backend.(backends.WatchBackend); ok {
+		return &watchingBackend{OverrideBackend: ob, watcher: watcher}
+	}
+
+	return ob
+}
+
+func (ob *OverrideBackend) Close(ctx context.Context) error {
+	return ob.backend.Close(ctx)
+}
+
+func (ob *OverrideBackend) State(ctx context.Context, flag backends.Flag, user backends.User) (backends.Flag, error) {
+	ctx, span := tr.Start(ctx, "state")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("flag.key", flag.Key))
+
+	raw, found := ob.overrides[flag.Key]
+	span.SetAttributes(attribute.Bool("overridden", found))
+
+	if !found {
+		return ob.backend.State(ctx, flag, user)
+	}
+
+	flag.Value = flag.DefaultValue
+
+	value, err := backends.ParseValue(flag.ValueType(), raw)
+	if err != nil {
+		flag.Reason = &backends.Reason{Kind: backends.ReasonError, ErrorKind: backends.ErrorWrongType}
+		span.SetAttributes(attribute.String("reason", flag.Reason.String()))
+		return flag, tracing.Errorf(span, "unable to parse the override of %s as a %s: %w", flag.Key, flag.ValueType(), err)
+	}
+
+	flag.Value = value
+	flag.Reason = &backends.Reason{Kind: backends.ReasonOverride}
+	span.SetAttributes(attribute.String("reason", flag.Reason.String()))
+
+	return flag, nil
+}
+
+// AllFlags replaces the backend's flags with any overrides, and adds the
+// overridden flags which the backend does not know about.
+func (ob *OverrideBackend) AllFlags(ctx context.Context, user backends.User) ([]backends.Flag, error) {
+	ctx, span := tr.Start(ctx, "all_flags")
+	defer span.End()
+
+	all, ok := ob.backend.(backends.AllFlagsBackend)
+	if !ok {
+		return nil, tracing.Error(span, errors.New("the backend does not support evaluating all flags"))
+	}
+
+	flags, err := all.AllFlags(ctx, user)
+	if err != nil {
+		return nil, tracing.Error(span, err)
+	}
+
+	seen := map[string]bool{}
+
+	for i, flag := range flags {
+		seen[flag.Key] = true
+
+		if _, found := ob.overrides[flag.Key]; !found {
+			continue
+		}
+
+		if flags[i], err = ob.State(ctx, backends.Flag{Key: flag.Key, Type: flag.Type}, user); err != nil {
+			return nil, tracing.Error(span, err)
+		}
+	}
+
+	for key := range ob.overrides {
+		if seen[key] {
+			continue
+		}
+
+		flag, err := ob.State(ctx, backends.Flag{Key: key, Type: backends.TypeJSON}, user)
+		if err != nil {
+			// an override which is not json is a string flag
+			if flag, err = ob.State(ctx, backends.Flag{Key: key, Type: backends.TypeString}, user); err != nil {
+				return nil, tracing.Error(span, err)
+			}
+		}
+
+		flag.Type = backends.TypeOf(flag.Value)
+		flags = append(flags, flag)
+	}
+
+	span.SetAttributes(attribute.Int("flags.count", len(flags)))
+
+	return flags, nil
+}
+
+// Watch sends an overridden flag once, as it cannot change, and watches any
+// other flag with the wrapped backend
+func (wb *watchingBackend) Watch(ctx context.Context, flag backends.Flag, user backends.User) (<-chan backends.Flag, error) {
+	ctx, span := tr.Start(ctx, "watch")
+	defer span.End()
+
+	if _, found := wb.overrides[flag.Key]; !found {
+		return wb.watcher.Watch(ctx, flag, user)
+	}
+
+	current, err := wb.State(ctx, flag, user)
+	if err != nil {
+		return nil, tracing.Error(span, err)
+	}
+
+	changes := make(chan backends.Flag, 1)
+	changes <- current
+
+	go func() {
+		defer close(changes)
+		<-ctx.Done()
+	}()
+
+	return changes, nil
+}
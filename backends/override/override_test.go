@@ -0,0 +1,141 @@
+package override
+
+import (
+	"context"
+	"flagon/backends"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type staticBackend struct {
+	flags map[string]any
+}
+
+func (sb *staticBackend) State(ctx context.Context, flag backends.Flag, user backends.User) (backends.Flag, error) {
+	flag.Value = flag.DefaultValue
+	flag.Reason = &backends.Reason{Kind: backends.ReasonError, ErrorKind: backends.ErrorFlagNotFound}
+
+	if value, found := sb.flags[flag.Key]; found {
+		flag.Value = value
+		flag.Reason = &backends.Reason{Kind: backends.ReasonFallthrough}
+	}
+
+	return flag, nil
+}
+
+func (sb *staticBackend) Close(ctx context.Context) error {
+	return nil
+}
+
+type listingBackend struct {
+	staticBackend
+}
+
+func (lb *listingBackend) AllFlags(ctx context.Context, user backends.User) ([]backends.Flag, error) {
+	flags := []backends.Flag{}
+	for key, value := range lb.flags {
+		flags = append(flags, backends.Flag{Key: key, Type: backends.TypeOf(value), Value: value, Reason: &backends.Reason{Kind: backends.ReasonFallthrough}})
+	}
+
+	return flags, nil
+}
+
+type notifyingBackend struct {
+	staticBackend
+	watched []string
+}
+
+func (nb *notifyingBackend) Watch(ctx context.Context, flag backends.Flag, user backends.User) (<-chan backends.Flag, error) {
+	nb.watched = append(nb.watched, flag.Key)
+	return make(chan backends.Flag), nil
+}
+
+func TestState(t *testing.T) {
+
+	backend := Wrap(&staticBackend{flags: map[string]any{"deploy": false, "strategy": "rolling"}}, map[string]string{
+		"deploy":  "true",
+		"retries": "3",
+	})
+
+	ctx := context.Background()
+	user := backends.User{Key: "alice"}
+
+	t.Run("overridden", func(t *testing.T) {
+		flag, err := backend.State(ctx, backends.Flag{Key: "deploy", DefaultValue: false}, user)
+		assert.NoError(t, err)
+		assert.Equal(t, true, flag.Value)
+		assert.Equal(t, backends.ReasonOverride, flag.Reason.Kind)
+	})
+
+	t.Run("overridden as the flag's type", func(t *testing.T) {
+		flag, err := backend.State(ctx, backends.Flag{Key: "retries", Type: backends.TypeNumber}, user)
+		assert.NoError(t, err)
+		assert.Equal(t, 3.0, flag.Value)
+	})
+
+	t.Run("override of the wrong type", func(t *testing.T) {
+		flag, err := backend.State(ctx, backends.Flag{Key: "retries", DefaultValue: true}, user)
+		assert.Error(t, err)
+		assert.Equal(t, true, flag.Value)
+		assert.Equal(t, backends.ErrorWrongType, flag.Reason.ErrorKind)
+	})
+
+	t.Run("not overridden", func(t *testing.T) {
+		flag, err := backend.State(ctx, backends.Flag{Key: "strategy", Type: backends.TypeString}, user)
+		assert.NoError(t, err)
+		assert.Equal(t, "rolling", flag.Value)
+		assert.Equal(t, backends.ReasonFallthrough, flag.Reason.Kind)
+	})
+}
+
+func TestAllFlags(t *testing.T) {
+
+	backend := Wrap(&listingBackend{staticBackend{flags: map[string]any{"deploy": false, "strategy": "rolling"}}}, map[string]string{
+		"deploy":  "true",
+		"retries": "3",
+		"owner":   "platform",
+	})
+
+	flags, err := backend.(backends.AllFlagsBackend).AllFlags(context.Background(), backends.User{Key: "alice"})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []backends.Flag{
+		{Key: "deploy", Type: backends.TypeBool, Value: true, Reason: &backends.Reason{Kind: backends.ReasonOverride}},
+		{Key: "strategy", Type: backends.TypeString, Value: "rolling", Reason: &backends.Reason{Kind: backends.ReasonFallthrough}},
+		{Key: "retries", Type: backends.TypeNumber, Value: 3.0, Reason: &backends.Reason{Kind: backends.ReasonOverride}},
+		{Key: "owner", Type: backends.TypeString, Value: "platform", Reason: &backends.Reason{Kind: backends.ReasonOverride}},
+	}, flags)
+
+	t.Run("unsupported backend", func(t *testing.T) {
+		backend := Wrap(&staticBackend{}, map[string]string{"deploy": "true"})
+
+		_, err := backend.(backends.AllFlagsBackend).AllFlags(context.Background(), backends.User{Key: "alice"})
+		assert.Error(t, err)
+	})
+}
+
+func TestWatch(t *testing.T) {
+
+	inner := &notifyingBackend{staticBackend: staticBackend{flags: map[string]any{}}}
+	backend := Wrap(inner, map[string]string{"deploy": "true"})
+
+	watcher, ok := backend.(backends.WatchBackend)
+	assert.True(t, ok)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	changes, err := watcher.Watch(ctx, backends.Flag{Key: "deploy"}, backends.User{})
+	assert.NoError(t, err)
+	assert.Equal(t, true, (<-changes).Value)
+
+	cancel()
+	_, open := <-changes
+	assert.False(t, open)
+
+	_, err = watcher.Watch(context.Background(), backends.Flag{Key: "other"}, backends.User{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"other"}, inner.watched)
+
+	_, ok = Wrap(&staticBackend{}, map[string]string{}).(backends.WatchBackend)
+	assert.False(t, ok)
+}
@@ -0,0 +1,47 @@
+package plugin
+
+import (
+	"os"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+const TimeoutEnvVar = "FLAGON_PLUGIN_TIMEOUT"
+
+type PluginConfiguration struct {
+	Timeout time.Duration
+}
+
+func (cfg *PluginConfiguration) OverrideFrom(other PluginConfiguration) {
+	if other.Timeout > 0 {
+		cfg.Timeout = other.Timeout
+	}
+}
+
+func (cfg *PluginConfiguration) Flags() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("Plugins", pflag.ContinueOnError)
+
+	flags.DurationVar(&cfg.Timeout, "plugin-timeout", 0, "how long a plugin has to answer each request before it is stopped")
+
+	return flags
+}
+
+func ConfigFromEnvironment() PluginConfiguration {
+
+	cfg := PluginConfiguration{}
+
+	if val := os.Getenv(TimeoutEnvVar); val != "" {
+		if timeout, err := time.ParseDuration(val); err == nil {
+			cfg.Timeout = timeout
+		}
+	}
+
+	return cfg
+}
+
+func DefaultConfig() PluginConfiguration {
+	return PluginConfiguration{
+		Timeout: 10 * time.Second,
+	}
+}
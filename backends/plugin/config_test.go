@@ -0,0 +1,37 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadEnvironment(t *testing.T) {
+
+	t.Setenv(TimeoutEnvVar, "3s")
+
+	cfg := ConfigFromEnvironment()
+
+	assert.Equal(t, 3*time.Second, cfg.Timeout)
+}
+
+func TestFlags(t *testing.T) {
+
+	cfg := PluginConfiguration{}
+	flags := cfg.Flags()
+
+	assert.NoError(t, flags.Parse([]string{"--plugin-timeout", "1s"}))
+
+	assert.Equal(t, time.Second, cfg.Timeout)
+}
+
+func TestOverridingValues(t *testing.T) {
+
+	base := DefaultConfig()
+	base.OverrideFrom(PluginConfiguration{})
+	assert.Equal(t, DefaultConfig(), base)
+
+	base.OverrideFrom(PluginConfiguration{Timeout: time.Second})
+	assert.Equal(t, time.Second, base.Timeout)
+}
@@ -0,0 +1,237 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flagon/backends"
+	"flagon/tracing"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var tr = otel.Tracer("backend.plugin")
+
+// how long a plugin has to exit after being asked to close
+const closeTimeout = 5 * time.Second
+
+// PluginBackend runs an external executable, and evaluates flags by sending
+// it json requests over stdin, and reading json responses from stdout
+type PluginBackend struct {
+	name    string
+	cmd     *exec.Cmd
+	timeout time.Duration
+
+	lock    sync.Mutex
+	stdin   io.WriteCloser
+	stdout  io.Closer
+	encoder *json.Encoder
+	decoder *json.Decoder
+	// stopped is set when the plugin is killed for not answering, after
+	// which it can't be used
+	stopped error
+
+	exited chan struct{}
+}
+
+// Find returns the path of the plugin executable for a backend name, if it
+// is on the $PATH
+func Find(name string) (string, bool) {
+	path, err := exec.LookPath(ExecutablePrefix + name)
+	if err != nil {
+		return "", false
+	}
+
+	return path, true
+}
+
+func CreateBackend(ctx context.Context, name string, path string, cfg PluginConfiguration) (*PluginBackend, error) {
+	ctx, span := tr.Start(ctx, "create_backend")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("plugin.name", name),
+		attribute.String("plugin.path", path),
+		attribute.String("plugin.timeout", cfg.Timeout.String()),
+	)
+
+	cmd := exec.Command(path)
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+
+	if traceParent := tracing.TraceParent(ctx); traceParent != "" {
+		cmd.Env = append(cmd.Env, "TRACEPARENT="+traceParent)
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, tracing.Error(span, err)
+	}
+
+	// not cmd.StdoutPipe, as Wait closes it while a response could still be
+	// being read
+	stdout, writer, err := os.Pipe()
+	if err != nil {
+		return nil, tracing.Error(span, err)
+	}
+	cmd.Stdout = writer
+
+	err = cmd.Start()
+	writer.Close()
+
+	if err != nil {
+		stdout.Close()
+		return nil, tracing.Errorf(span, "unable to start the %s backend: %w", name, err)
+	}
+
+	pb := &PluginBackend{
+		name:    name,
+		cmd:     cmd,
+		timeout: cfg.Timeout,
+		stdin:   stdin,
+		stdout:  stdout,
+		encoder: json.NewEncoder(stdin),
+		decoder: json.NewDecoder(stdout),
+		exited:  make(chan struct{}),
+	}
+
+	go func() {
+		cmd.Wait()
+		close(pb.exited)
+	}()
+
+	return pb, nil
+}
+
+func (pb *PluginBackend) State(ctx context.Context, flag backends.Flag, user backends.User) (backends.Flag, error) {
+	ctx, span := tr.Start(ctx, "state")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("plugin.name", pb.name),
+		attribute.String("flag.key", flag.Key),
+	)
+
+	// used when the plugin fails to answer
+	flag.Value = flag.DefaultValue
+
+	res, err := pb.call(ctx, Request{
+		Method:      MethodState,
+		Flag:        &flag,
+		User:        &user,
+		TraceParent: tracing.TraceParent(ctx),
+	})
+	if err != nil {
+		return flag, tracing.Error(span, err)
+	}
+
+	if res.Flag != nil {
+		flag = *res.Flag
+	}
+
+	if res.Error != "" {
+		return flag, tracing.Error(span, errors.New(res.Error))
+	}
+
+	if res.Flag == nil {
+		return flag, tracing.Errorf(span, "the %s backend did not return a flag", pb.name)
+	}
+
+	return flag, nil
+}
+
+func (pb *PluginBackend) Close(ctx context.Context) error {
+	ctx, span := tr.Start(ctx, "close")
+	defer span.End()
+
+	// the plugin is still asked to close when the command's context has been
+	// cancelled, such as when watch is interrupted
+	res, err := pb.call(context.Background(), Request{
+		Method:      MethodClose,
+		TraceParent: tracing.TraceParent(ctx),
+	})
+
+	pb.stdin.Close()
+
+	select {
+	case <-pb.exited:
+	case <-time.After(closeTimeout):
+		pb.cmd.Process.Kill()
+		<-pb.exited
+	}
+
+	pb.stdout.Close()
+
+	if err != nil {
+		return tracing.Error(span, err)
+	}
+
+	if res.Error != "" {
+		return tracing.Error(span, errors.New(res.Error))
+	}
+
+	return nil
+}
+
+// call sends a request and waits for its response, killing the plugin if it
+// doesn't answer before the timeout, or the context is cancelled
+func (pb *PluginBackend) call(ctx context.Context, req Request) (Response, error) {
+	pb.lock.Lock()
+	defer pb.lock.Unlock()
+
+	if pb.stopped != nil {
+		return Response{}, pb.stopped
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, pb.timeout)
+	defer cancel()
+
+	type result struct {
+		res Response
+		err error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		res, err := pb.send(req)
+		done <- result{res: res, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.res, r.err
+
+	case <-ctx.Done():
+		// a late response would be read as the answer to the next request,
+		// so the plugin can't be used again
+		pb.stopped = fmt.Errorf("the %s backend did not respond: %w", pb.name, ctx.Err())
+		pb.cmd.Process.Kill()
+		pb.stdout.Close()
+
+		return Response{}, pb.stopped
+	}
+}
+
+func (pb *PluginBackend) send(req Request) (Response, error) {
+	res := Response{}
+
+	if err := pb.encoder.Encode(req); err != nil {
+		return res, fmt.Errorf("unable to send a request to the %s backend: %w", pb.name, err)
+	}
+
+	if err := pb.decoder.Decode(&res); err != nil {
+		if errors.Is(err, io.EOF) {
+			return res, fmt.Errorf("the %s backend exited unexpectedly", pb.name)
+		}
+		return res, fmt.Errorf("unable to read the response from the %s backend: %w", pb.name, err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,189 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"flagon/backends"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// when this is set, the test binary acts as a plugin rather than running the
+// tests, so that the real process handling can be tested
+const testPluginEnvVar = "FLAGON_TEST_PLUGIN"
+
+func TestMain(m *testing.M) {
+	switch os.Getenv(testPluginEnvVar) {
+	case "serve":
+		backend := &fakeBackend{flags: map[string]any{"enabled": true, "strategy": "blue-green"}}
+		if err := Serve(context.Background(), backend); err != nil {
+			os.Exit(2)
+		}
+		os.Exit(0)
+
+	case "crash":
+		os.Exit(3)
+
+	case "hang":
+		time.Sleep(time.Minute)
+		os.Exit(4)
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestPluginBackend(t *testing.T) {
+	t.Setenv(testPluginEnvVar, "serve")
+
+	ctx := context.Background()
+	pb, err := CreateBackend(ctx, "test", os.Args[0], DefaultConfig())
+	assert.NoError(t, err)
+
+	user := backends.User{Key: "someone", Attributes: map[string]any{"branch": "main"}}
+
+	t.Run("bool flag", func(t *testing.T) {
+		flag, err := pb.State(ctx, backends.Flag{Key: "enabled", DefaultValue: false}, user)
+		assert.NoError(t, err)
+		assert.Equal(t, backends.Flag{Key: "enabled", DefaultValue: false, Value: true}, flag)
+	})
+
+	t.Run("string flag", func(t *testing.T) {
+		flag, err := pb.State(ctx, backends.Flag{Key: "strategy", Type: backends.TypeString, DefaultValue: "rolling"}, user)
+		assert.NoError(t, err)
+		assert.Equal(t, "blue-green", flag.Value)
+	})
+
+	t.Run("private attributes and anonymous users", func(t *testing.T) {
+		private := backends.User{Key: "someone", Attributes: map[string]any{"email": "someone@example.com"}, PrivateAttributes: []string{"email"}, Anonymous: true}
+
+		flag, err := pb.State(ctx, backends.Flag{Key: "private-attributes", Type: backends.TypeString, DefaultValue: ""}, private)
+		assert.NoError(t, err)
+		assert.Equal(t, "email", flag.Value)
+
+		flag, err = pb.State(ctx, backends.Flag{Key: "anonymous", DefaultValue: false}, private)
+		assert.NoError(t, err)
+		assert.Equal(t, true, flag.Value)
+	})
+
+	t.Run("missing flag", func(t *testing.T) {
+		flag, err := pb.State(ctx, backends.Flag{Key: "missing", DefaultValue: true}, user)
+		assert.NoError(t, err)
+		assert.Equal(t, true, flag.Value)
+	})
+
+	t.Run("backend error", func(t *testing.T) {
+		flag, err := pb.State(ctx, backends.Flag{Key: "broken", DefaultValue: true}, user)
+		assert.EqualError(t, err, "broken flag")
+		assert.Equal(t, true, flag.Value)
+	})
+
+	assert.NoError(t, pb.Close(ctx))
+	assert.Equal(t, 0, pb.cmd.ProcessState.ExitCode())
+}
+
+func TestPluginExitsEarly(t *testing.T) {
+	t.Setenv(testPluginEnvVar, "crash")
+
+	ctx := context.Background()
+	pb, err := CreateBackend(ctx, "test", os.Args[0], DefaultConfig())
+	assert.NoError(t, err)
+
+	flag, err := pb.State(ctx, backends.Flag{Key: "enabled", DefaultValue: true}, backends.User{})
+	assert.Error(t, err)
+	assert.Equal(t, true, flag.Value)
+
+	assert.Error(t, pb.Close(ctx))
+}
+
+func TestPluginDoesNotRespond(t *testing.T) {
+	t.Setenv(testPluginEnvVar, "hang")
+
+	t.Run("timeout", func(t *testing.T) {
+		ctx := context.Background()
+		pb, err := CreateBackend(ctx, "test", os.Args[0], PluginConfiguration{Timeout: 50 * time.Millisecond})
+		assert.NoError(t, err)
+
+		flag, err := pb.State(ctx, backends.Flag{Key: "enabled", DefaultValue: true}, backends.User{})
+		assert.ErrorContains(t, err, "the test backend did not respond")
+		assert.Equal(t, true, flag.Value)
+
+		<-pb.exited
+
+		_, err = pb.State(ctx, backends.Flag{Key: "enabled", DefaultValue: true}, backends.User{})
+		assert.ErrorContains(t, err, "the test backend did not respond")
+
+		assert.Error(t, pb.Close(ctx))
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		pb, err := CreateBackend(context.Background(), "test", os.Args[0], DefaultConfig())
+		assert.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err = pb.State(ctx, backends.Flag{Key: "enabled", DefaultValue: true}, backends.User{})
+		assert.ErrorIs(t, err, context.Canceled)
+
+		<-pb.exited
+		assert.Error(t, pb.Close(context.Background()))
+	})
+}
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	plugin := path.Join(dir, ExecutablePrefix+"test")
+	assert.NoError(t, os.WriteFile(plugin, []byte("#!/bin/sh\n"), 0755))
+
+	t.Setenv("PATH", dir)
+
+	found, ok := Find("test")
+	assert.True(t, ok)
+	assert.Equal(t, plugin, found)
+
+	_, ok = Find("other")
+	assert.False(t, ok)
+}
+
+func TestUnsupportedMethod(t *testing.T) {
+	res := handle(context.Background(), &fakeBackend{}, Request{Method: "explode"})
+	assert.Equal(t, "unsupported method: explode", res.Error)
+}
+
+type fakeBackend struct {
+	flags map[string]any
+}
+
+func (f *fakeBackend) State(ctx context.Context, flag backends.Flag, user backends.User) (backends.Flag, error) {
+	flag.Value = flag.DefaultValue
+
+	if flag.Key == "broken" {
+		return flag, errors.New("broken flag")
+	}
+
+	// echo the user's privacy settings, to check they reach the plugin
+	if flag.Key == "private-attributes" {
+		flag.Value = strings.Join(user.PrivateAttributes, ",")
+		return flag, nil
+	}
+
+	if flag.Key == "anonymous" {
+		flag.Value = user.Anonymous
+		return flag, nil
+	}
+
+	if v, found := f.flags[flag.Key]; found {
+		flag.Value = v
+	}
+
+	return flag, nil
+}
+
+func (f *fakeBackend) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,31 @@
+package plugin
+
+import "flagon/backends"
+
+// ExecutablePrefix is prepended to the --backend name to find a plugin on
+// the $PATH, so `--backend foo` runs `flagon-backend-foo`
+const ExecutablePrefix = "flagon-backend-"
+
+const (
+	MethodState = "state"
+	MethodClose = "close"
+)
+
+// Request is written to the plugin's stdin as a single line of json.  The
+// plugin must write exactly one Response line to its stdout for each request.
+type Request struct {
+	Method string         `json:"method"`
+	Flag   *backends.Flag `json:"flag,omitempty"`
+	User   *backends.User `json:"user,omitempty"`
+
+	// TraceParent is the w3c traceparent of the span making the request, so
+	// that the plugin can add its own spans to the trace
+	TraceParent string `json:"traceparent,omitempty"`
+}
+
+// Response contains the evaluated flag for a state request, which should
+// still be returned with its default value when evaluation fails
+type Response struct {
+	Flag  *backends.Flag `json:"flag,omitempty"`
+	Error string         `json:"error,omitempty"`
+}
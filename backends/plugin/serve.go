@@ -0,0 +1,76 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flagon/backends"
+	"flagon/tracing"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Serve implements the plugin protocol on stdin and stdout for a backend
+// written in go, and returns once flagon closes the backend.
+func Serve(ctx context.Context, backend backends.Backend) error {
+	return serve(ctx, backend, os.Stdin, os.Stdout)
+}
+
+func serve(ctx context.Context, backend backends.Backend, r io.Reader, w io.Writer) error {
+	decoder := json.NewDecoder(bufio.NewReader(r))
+	encoder := json.NewEncoder(w)
+
+	for {
+		req := Request{}
+		if err := decoder.Decode(&req); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		res := handle(tracing.WithTraceParent(ctx, req.TraceParent), backend, req)
+
+		if err := encoder.Encode(res); err != nil {
+			return err
+		}
+
+		if req.Method == MethodClose {
+			return nil
+		}
+	}
+}
+
+func handle(ctx context.Context, backend backends.Backend, req Request) Response {
+	ctx, span := tr.Start(ctx, "serve_"+req.Method)
+	defer span.End()
+
+	res := Response{}
+
+	switch req.Method {
+	case MethodState:
+		if req.Flag == nil || req.User == nil {
+			res.Error = tracing.Errorf(span, "a state request needs a flag and a user").Error()
+			return res
+		}
+
+		flag, err := backend.State(ctx, *req.Flag, *req.User)
+		res.Flag = &flag
+
+		if err != nil {
+			res.Error = tracing.Error(span, err).Error()
+		}
+
+	case MethodClose:
+		if err := backend.Close(ctx); err != nil {
+			res.Error = tracing.Error(span, err).Error()
+		}
+
+	default:
+		res.Error = tracing.Error(span, fmt.Errorf("unsupported method: %s", req.Method)).Error()
+	}
+
+	return res
+}
@@ -0,0 +1,56 @@
+package backends
+
+type ReasonKind string
+
+const (
+	ReasonOff                ReasonKind = "OFF"
+	ReasonFallthrough        ReasonKind = "FALLTHROUGH"
+	ReasonTargetMatch        ReasonKind = "TARGET_MATCH"
+	ReasonRuleMatch          ReasonKind = "RULE_MATCH"
+	ReasonPrerequisiteFailed ReasonKind = "PREREQUISITE_FAILED"
+	ReasonError              ReasonKind = "ERROR"
+
+	// ReasonOverride is used when the value was forced with `--override`
+	// rather than evaluated by the backend
+	ReasonOverride ReasonKind = "OVERRIDE"
+)
+
+const (
+	ErrorFlagNotFound = "FLAG_NOT_FOUND"
+	ErrorWrongType    = "WRONG_TYPE"
+)
+
+// Reason describes why a flag evaluated to the value it did.  Which fields are
+// populated depends on the Kind and on what the backend supports.
+type Reason struct {
+	Kind ReasonKind `json:"kind"`
+
+	RuleIndex       *int   `json:"ruleIndex,omitempty"`
+	RuleID          string `json:"ruleId,omitempty"`
+	PrerequisiteKey string `json:"prerequisiteKey,omitempty"`
+	InExperiment    bool   `json:"inExperiment,omitempty"`
+	ErrorKind       string `json:"errorKind,omitempty"`
+}
+
+func (r *Reason) String() string {
+	if r == nil {
+		return ""
+	}
+
+	switch r.Kind {
+	case ReasonError:
+		return string(r.Kind) + "(" + r.ErrorKind + ")"
+
+	case ReasonPrerequisiteFailed:
+		return string(r.Kind) + "(" + r.PrerequisiteKey + ")"
+
+	case ReasonRuleMatch:
+		if r.RuleID != "" {
+			return string(r.Kind) + "(" + r.RuleID + ")"
+		}
+		return string(r.Kind)
+
+	default:
+		return string(r.Kind)
+	}
+}
@@ -0,0 +1,68 @@
+package backends
+
+import (
+	"context"
+	"flagon/tracing"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Refresher holds a value downloaded from a flag service, such as a backend's
+// rules, and fetches it again once it is older than the interval.
+type Refresher[T any] struct {
+	// Fetch returns the latest value, given the previous one, so that it can
+	// keep the previous value when the service reports no changes
+	Fetch func(ctx context.Context, previous T) (T, error)
+	// Interval is how long a value is used before fetching it again
+	Interval time.Duration
+
+	lock    sync.Mutex
+	value   T
+	fetched time.Time
+}
+
+// Refresh fetches the value now.  A failed fetch still counts as an attempt,
+// so the next refresh waits for the interval.
+func (r *Refresher[T]) Refresh(ctx context.Context) error {
+	r.lock.Lock()
+	previous := r.value
+	r.lock.Unlock()
+
+	value, err := r.Fetch(ctx, previous)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.fetched = time.Now()
+
+	if err != nil {
+		return err
+	}
+
+	r.value = value
+
+	return nil
+}
+
+// Current returns the value, fetching it again first if it is older than the
+// interval.  A failed refresh is recorded on the context's span, and keeps
+// using the previous value until the next interval, so that each use doesn't
+// wait for the service to fail again.
+func (r *Refresher[T]) Current(ctx context.Context) T {
+	r.lock.Lock()
+	stale := time.Since(r.fetched) >= r.Interval
+	r.lock.Unlock()
+
+	if stale {
+		if err := r.Refresh(ctx); err != nil {
+			tracing.Error(trace.SpanFromContext(ctx), err)
+		}
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.value
+}
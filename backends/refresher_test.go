@@ -0,0 +1,46 @@
+package backends
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefresher(t *testing.T) {
+
+	fetches := 0
+	fail := false
+
+	r := &Refresher[int]{
+		Fetch: func(ctx context.Context, previous int) (int, error) {
+			fetches++
+			if fail {
+				return previous, errors.New("unavailable")
+			}
+			return previous + 1, nil
+		},
+		Interval: time.Hour,
+	}
+	ctx := context.Background()
+
+	assert.NoError(t, r.Refresh(ctx))
+	assert.Equal(t, 1, r.Current(ctx))
+	assert.Equal(t, 1, fetches, "a fresh value isn't fetched again")
+
+	r.fetched = time.Time{}
+	assert.Equal(t, 2, r.Current(ctx), "a stale value is fetched again")
+	assert.Equal(t, 2, fetches)
+
+	fail = true
+	r.fetched = time.Time{}
+	assert.Equal(t, 2, r.Current(ctx), "a failed refresh keeps the previous value")
+	assert.Equal(t, 3, fetches)
+
+	assert.Equal(t, 2, r.Current(ctx))
+	assert.Equal(t, 3, fetches, "a failed refresh isn't retried until the next interval")
+
+	assert.EqualError(t, r.Refresh(ctx), "unavailable")
+}
@@ -0,0 +1,159 @@
+package backends
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/spf13/pflag"
+)
+
+// BackendConfig holds the command line flags for one backend, and creates
+// the backend from its defaults, the environment, and those flags.
+type BackendConfig interface {
+	Flags() *pflag.FlagSet
+	CreateBackend(ctx context.Context) (Backend, error)
+
+	// Selected is true when the configuration selects its backend regardless
+	// of `--backend`, such as the remote backend when given a server address
+	Selected() bool
+}
+
+// Selector is implemented by the pointer to a backend's configuration when
+// the backend can be selected by its configuration, rather than by name.
+type Selector interface {
+	Selected() bool
+}
+
+// Factory creates a backend by the name it would be given to `--backend`
+type Factory func(ctx context.Context, name string) (Backend, error)
+
+type factoryKey struct{}
+
+type Registration struct {
+	// Name is the value used to select the backend with `--backend`
+	Name string
+	// Title is used to group the backend's flags in help output
+	Title string
+
+	NewConfig func() BackendConfig
+}
+
+// Configuration is implemented by the pointer to a backend's configuration
+// struct, so that the registry can create and override it generically.
+type Configuration[T any] interface {
+	*T
+	OverrideFrom(other T)
+	Flags() *pflag.FlagSet
+}
+
+var (
+	registryLock sync.RWMutex
+	registry     = map[string]Registration{}
+)
+
+// Register adds a backend which can be selected with `--backend`.  It is
+// expected to be called from the `init` function of each backend's package.
+// The configuration passed to create is the defaults, overridden by the
+// environment, overridden by command line flags.
+func Register[T any, PT Configuration[T]](
+	name string,
+	title string,
+	defaults func() T,
+	fromEnvironment func() T,
+	create func(ctx context.Context, cfg T) (Backend, error),
+) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	if _, found := registry[name]; found {
+		panic(fmt.Sprintf("a backend called %s is already registered", name))
+	}
+
+	registry[name] = Registration{
+		Name:  name,
+		Title: title,
+		NewConfig: func() BackendConfig {
+			return &registeredConfig[T, PT]{
+				defaults:        defaults,
+				fromEnvironment: fromEnvironment,
+				create:          create,
+			}
+		},
+	}
+}
+
+// Registered returns all the registered backends, ordered by name
+func Registered() []Registration {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	all := make([]Registration, 0, len(registry))
+	for _, r := range registry {
+		all = append(all, r)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Name < all[j].Name
+	})
+
+	return all
+}
+
+type registeredConfig[T any, PT Configuration[T]] struct {
+	flags T
+
+	defaults        func() T
+	fromEnvironment func() T
+	create          func(ctx context.Context, cfg T) (Backend, error)
+}
+
+func (rc *registeredConfig[T, PT]) Flags() *pflag.FlagSet {
+	return PT(&rc.flags).Flags()
+}
+
+func (rc *registeredConfig[T, PT]) CreateBackend(ctx context.Context) (Backend, error) {
+	return rc.create(ctx, rc.config())
+}
+
+func (rc *registeredConfig[T, PT]) Selected() bool {
+	cfg := rc.config()
+	selector, ok := any(PT(&cfg)).(Selector)
+
+	return ok && selector.Selected()
+}
+
+func (rc *registeredConfig[T, PT]) config() T {
+	cfg := rc.defaults()
+	PT(&cfg).OverrideFrom(rc.fromEnvironment())
+	PT(&cfg).OverrideFrom(rc.flags)
+
+	return cfg
+}
+
+// WithFactory stores the factory used by CreateNamed, so that backends made
+// of other backends, such as the chain, create them with the same
+// configuration as the command.
+func WithFactory(ctx context.Context, create Factory) context.Context {
+	return context.WithValue(ctx, factoryKey{}, create)
+}
+
+// CreateNamed creates a backend by the name it would be given to `--backend`,
+// using the factory stored in the context.  Without one, the registered
+// backend is created from its defaults and the environment.
+func CreateNamed(ctx context.Context, name string) (Backend, error) {
+	if create, ok := ctx.Value(factoryKey{}).(Factory); ok {
+		return create(ctx, name)
+	}
+
+	registryLock.RLock()
+	r, found := registry[name]
+	registryLock.RUnlock()
+
+	if !found {
+		return nil, fmt.Errorf("unsupported backend: %s", name)
+	}
+
+	return r.NewConfig().CreateBackend(ctx)
+}
@@ -0,0 +1,166 @@
+package backends
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+)
+
+type testConfiguration struct {
+	Path    string
+	Verbose bool
+}
+
+func (c *testConfiguration) OverrideFrom(other testConfiguration) {
+	if other.Path != "" {
+		c.Path = other.Path
+	}
+
+	if other.Verbose {
+		c.Verbose = other.Verbose
+	}
+}
+
+func (c *testConfiguration) Flags() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+
+	flags.StringVar(&c.Path, "test-path", "", "")
+	flags.BoolVar(&c.Verbose, "test-verbose", false, "")
+
+	return flags
+}
+
+type configBackend struct {
+	cfg testConfiguration
+}
+
+func (b *configBackend) State(ctx context.Context, flag Flag, user User) (Flag, error) {
+	return flag, nil
+}
+
+func (b *configBackend) Close(ctx context.Context) error {
+	return nil
+}
+
+func registerTestBackend(name string, env testConfiguration) {
+	Register(
+		name,
+		"Test Backend",
+		func() testConfiguration { return testConfiguration{Path: "default.txt"} },
+		func() testConfiguration { return env },
+		func(ctx context.Context, cfg testConfiguration) (Backend, error) {
+			return &configBackend{cfg: cfg}, nil
+		},
+	)
+}
+
+func findRegistration(name string) (Registration, bool) {
+	for _, r := range Registered() {
+		if r.Name == name {
+			return r, true
+		}
+	}
+
+	return Registration{}, false
+}
+
+func TestRegisteredIsSortedByName(t *testing.T) {
+	registerTestBackend("test-sort-b", testConfiguration{})
+	registerTestBackend("test-sort-a", testConfiguration{})
+
+	names := []string{}
+	for _, r := range Registered() {
+		names = append(names, r.Name)
+	}
+
+	assert.IsIncreasing(t, names)
+	assert.Contains(t, names, "test-sort-a")
+	assert.Contains(t, names, "test-sort-b")
+}
+
+func TestRegisteringTwicePanics(t *testing.T) {
+	registerTestBackend("test-duplicate", testConfiguration{})
+
+	assert.Panics(t, func() {
+		registerTestBackend("test-duplicate", testConfiguration{})
+	})
+}
+
+func TestConfigurationPrecedence(t *testing.T) {
+	registerTestBackend("test-precedence", testConfiguration{Path: "env.txt"})
+
+	r, found := findRegistration("test-precedence")
+	assert.True(t, found)
+	assert.Equal(t, "Test Backend", r.Title)
+
+	t.Run("environment overrides defaults", func(t *testing.T) {
+		cfg := r.NewConfig()
+
+		backend, err := cfg.CreateBackend(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, testConfiguration{Path: "env.txt"}, backend.(*configBackend).cfg)
+	})
+
+	t.Run("flags override environment", func(t *testing.T) {
+		cfg := r.NewConfig()
+		assert.NoError(t, cfg.Flags().Parse([]string{"--test-path", "flag.txt", "--test-verbose"}))
+
+		backend, err := cfg.CreateBackend(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, testConfiguration{Path: "flag.txt", Verbose: true}, backend.(*configBackend).cfg)
+	})
+
+	t.Run("each config has its own flags", func(t *testing.T) {
+		first := r.NewConfig()
+		second := r.NewConfig()
+		assert.NoError(t, first.Flags().Parse([]string{"--test-path", "first.txt"}))
+
+		backend, err := second.CreateBackend(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, "env.txt", backend.(*configBackend).cfg.Path)
+	})
+}
+
+func (c *testConfiguration) Selected() bool {
+	return c.Verbose
+}
+
+func TestSelected(t *testing.T) {
+	registerTestBackend("test-selected", testConfiguration{})
+
+	r, found := findRegistration("test-selected")
+	assert.True(t, found)
+
+	cfg := r.NewConfig()
+	assert.False(t, cfg.Selected())
+
+	assert.NoError(t, cfg.Flags().Parse([]string{"--test-verbose"}))
+	assert.True(t, cfg.Selected())
+}
+
+func TestCreateNamed(t *testing.T) {
+	registerTestBackend("test-named", testConfiguration{Path: "env.txt"})
+
+	t.Run("from the registry", func(t *testing.T) {
+		backend, err := CreateNamed(context.Background(), "test-named")
+		assert.NoError(t, err)
+		assert.Equal(t, "env.txt", backend.(*configBackend).cfg.Path)
+	})
+
+	t.Run("from the factory", func(t *testing.T) {
+		ctx := WithFactory(context.Background(), func(ctx context.Context, name string) (Backend, error) {
+			return &configBackend{cfg: testConfiguration{Path: name + ".txt"}}, nil
+		})
+
+		backend, err := CreateNamed(ctx, "test-named")
+		assert.NoError(t, err)
+		assert.Equal(t, "test-named.txt", backend.(*configBackend).cfg.Path)
+	})
+
+	t.Run("unknown backend", func(t *testing.T) {
+		_, err := CreateNamed(context.Background(), "test-missing")
+		assert.ErrorContains(t, err, "unsupported backend: test-missing")
+	})
+}
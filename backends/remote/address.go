@@ -0,0 +1,59 @@
+package remote
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// ParseAddress splits an address into the network and address parts used by
+// the net package.  Addresses can be unix:///path/to.sock, tcp://host:port,
+// or a plain host:port.
+func ParseAddress(addr string) (network string, address string, err error) {
+
+	switch {
+	case strings.HasPrefix(addr, "unix://"):
+		network, address = "unix", strings.TrimPrefix(addr, "unix://")
+
+	case strings.HasPrefix(addr, "tcp://"):
+		network, address = "tcp", strings.TrimPrefix(addr, "tcp://")
+
+	case strings.Contains(addr, "://"):
+		return "", "", fmt.Errorf("unsupported address scheme: %s (must be unix:// or tcp://)", addr)
+
+	default:
+		network, address = "tcp", addr
+	}
+
+	if address == "" {
+		return "", "", fmt.Errorf("no address specified in %s", addr)
+	}
+
+	return network, address, nil
+}
+
+// Listen opens a listener on the address.  Unix socket files left behind by
+// a previous server which is no longer running are removed first.
+func Listen(addr string) (net.Listener, error) {
+
+	network, address, err := ParseAddress(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if network == "unix" {
+		if _, err := os.Stat(address); err == nil {
+			if conn, err := net.Dial(network, address); err == nil {
+				conn.Close()
+				return nil, fmt.Errorf("a server is already listening on %s", addr)
+			}
+
+			if err := os.Remove(address); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return net.Listen(network, address)
+}
@@ -0,0 +1,47 @@
+package remote
+
+import (
+	"os"
+
+	"github.com/spf13/pflag"
+)
+
+const AddressEnvVar = "FLAGON_SERVER"
+
+type RemoteConfiguration struct {
+	Address string
+}
+
+func (cfg *RemoteConfiguration) OverrideFrom(other RemoteConfiguration) {
+	if other.Address != "" {
+		cfg.Address = other.Address
+	}
+}
+
+// Selected queries flags through the server whenever an address is given,
+// whichever backend is selected with `--backend`
+func (cfg *RemoteConfiguration) Selected() bool {
+	return cfg.Address != ""
+}
+
+func (cfg *RemoteConfiguration) Flags() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("Server", pflag.ContinueOnError)
+
+	flags.StringVar(&cfg.Address, "server", "", "query flags through a `flagon serve` process, rather than the backend directly.  For example unix:///tmp/flagon.sock or tcp://localhost:7468")
+
+	return flags
+}
+
+func ConfigFromEnvironment() RemoteConfiguration {
+
+	cfg := RemoteConfiguration{}
+	cfg.Address = os.Getenv(AddressEnvVar)
+
+	return cfg
+}
+
+func DefaultConfig() RemoteConfiguration {
+	return RemoteConfiguration{
+		Address: "",
+	}
+}
@@ -0,0 +1,71 @@
+package remote
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadEnvironment(t *testing.T) {
+
+	os.Setenv(AddressEnvVar, "unix:///tmp/test.sock")
+	defer os.Unsetenv(AddressEnvVar)
+
+	cfg := ConfigFromEnvironment()
+
+	assert.Equal(t, "unix:///tmp/test.sock", cfg.Address)
+}
+
+func TestFlags(t *testing.T) {
+
+	cfg := RemoteConfiguration{}
+	flags := cfg.Flags()
+
+	assert.NoError(t, flags.Parse([]string{
+		"--server", "tcp://localhost:7468",
+	}))
+
+	assert.Equal(t, "tcp://localhost:7468", cfg.Address)
+}
+
+func TestOverridingValues(t *testing.T) {
+
+	base := DefaultConfig()
+	base.OverrideFrom(RemoteConfiguration{})
+	assert.Equal(t, "", base.Address)
+
+	base.OverrideFrom(RemoteConfiguration{Address: "localhost:1234"})
+	assert.Equal(t, "localhost:1234", base.Address)
+}
+
+func TestParseAddress(t *testing.T) {
+
+	cases := []struct {
+		input   string
+		network string
+		address string
+		err     string
+	}{
+		{input: "unix:///tmp/flagon.sock", network: "unix", address: "/tmp/flagon.sock"},
+		{input: "tcp://localhost:7468", network: "tcp", address: "localhost:7468"},
+		{input: "localhost:7468", network: "tcp", address: "localhost:7468"},
+		{input: "http://localhost:7468", err: "unsupported address scheme: http://localhost:7468 (must be unix:// or tcp://)"},
+		{input: "unix://", err: "no address specified in unix://"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.input, func(t *testing.T) {
+			network, address, err := ParseAddress(tc.input)
+
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.network, network)
+			assert.Equal(t, tc.address, address)
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package remote
+
+import (
+	"context"
+	"flagon/backends"
+)
+
+// Name is the value of `--backend` which selects the remote backend, although
+// it is normally selected by setting `--server`
+const Name = "remote"
+
+func init() {
+	backends.Register(Name, "Server", DefaultConfig, ConfigFromEnvironment,
+		func(ctx context.Context, cfg RemoteConfiguration) (backends.Backend, error) {
+			backend, err := CreateBackend(ctx, cfg)
+			if err != nil {
+				return nil, err
+			}
+			return backend, nil
+		},
+	)
+}
@@ -0,0 +1,144 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flagon/backends"
+	"flagon/tracing"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+var tr = otel.Tracer("backend.remote")
+
+// RemoteBackend queries flags through a `flagon serve` process, so that many
+// invocations of flagon can share one connection to the real backend
+type RemoteBackend struct {
+	client  *http.Client
+	baseUrl string
+}
+
+func CreateBackend(ctx context.Context, cfg RemoteConfiguration) (*RemoteBackend, error) {
+	ctx, span := tr.Start(ctx, "create_backend")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("server.address", cfg.Address))
+
+	network, address, err := ParseAddress(cfg.Address)
+	if err != nil {
+		return nil, tracing.Error(span, err)
+	}
+
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			dialer := net.Dialer{}
+			return dialer.DialContext(ctx, network, address)
+		},
+	}
+
+	rb := &RemoteBackend{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   30 * time.Second,
+		},
+		// the host is ignored by the dialer, but is needed to make a valid url
+		baseUrl: "http://flagon",
+	}
+
+	if network == "tcp" {
+		rb.baseUrl = "http://" + address
+	}
+
+	if err := rb.call(ctx, http.MethodGet, HealthPath, nil, nil); err != nil {
+		return nil, tracing.Errorf(span, "unable to connect to flagon server at %s: %w", cfg.Address, err)
+	}
+
+	return rb, nil
+}
+
+func (rb *RemoteBackend) Close(ctx context.Context) error {
+	rb.client.CloseIdleConnections()
+	return nil
+}
+
+func (rb *RemoteBackend) State(ctx context.Context, flag backends.Flag, user backends.User) (backends.Flag, error) {
+	ctx, span := tr.Start(ctx, "state")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("flag.key", flag.Key))
+
+	res := StateResponse{}
+	if err := rb.call(ctx, http.MethodPost, StatePath, StateRequest{Flag: flag, User: user}, &res); err != nil {
+		flag.Value = flag.DefaultValue
+		return flag, tracing.Error(span, err)
+	}
+
+	if res.Error != "" {
+		return res.Flag, tracing.Error(span, errors.New(res.Error))
+	}
+
+	return res.Flag, nil
+}
+
+func (rb *RemoteBackend) AllFlags(ctx context.Context, user backends.User) ([]backends.Flag, error) {
+	ctx, span := tr.Start(ctx, "all_flags")
+	defer span.End()
+
+	res := AllResponse{}
+	if err := rb.call(ctx, http.MethodPost, AllPath, AllRequest{User: user}, &res); err != nil {
+		return nil, tracing.Error(span, err)
+	}
+
+	if res.Error != "" {
+		return nil, tracing.Error(span, errors.New(res.Error))
+	}
+
+	return res.Flags, nil
+}
+
+func (rb *RemoteBackend) call(ctx context.Context, method string, path string, body any, response any) error {
+
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, rb.baseUrl+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	res, err := rb.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	isJson := res.Header.Get("Content-Type") == "application/json"
+
+	if res.StatusCode != http.StatusOK && !isJson {
+		msg, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("server returned %s: %s", res.Status, bytes.TrimSpace(msg))
+	}
+
+	if response == nil {
+		return nil
+	}
+
+	return json.NewDecoder(res.Body).Decode(response)
+}
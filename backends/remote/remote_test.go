@@ -0,0 +1,140 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"flagon/backends"
+	"net/http"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoteBackend(t *testing.T) {
+
+	backend := &fakeBackend{
+		flags: map[string]any{
+			"enabled":  true,
+			"strategy": "blue-green",
+		},
+	}
+
+	socket := path.Join(t.TempDir(), "flagon.sock")
+	startServer(t, "unix://"+socket, backend)
+
+	ctx := context.Background()
+	rb, err := CreateBackend(ctx, RemoteConfiguration{Address: "unix://" + socket})
+	assert.NoError(t, err)
+	defer rb.Close(ctx)
+
+	user := backends.User{Key: "someone", Attributes: map[string]any{"branch": "main"}}
+
+	t.Run("bool flag", func(t *testing.T) {
+		flag, err := rb.State(ctx, backends.Flag{Key: "enabled", DefaultValue: false}, user)
+		assert.NoError(t, err)
+		assert.Equal(t, backends.Flag{Key: "enabled", DefaultValue: false, Value: true}, flag)
+		assert.Equal(t, user, backend.users[len(backend.users)-1])
+	})
+
+	t.Run("private attributes and anonymous users", func(t *testing.T) {
+		private := backends.User{Key: "someone", Attributes: map[string]any{"email": "someone@example.com"}, PrivateAttributes: []string{"email"}, Anonymous: true}
+
+		_, err := rb.State(ctx, backends.Flag{Key: "enabled", DefaultValue: false}, private)
+		assert.NoError(t, err)
+		assert.Equal(t, private, backend.users[len(backend.users)-1], "the server's backend handles them")
+	})
+
+	t.Run("string flag", func(t *testing.T) {
+		flag, err := rb.State(ctx, backends.Flag{Key: "strategy", Type: backends.TypeString, DefaultValue: "rolling"}, user)
+		assert.NoError(t, err)
+		assert.Equal(t, "blue-green", flag.Value)
+	})
+
+	t.Run("backend error", func(t *testing.T) {
+		flag, err := rb.State(ctx, backends.Flag{Key: "broken", DefaultValue: true}, user)
+		assert.EqualError(t, err, "broken flag")
+		assert.Equal(t, true, flag.Value)
+	})
+
+	t.Run("all flags", func(t *testing.T) {
+		flags, err := rb.AllFlags(ctx, user)
+		assert.NoError(t, err)
+		assert.Len(t, flags, 2)
+	})
+}
+
+func TestRemoteBackendTcp(t *testing.T) {
+
+	address := startServer(t, "tcp://127.0.0.1:0", &fakeBackend{flags: map[string]any{"enabled": true}})
+
+	rb, err := CreateBackend(context.Background(), RemoteConfiguration{Address: "tcp://" + address})
+	assert.NoError(t, err)
+
+	flag, err := rb.State(context.Background(), backends.Flag{Key: "enabled", DefaultValue: false}, backends.User{})
+	assert.NoError(t, err)
+	assert.Equal(t, true, flag.Value)
+}
+
+func TestRemoteBackendNoServer(t *testing.T) {
+
+	socket := path.Join(t.TempDir(), "flagon.sock")
+
+	_, err := CreateBackend(context.Background(), RemoteConfiguration{Address: "unix://" + socket})
+	assert.ErrorContains(t, err, "unable to connect to flagon server at unix://"+socket)
+}
+
+func TestListenExistingServer(t *testing.T) {
+
+	socket := path.Join(t.TempDir(), "flagon.sock")
+	startServer(t, "unix://"+socket, &fakeBackend{})
+
+	_, err := Listen("unix://" + socket)
+	assert.EqualError(t, err, "a server is already listening on unix://"+socket)
+}
+
+func startServer(t *testing.T, address string, backend backends.Backend) string {
+	listener, err := Listen(address)
+	assert.NoError(t, err)
+
+	server := &http.Server{Handler: NewHandler(backend)}
+	go server.Serve(listener)
+
+	t.Cleanup(func() {
+		server.Close()
+	})
+
+	return listener.Addr().String()
+}
+
+type fakeBackend struct {
+	flags map[string]any
+	users []backends.User
+}
+
+func (f *fakeBackend) State(ctx context.Context, flag backends.Flag, user backends.User) (backends.Flag, error) {
+	flag.Value = flag.DefaultValue
+	f.users = append(f.users, user)
+
+	if flag.Key == "broken" {
+		return flag, errors.New("broken flag")
+	}
+
+	if v, found := f.flags[flag.Key]; found {
+		flag.Value = v
+	}
+
+	return flag, nil
+}
+
+func (f *fakeBackend) AllFlags(ctx context.Context, user backends.User) ([]backends.Flag, error) {
+	flags := []backends.Flag{}
+	for key, value := range f.flags {
+		flags = append(flags, backends.Flag{Key: key, Type: backends.TypeOf(value), Value: value})
+	}
+	return flags, nil
+}
+
+func (f *fakeBackend) Close(ctx context.Context) error {
+	return nil
+}
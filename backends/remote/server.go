@@ -0,0 +1,128 @@
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"flagon/backends"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+const (
+	HealthPath = "/v1/health"
+	StatePath  = "/v1/state"
+	AllPath    = "/v1/all"
+)
+
+type StateRequest struct {
+	Flag backends.Flag `json:"flag"`
+	User backends.User `json:"user"`
+}
+
+// StateResponse always contains the flag, as backends return the flag with
+// its default value even when evaluation fails
+type StateResponse struct {
+	Flag  backends.Flag `json:"flag"`
+	Error string        `json:"error,omitempty"`
+}
+
+type AllRequest struct {
+	User backends.User `json:"user"`
+}
+
+type AllResponse struct {
+	Flags []backends.Flag `json:"flags"`
+	Error string          `json:"error,omitempty"`
+}
+
+type server struct {
+	backend backends.Backend
+}
+
+// NewHandler serves the flagon evaluation api for a backend.  The backend is
+// shared between all requests, so must be safe for concurrent use.
+func NewHandler(backend backends.Backend) http.Handler {
+	s := &server{backend: backend}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(HealthPath, s.health)
+	mux.HandleFunc(StatePath, s.state)
+	mux.HandleFunc(AllPath, s.all)
+
+	return mux
+}
+
+func requestContext(r *http.Request) context.Context {
+	return otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+}
+
+func (s *server) health(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *server) state(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tr.Start(requestContext(r), "serve_state")
+	defer span.End()
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	req := StateRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	span.SetAttributes(attribute.String("flag.key", req.Flag.Key))
+
+	flag, err := s.backend.State(ctx, req.Flag, req.User)
+
+	res := StateResponse{Flag: flag}
+	if err != nil {
+		res.Error = err.Error()
+	}
+
+	writeJson(w, http.StatusOK, res)
+}
+
+func (s *server) all(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tr.Start(requestContext(r), "serve_all")
+	defer span.End()
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	all, ok := s.backend.(backends.AllFlagsBackend)
+	if !ok {
+		writeJson(w, http.StatusNotImplemented, AllResponse{Error: "the backend does not support evaluating all flags"})
+		return
+	}
+
+	req := AllRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	flags, err := all.AllFlags(ctx, req.User)
+
+	res := AllResponse{Flags: flags}
+	if err != nil {
+		res.Error = err.Error()
+	}
+
+	writeJson(w, http.StatusOK, res)
+}
+
+func writeJson(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,81 @@
+package unleash
+
+import (
+	"os"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+const UrlEnvVar = "FLAGON_UNLEASH_URL"
+const ApiTokenEnvVar = "FLAGON_UNLEASH_API_TOKEN"
+const AppNameEnvVar = "FLAGON_UNLEASH_APP_NAME"
+const EnvironmentEnvVar = "FLAGON_UNLEASH_ENVIRONMENT"
+const TimeoutEnvVar = "FLAGON_UNLEASH_TIMEOUT"
+
+type UnleashConfiguration struct {
+	Url         string
+	ApiToken    string
+	AppName     string
+	Environment string
+	Timeout     time.Duration
+}
+
+func (cfg *UnleashConfiguration) OverrideFrom(other UnleashConfiguration) {
+	if other.Url != "" {
+		cfg.Url = other.Url
+	}
+
+	if other.ApiToken != "" {
+		cfg.ApiToken = other.ApiToken
+	}
+
+	if other.AppName != "" {
+		cfg.AppName = other.AppName
+	}
+
+	if other.Environment != "" {
+		cfg.Environment = other.Environment
+	}
+
+	if other.Timeout > 0 {
+		cfg.Timeout = other.Timeout
+	}
+}
+
+func (cfg *UnleashConfiguration) Flags() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("Unleash Backend", pflag.ContinueOnError)
+
+	flags.StringVar(&cfg.Url, "unleash-url", "", "the url of the unleash api, for example https://unleash.example.com/api")
+	flags.StringVar(&cfg.ApiToken, "unleash-api-token", "", "the client api token to use")
+	flags.StringVar(&cfg.AppName, "unleash-app-name", "", "the appName to evaluate flags with")
+	flags.StringVar(&cfg.Environment, "unleash-environment", "", "the environment to evaluate flags with")
+	flags.DurationVar(&cfg.Timeout, "unleash-timeout", 0, "timeout before failing to communicate with unleash")
+
+	return flags
+}
+
+func ConfigFromEnvironment() UnleashConfiguration {
+
+	cfg := UnleashConfiguration{}
+	cfg.Url = os.Getenv(UrlEnvVar)
+	cfg.ApiToken = os.Getenv(ApiTokenEnvVar)
+	cfg.AppName = os.Getenv(AppNameEnvVar)
+	cfg.Environment = os.Getenv(EnvironmentEnvVar)
+
+	if val := os.Getenv(TimeoutEnvVar); val != "" {
+		if timeout, err := time.ParseDuration(val); err == nil {
+			cfg.Timeout = timeout
+		}
+	}
+
+	return cfg
+}
+
+func DefaultConfig() UnleashConfiguration {
+	return UnleashConfiguration{
+		AppName:     "flagon",
+		Environment: "default",
+		Timeout:     5 * time.Second,
+	}
+}
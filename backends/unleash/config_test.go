@@ -0,0 +1,58 @@
+package unleash
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadEnvironment(t *testing.T) {
+
+	os.Setenv(UrlEnvVar, "https://unleash.example.com/api")
+	os.Setenv(ApiTokenEnvVar, "default:development.abc")
+	os.Setenv(AppNameEnvVar, "pipeline")
+	os.Setenv(EnvironmentEnvVar, "production")
+	os.Setenv(TimeoutEnvVar, "3s")
+
+	cfg := ConfigFromEnvironment()
+
+	assert.Equal(t, "https://unleash.example.com/api", cfg.Url)
+	assert.Equal(t, "default:development.abc", cfg.ApiToken)
+	assert.Equal(t, "pipeline", cfg.AppName)
+	assert.Equal(t, "production", cfg.Environment)
+	assert.Equal(t, 3*time.Second, cfg.Timeout)
+}
+
+func TestFlags(t *testing.T) {
+
+	cfg := UnleashConfiguration{}
+	flags := cfg.Flags()
+
+	assert.NoError(t, flags.Parse([]string{
+		"--unleash-url", "http://localhost:4242/api",
+		"--unleash-api-token", "token",
+		"--unleash-app-name", "deploys",
+		"--unleash-environment", "staging",
+		"--unleash-timeout", "1s",
+	}))
+
+	assert.Equal(t, "http://localhost:4242/api", cfg.Url)
+	assert.Equal(t, "token", cfg.ApiToken)
+	assert.Equal(t, "deploys", cfg.AppName)
+	assert.Equal(t, "staging", cfg.Environment)
+	assert.Equal(t, time.Second, cfg.Timeout)
+}
+
+func TestOverridingValues(t *testing.T) {
+
+	base := DefaultConfig()
+	base.OverrideFrom(UnleashConfiguration{})
+	assert.Equal(t, DefaultConfig(), base)
+
+	base.OverrideFrom(UnleashConfiguration{Url: "http://other/api", Timeout: time.Minute})
+	assert.Equal(t, "http://other/api", base.Url)
+	assert.Equal(t, "flagon", base.AppName)
+	assert.Equal(t, time.Minute, base.Timeout)
+}
@@ -0,0 +1,111 @@
+package unleash
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// featuresResponse is the body of the unleash client api's
+// `/client/features` endpoint
+type featuresResponse struct {
+	Version  int       `json:"version"`
+	Features []feature `json:"features"`
+	Segments []segment `json:"segments"`
+}
+
+type feature struct {
+	Name       string     `json:"name"`
+	Enabled    bool       `json:"enabled"`
+	Strategies []strategy `json:"strategies"`
+	Variants   []variant  `json:"variants"`
+}
+
+type strategy struct {
+	ID          string         `json:"id"`
+	Name        string         `json:"name"`
+	Parameters  map[string]any `json:"parameters"`
+	Constraints []constraint   `json:"constraints"`
+	Segments    []int          `json:"segments"`
+	Variants    []variant      `json:"variants"`
+}
+
+type constraint struct {
+	ContextName     string   `json:"contextName"`
+	Operator        string   `json:"operator"`
+	Values          []string `json:"values"`
+	Value           string   `json:"value"`
+	Inverted        bool     `json:"inverted"`
+	CaseInsensitive bool     `json:"caseInsensitive"`
+}
+
+type segment struct {
+	ID          int          `json:"id"`
+	Constraints []constraint `json:"constraints"`
+}
+
+type variant struct {
+	Name       string     `json:"name"`
+	Weight     int        `json:"weight"`
+	Stickiness string     `json:"stickiness"`
+	Payload    *payload   `json:"payload"`
+	Overrides  []override `json:"overrides"`
+}
+
+type payload struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type override struct {
+	ContextName string   `json:"contextName"`
+	Values      []string `json:"values"`
+}
+
+// unleashContext is the unleash equivalent of a backends.User
+type unleashContext struct {
+	UserID        string
+	SessionID     string
+	RemoteAddress string
+	Environment   string
+	AppName       string
+	CurrentTime   time.Time
+	Properties    map[string]string
+}
+
+// field finds a value by the names used in constraints and stickiness, which
+// are either one of unleash's standard fields, or a custom property
+func (c *unleashContext) field(name string) (string, bool) {
+	switch name {
+	case "userId":
+		return c.UserID, c.UserID != ""
+	case "sessionId":
+		return c.SessionID, c.SessionID != ""
+	case "remoteAddress":
+		return c.RemoteAddress, c.RemoteAddress != ""
+	case "environment":
+		return c.Environment, c.Environment != ""
+	case "appName":
+		return c.AppName, c.AppName != ""
+	case "currentTime":
+		return c.CurrentTime.Format(time.RFC3339), true
+	default:
+		val, found := c.Properties[name]
+		return val, found
+	}
+}
+
+// parameter reads a strategy parameter, which the api usually sends as a
+// string, but can also be a number
+func (s *strategy) parameter(name string) string {
+	switch v := s.Parameters[name].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,67 @@
+package unleash
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// seeds used by the unleash sdks, so that flagon puts users in the same
+// buckets as every other unleash client
+const (
+	rolloutSeed = 0
+	variantSeed = 86028157
+)
+
+// normalizedHash maps an identifier to a number between 1 and normalizer
+func normalizedHash(identifier string, groupID string, normalizer uint32, seed uint32) uint32 {
+	return murmur3([]byte(groupID+":"+identifier), seed)%normalizer + 1
+}
+
+// murmur3 is the 32 bit x86 variant of murmurhash3
+func murmur3(data []byte, seed uint32) uint32 {
+	const (
+		c1 = 0xcc9e2d51
+		c2 = 0x1b873593
+	)
+
+	h := seed
+	blocks := len(data) / 4
+
+	for i := 0; i < blocks; i++ {
+		k := binary.LittleEndian.Uint32(data[i*4:])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+
+		h ^= k
+		h = bits.RotateLeft32(h, 13)
+		h = h*5 + 0xe6546b64
+	}
+
+	tail := data[blocks*4:]
+	var k uint32
+
+	switch len(tail) {
+	case 3:
+		k ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		k ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(tail[0])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+	}
+
+	h ^= uint32(len(data))
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+
+	return h
+}
@@ -0,0 +1,18 @@
+package unleash
+
+import (
+	"context"
+	"flagon/backends"
+)
+
+func init() {
+	backends.Register("unleash", "Unleash Backend", DefaultConfig, ConfigFromEnvironment,
+		func(ctx context.Context, cfg UnleashConfiguration) (backends.Backend, error) {
+			backend, err := CreateBackend(ctx, cfg)
+			if err != nil {
+				return nil, err
+			}
+			return backend, nil
+		},
+	)
+}
@@ -0,0 +1,294 @@
+package unleash
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/launchdarkly/go-semver"
+)
+
+const (
+	StrategyDefault                 = "default"
+	StrategyUserWithId              = "userWithId"
+	StrategyFlexibleRollout         = "flexibleRollout"
+	StrategyGradualRolloutUserId    = "gradualRolloutUserId"
+	StrategyGradualRolloutSessionId = "gradualRolloutSessionId"
+	StrategyGradualRolloutRandom    = "gradualRolloutRandom"
+)
+
+// isEnabled checks the strategy and its constraints.  Unknown strategies are
+// never enabled, which is how the unleash sdks behave.
+func (s *strategy) isEnabled(f *feature, segments map[int]segment, ctx *unleashContext) bool {
+	for _, c := range s.Constraints {
+		if !c.matches(ctx) {
+			return false
+		}
+	}
+
+	for _, id := range s.Segments {
+		seg, found := segments[id]
+		if !found {
+			return false
+		}
+
+		for _, c := range seg.Constraints {
+			if !c.matches(ctx) {
+				return false
+			}
+		}
+	}
+
+	switch s.Name {
+	case StrategyDefault:
+		return true
+
+	case StrategyUserWithId:
+		for _, id := range strings.Split(s.parameter("userIds"), ",") {
+			if strings.TrimSpace(id) == ctx.UserID && ctx.UserID != "" {
+				return true
+			}
+		}
+		return false
+
+	case StrategyFlexibleRollout:
+		id, found := stickinessID(s.parameter("stickiness"), ctx)
+		if !found {
+			return false
+		}
+		return inRollout(id, s.groupID(f), s.parameter("rollout"))
+
+	case StrategyGradualRolloutUserId:
+		if ctx.UserID == "" {
+			return false
+		}
+		return inRollout(ctx.UserID, s.groupID(f), s.parameter("percentage"))
+
+	case StrategyGradualRolloutSessionId:
+		if ctx.SessionID == "" {
+			return false
+		}
+		return inRollout(ctx.SessionID, s.groupID(f), s.parameter("percentage"))
+
+	case StrategyGradualRolloutRandom:
+		return inRollout(randomID(), s.groupID(f), s.parameter("percentage"))
+
+	default:
+		return false
+	}
+}
+
+func (s *strategy) groupID(f *feature) string {
+	if id := s.parameter("groupId"); id != "" {
+		return id
+	}
+
+	return f.Name
+}
+
+func inRollout(id string, groupID string, rawPercentage string) bool {
+	percentage, err := strconv.ParseFloat(rawPercentage, 64)
+	if err != nil || percentage <= 0 {
+		return false
+	}
+
+	return float64(normalizedHash(id, groupID, 100, rolloutSeed)) <= percentage
+}
+
+// stickinessID finds the value to hash for a rollout.  The default stickiness
+// uses the userId, then the sessionId, and falls back to being random.
+func stickinessID(stickiness string, ctx *unleashContext) (string, bool) {
+	switch stickiness {
+	case "", "default":
+		if ctx.UserID != "" {
+			return ctx.UserID, true
+		}
+		if ctx.SessionID != "" {
+			return ctx.SessionID, true
+		}
+		return randomID(), true
+
+	case "random":
+		return randomID(), true
+
+	default:
+		return ctx.field(stickiness)
+	}
+}
+
+func randomID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// selectVariant picks a variant by its weight, unless the context matches
+// one of the variant's overrides.  It returns -1 if there are no variants.
+func selectVariant(variants []variant, groupID string, ctx *unleashContext) int {
+	total := 0
+	for _, v := range variants {
+		total += v.Weight
+	}
+
+	if total <= 0 {
+		return -1
+	}
+
+	for i, v := range variants {
+		for _, o := range v.Overrides {
+			if val, found := ctx.field(o.ContextName); found && contains(o.Values, val) {
+				return i
+			}
+		}
+	}
+
+	id, found := variantStickinessID(variants[0].Stickiness, ctx)
+	if !found {
+		id = randomID()
+	}
+
+	target := int(normalizedHash(id, groupID, uint32(total), variantSeed))
+
+	counter := 0
+	for i, v := range variants {
+		if v.Weight <= 0 {
+			continue
+		}
+
+		counter += v.Weight
+		if counter >= target {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func variantStickinessID(stickiness string, ctx *unleashContext) (string, bool) {
+	if stickiness == "" || stickiness == "default" {
+		for _, name := range []string{"userId", "sessionId", "remoteAddress"} {
+			if val, found := ctx.field(name); found {
+				return val, true
+			}
+		}
+		return randomID(), true
+	}
+
+	return stickinessID(stickiness, ctx)
+}
+
+func (c *constraint) matches(ctx *unleashContext) bool {
+	return c.evaluate(ctx) != c.Inverted
+}
+
+func (c *constraint) evaluate(ctx *unleashContext) bool {
+	val, found := ctx.field(c.ContextName)
+
+	switch c.Operator {
+	case "IN":
+		return found && contains(c.Values, val)
+
+	case "NOT_IN":
+		return !found || !contains(c.Values, val)
+
+	case "STR_CONTAINS", "STR_STARTS_WITH", "STR_ENDS_WITH":
+		if !found {
+			return false
+		}
+
+		compare := map[string]func(s, substr string) bool{
+			"STR_CONTAINS":    strings.Contains,
+			"STR_STARTS_WITH": strings.HasPrefix,
+			"STR_ENDS_WITH":   strings.HasSuffix,
+		}[c.Operator]
+
+		if c.CaseInsensitive {
+			val = strings.ToLower(val)
+		}
+
+		for _, v := range c.Values {
+			if c.CaseInsensitive {
+				v = strings.ToLower(v)
+			}
+			if compare(val, v) {
+				return true
+			}
+		}
+		return false
+
+	case "NUM_EQ", "NUM_GT", "NUM_GTE", "NUM_LT", "NUM_LTE":
+		actual, err := strconv.ParseFloat(val, 64)
+		if !found || err != nil {
+			return false
+		}
+
+		expected, err := strconv.ParseFloat(c.Value, 64)
+		if err != nil {
+			return false
+		}
+
+		switch c.Operator {
+		case "NUM_EQ":
+			return actual == expected
+		case "NUM_GT":
+			return actual > expected
+		case "NUM_GTE":
+			return actual >= expected
+		case "NUM_LT":
+			return actual < expected
+		default:
+			return actual <= expected
+		}
+
+	case "DATE_AFTER", "DATE_BEFORE":
+		actual, err := time.Parse(time.RFC3339, val)
+		if !found || err != nil {
+			return false
+		}
+
+		expected, err := time.Parse(time.RFC3339, c.Value)
+		if err != nil {
+			return false
+		}
+
+		if c.Operator == "DATE_AFTER" {
+			return actual.After(expected)
+		}
+		return actual.Before(expected)
+
+	case "SEMVER_EQ", "SEMVER_GT", "SEMVER_LT":
+		actual, err := semver.Parse(val)
+		if !found || err != nil {
+			return false
+		}
+
+		expected, err := semver.Parse(c.Value)
+		if err != nil {
+			return false
+		}
+
+		switch c.Operator {
+		case "SEMVER_EQ":
+			return actual.ComparePrecedence(expected) == 0
+		case "SEMVER_GT":
+			return actual.ComparePrecedence(expected) > 0
+		default:
+			return actual.ComparePrecedence(expected) < 0
+		}
+
+	default:
+		return false
+	}
+}
+
+func contains(values []string, val string) bool {
+	for _, v := range values {
+		if v == val {
+			return true
+		}
+	}
+
+	return false
+}
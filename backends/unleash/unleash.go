@@ -0,0 +1,284 @@
+package unleash
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flagon/backends"
+	"flagon/tracing"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var tr = otel.Tracer("backend.unleash")
+
+const featuresPath = "/client/features"
+
+// how long fetched features are used before checking for changes, which
+// matches the unleash sdks' default polling interval
+const refreshInterval = 15 * time.Second
+
+// UnleashBackend fetches feature toggles from the unleash client api, and
+// evaluates their strategies locally
+type UnleashBackend struct {
+	cfg    UnleashConfiguration
+	client *http.Client
+
+	toggles *backends.Refresher[toggles]
+}
+
+// toggles are the features and segments fetched from unleash, and the etag
+// used to check whether they have changed
+type toggles struct {
+	features map[string]*feature
+	segments map[int]segment
+	etag     string
+}
+
+func CreateBackend(ctx context.Context, cfg UnleashConfiguration) (*UnleashBackend, error) {
+	ctx, span := tr.Start(ctx, "create_backend")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("unleash.url", cfg.Url),
+		attribute.String("unleash.app_name", cfg.AppName),
+		attribute.String("unleash.environment", cfg.Environment),
+	)
+
+	if cfg.Url == "" {
+		return nil, tracing.Errorf(span, "no unleash url specified, use --unleash-url or $%s", UrlEnvVar)
+	}
+
+	ub := &UnleashBackend{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}
+	ub.toggles = &backends.Refresher[toggles]{Fetch: ub.fetch, Interval: refreshInterval}
+
+	if err := ub.toggles.Refresh(ctx); err != nil {
+		return nil, tracing.Error(span, err)
+	}
+
+	span.SetAttributes(attribute.Int("unleash.features", len(ub.toggles.Current(ctx).features)))
+
+	return ub, nil
+}
+
+func (ub *UnleashBackend) Close(ctx context.Context) error {
+	ub.client.CloseIdleConnections()
+	return nil
+}
+
+func (ub *UnleashBackend) State(ctx context.Context, flag backends.Flag, user backends.User) (backends.Flag, error) {
+	ctx, span := tr.Start(ctx, "state")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("flag.key", flag.Key))
+
+	flag.Value = flag.DefaultValue
+
+	features, segments := ub.current(ctx)
+
+	f, found := features[flag.Key]
+	if !found {
+		flag.Reason = &backends.Reason{Kind: backends.ReasonError, ErrorKind: backends.ErrorFlagNotFound}
+		span.SetAttributes(attribute.String("reason", flag.Reason.String()))
+		return flag, nil
+	}
+
+	uc := ub.createContext(user)
+	enabled, index := f.evaluate(segments, uc)
+
+	switch {
+	case !f.Enabled:
+		flag.Reason = &backends.Reason{Kind: backends.ReasonOff}
+	case index >= 0:
+		i := index
+		flag.Reason = &backends.Reason{Kind: backends.ReasonRuleMatch, RuleIndex: &i, RuleID: f.Strategies[index].ID}
+	default:
+		flag.Reason = &backends.Reason{Kind: backends.ReasonFallthrough}
+	}
+
+	span.SetAttributes(
+		attribute.String("reason", flag.Reason.String()),
+		attribute.Bool("enabled", enabled),
+	)
+
+	if flag.ValueType() == backends.TypeBool {
+		flag.Value = enabled
+		return flag, nil
+	}
+
+	if !enabled {
+		return flag, nil
+	}
+
+	// variants on the matching strategy take priority over the feature's
+	variants, groupID := f.Variants, f.Name
+	if index >= 0 && len(f.Strategies[index].Variants) > 0 {
+		variants, groupID = f.Strategies[index].Variants, f.Strategies[index].groupID(f)
+	}
+
+	v := selectVariant(variants, groupID, uc)
+	if v < 0 {
+		return flag, nil
+	}
+
+	span.SetAttributes(attribute.String("variant", variants[v].Name))
+
+	value, err := variantValue(flag.ValueType(), variants[v])
+	if err != nil {
+		flag.Reason = &backends.Reason{Kind: backends.ReasonError, ErrorKind: backends.ErrorWrongType}
+		return flag, tracing.Errorf(span, "variant %s of %s cannot be used as a %s: %w", variants[v].Name, flag.Key, flag.ValueType(), err)
+	}
+
+	flag.Value = value
+	flag.VariationIndex = &v
+
+	return flag, nil
+}
+
+func (ub *UnleashBackend) AllFlags(ctx context.Context, user backends.User) ([]backends.Flag, error) {
+	ctx, span := tr.Start(ctx, "all_flags")
+	defer span.End()
+
+	features, _ := ub.current(ctx)
+
+	flags := make([]backends.Flag, 0, len(features))
+
+	for key := range features {
+		flag, err := ub.State(ctx, backends.Flag{Key: key, Type: backends.TypeBool, DefaultValue: false}, user)
+		if err != nil {
+			return nil, tracing.Error(span, err)
+		}
+
+		flags = append(flags, flag)
+	}
+
+	span.SetAttributes(attribute.Int("flags.count", len(flags)))
+
+	return flags, nil
+}
+
+// evaluate returns whether the feature is enabled, and the index of the
+// strategy which enabled it, or -1 if there was no strategy
+func (f *feature) evaluate(segments map[int]segment, ctx *unleashContext) (bool, int) {
+	if !f.Enabled {
+		return false, -1
+	}
+
+	if len(f.Strategies) == 0 {
+		return true, -1
+	}
+
+	for i, s := range f.Strategies {
+		if s.isEnabled(f, segments, ctx) {
+			return true, i
+		}
+	}
+
+	return false, -1
+}
+
+// variantValue uses the variant's payload as the flag's value, or the
+// variant's name if there is no payload
+func variantValue(t backends.FlagType, v variant) (any, error) {
+	if v.Payload == nil {
+		if t == backends.TypeString {
+			return v.Name, nil
+		}
+		return nil, errors.New("the variant has no payload")
+	}
+
+	return backends.ParseValue(t, v.Payload.Value)
+}
+
+func (ub *UnleashBackend) createContext(user backends.User) *unleashContext {
+	return &unleashContext{
+		UserID:        user.Key,
+		SessionID:     backends.AttributeString(user.Attributes["sessionId"]),
+		RemoteAddress: backends.AttributeString(user.Attributes["remoteAddress"]),
+		Environment:   ub.cfg.Environment,
+		AppName:       ub.cfg.AppName,
+		CurrentTime:   time.Now(),
+		Properties:    backends.StringAttributes(user.Attributes),
+	}
+}
+
+// current returns the features and segments, fetching them again if they are
+// older than the refresh interval
+func (ub *UnleashBackend) current(ctx context.Context) (map[string]*feature, map[int]segment) {
+	ctx, span := tr.Start(ctx, "current")
+	defer span.End()
+
+	t := ub.toggles.Current(ctx)
+
+	return t.features, t.segments
+}
+
+// fetch downloads the features, keeping the previous ones when unleash
+// reports they haven't changed
+func (ub *UnleashBackend) fetch(ctx context.Context, previous toggles) (toggles, error) {
+	ctx, span := tr.Start(ctx, "fetch")
+	defer span.End()
+
+	url := strings.TrimSuffix(ub.cfg.Url, "/") + featuresPath
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return previous, tracing.Error(span, err)
+	}
+
+	hostname, _ := os.Hostname()
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", ub.cfg.ApiToken)
+	req.Header.Set("UNLEASH-APPNAME", ub.cfg.AppName)
+	req.Header.Set("UNLEASH-INSTANCEID", "flagon-"+hostname)
+
+	if previous.etag != "" {
+		req.Header.Set("If-None-Match", previous.etag)
+	}
+
+	res, err := ub.client.Do(req)
+	if err != nil {
+		return previous, tracing.Errorf(span, "unable to fetch features from unleash: %w", err)
+	}
+	defer res.Body.Close()
+
+	span.SetAttributes(attribute.Int("http.status_code", res.StatusCode))
+
+	if res.StatusCode == http.StatusNotModified {
+		return previous, nil
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return previous, tracing.Errorf(span, "unable to fetch features from unleash: %s", res.Status)
+	}
+
+	body := featuresResponse{}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return previous, tracing.Errorf(span, "unable to read features from unleash: %w", err)
+	}
+
+	features := make(map[string]*feature, len(body.Features))
+	for i := range body.Features {
+		features[body.Features[i].Name] = &body.Features[i]
+	}
+
+	segments := make(map[int]segment, len(body.Segments))
+	for _, s := range body.Segments {
+		segments[s.ID] = s
+	}
+
+	return toggles{
+		features: features,
+		segments: segments,
+		etag:     res.Header.Get("ETag"),
+	}, nil
+}
@@ -0,0 +1,323 @@
+package unleash
+
+import (
+	"context"
+	"flagon/backends"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const features = `{
+  "version": 2,
+  "features": [
+    { "name": "enabled", "enabled": true, "strategies": [ { "id": "s1", "name": "default" } ] },
+    { "name": "disabled", "enabled": false, "strategies": [ { "name": "default" } ] },
+    { "name": "no-strategies", "enabled": true, "strategies": [] },
+    { "name": "unknown-strategy", "enabled": true, "strategies": [ { "name": "somethingCustom" } ] },
+    { "name": "users", "enabled": true, "strategies": [ { "name": "userWithId", "parameters": { "userIds": "alice, bob" } } ] },
+    { "name": "everyone-on-main", "enabled": true, "strategies": [
+      { "name": "flexibleRollout", "parameters": { "rollout": "100", "stickiness": "default", "groupId": "everyone-on-main" },
+        "constraints": [ { "contextName": "branch", "operator": "IN", "values": [ "main" ] } ] }
+    ] },
+    { "name": "nobody", "enabled": true, "strategies": [ { "name": "flexibleRollout", "parameters": { "rollout": 0 } } ] },
+    { "name": "gradual", "enabled": true, "strategies": [ { "name": "gradualRolloutUserId", "parameters": { "percentage": "100" } } ] },
+    { "name": "segmented", "enabled": true, "strategies": [ { "name": "default", "segments": [ 1 ] } ] },
+    { "name": "deploy-strategy", "enabled": true, "strategies": [ { "name": "default" } ], "variants": [
+      { "name": "blue-green", "weight": 1000, "payload": { "type": "string", "value": "blue-green" } }
+    ] },
+    { "name": "named-variant", "enabled": true, "strategies": [ { "name": "default" } ], "variants": [
+      { "name": "canary", "weight": 1000 }
+    ] },
+    { "name": "deploy-config", "enabled": true, "strategies": [ { "name": "default" } ], "variants": [
+      { "name": "fast", "weight": 500, "payload": { "type": "json", "value": "{\"retries\": 1}" } },
+      { "name": "careful", "weight": 500, "payload": { "type": "json", "value": "{\"retries\": 3}" },
+        "overrides": [ { "contextName": "userId", "values": [ "alice" ] } ] }
+    ] },
+    { "name": "strategy-variants", "enabled": true, "strategies": [
+      { "name": "default", "variants": [ { "name": "from-strategy", "weight": 1000, "payload": { "type": "number", "value": "42" } } ] }
+    ], "variants": [ { "name": "from-feature", "weight": 1000, "payload": { "type": "number", "value": "1" } } ] }
+  ],
+  "segments": [
+    { "id": 1, "constraints": [ { "contextName": "environment", "operator": "IN", "values": [ "production" ] } ] }
+  ]
+}`
+
+func startServer(t *testing.T, handler http.HandlerFunc) string {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return server.URL + "/api"
+}
+
+func serveFeatures(t *testing.T) (string, *http.Request) {
+	last := &http.Request{}
+
+	url := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		*last = *r
+		if r.URL.Path != "/api/client/features" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(features))
+	})
+
+	return url, last
+}
+
+func createBackend(t *testing.T, url string, environment string) *UnleashBackend {
+	cfg := DefaultConfig()
+	cfg.Url = url
+	cfg.ApiToken = "default:production.secret"
+	cfg.Environment = environment
+
+	ub, err := CreateBackend(context.Background(), cfg)
+	assert.NoError(t, err)
+
+	return ub
+}
+
+func TestState(t *testing.T) {
+
+	url, _ := serveFeatures(t)
+	ub := createBackend(t, url, "default")
+
+	cases := []struct {
+		name           string
+		key            string
+		user           backends.User
+		expected       bool
+		expectedReason backends.ReasonKind
+	}{
+		{name: "default strategy", key: "enabled", expected: true, expectedReason: backends.ReasonRuleMatch},
+		{name: "disabled feature", key: "disabled", expected: false, expectedReason: backends.ReasonOff},
+		{name: "no strategies", key: "no-strategies", expected: true, expectedReason: backends.ReasonFallthrough},
+		{name: "unknown strategy", key: "unknown-strategy", expected: false, expectedReason: backends.ReasonFallthrough},
+		{name: "user in list", key: "users", user: backends.User{Key: "bob"}, expected: true, expectedReason: backends.ReasonRuleMatch},
+		{name: "user not in list", key: "users", user: backends.User{Key: "carol"}, expected: false, expectedReason: backends.ReasonFallthrough},
+		{name: "constraint matches", key: "everyone-on-main", user: backends.User{Key: "carol", Attributes: map[string]any{"branch": "main"}}, expected: true, expectedReason: backends.ReasonRuleMatch},
+		{name: "constraint fails", key: "everyone-on-main", user: backends.User{Key: "carol", Attributes: map[string]any{"branch": "dev"}}, expected: false, expectedReason: backends.ReasonFallthrough},
+		{name: "zero rollout", key: "nobody", user: backends.User{Key: "carol"}, expected: false, expectedReason: backends.ReasonFallthrough},
+		{name: "gradual rollout", key: "gradual", user: backends.User{Key: "carol"}, expected: true, expectedReason: backends.ReasonRuleMatch},
+		{name: "gradual rollout without user", key: "gradual", expected: false, expectedReason: backends.ReasonFallthrough},
+		{name: "segment fails", key: "segmented", expected: false, expectedReason: backends.ReasonFallthrough},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			flag, err := ub.State(context.Background(), backends.Flag{Key: tc.key, DefaultValue: !tc.expected}, tc.user)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, flag.Value)
+			assert.Equal(t, tc.expectedReason, flag.Reason.Kind)
+		})
+	}
+
+	t.Run("missing flag", func(t *testing.T) {
+		flag, err := ub.State(context.Background(), backends.Flag{Key: "missing", DefaultValue: true}, backends.User{Key: "alice"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, true, flag.Value)
+		assert.Equal(t, backends.ErrorFlagNotFound, flag.Reason.ErrorKind)
+	})
+}
+
+func TestSegments(t *testing.T) {
+
+	url, _ := serveFeatures(t)
+	ub := createBackend(t, url, "production")
+
+	flag, err := ub.State(context.Background(), backends.Flag{Key: "segmented", DefaultValue: false}, backends.User{Key: "alice"})
+	assert.NoError(t, err)
+	assert.Equal(t, true, flag.Value)
+}
+
+func TestVariants(t *testing.T) {
+
+	url, _ := serveFeatures(t)
+	ub := createBackend(t, url, "default")
+	ctx := context.Background()
+
+	t.Run("string payload", func(t *testing.T) {
+		flag, err := ub.State(ctx, backends.Flag{Key: "deploy-strategy", Type: backends.TypeString, DefaultValue: "rolling"}, backends.User{Key: "alice"})
+		assert.NoError(t, err)
+		assert.Equal(t, "blue-green", flag.Value)
+		assert.Equal(t, 0, *flag.VariationIndex)
+	})
+
+	t.Run("variant name without a payload", func(t *testing.T) {
+		flag, err := ub.State(ctx, backends.Flag{Key: "named-variant", Type: backends.TypeString, DefaultValue: "none"}, backends.User{Key: "alice"})
+		assert.NoError(t, err)
+		assert.Equal(t, "canary", flag.Value)
+	})
+
+	t.Run("override", func(t *testing.T) {
+		flag, err := ub.State(ctx, backends.Flag{Key: "deploy-config", Type: backends.TypeJSON}, backends.User{Key: "alice"})
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"retries": 3.0}, flag.Value)
+		assert.Equal(t, 1, *flag.VariationIndex)
+	})
+
+	t.Run("strategy variants take priority", func(t *testing.T) {
+		flag, err := ub.State(ctx, backends.Flag{Key: "strategy-variants", Type: backends.TypeNumber, DefaultValue: 0.0}, backends.User{Key: "alice"})
+		assert.NoError(t, err)
+		assert.Equal(t, 42.0, flag.Value)
+	})
+
+	t.Run("disabled feature uses the default", func(t *testing.T) {
+		flag, err := ub.State(ctx, backends.Flag{Key: "disabled", Type: backends.TypeString, DefaultValue: "fallback"}, backends.User{Key: "alice"})
+		assert.NoError(t, err)
+		assert.Equal(t, "fallback", flag.Value)
+		assert.Nil(t, flag.VariationIndex)
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		flag, err := ub.State(ctx, backends.Flag{Key: "deploy-strategy", Type: backends.TypeNumber, DefaultValue: 1.0}, backends.User{Key: "alice"})
+		assert.Error(t, err)
+		assert.Equal(t, 1.0, flag.Value)
+		assert.Equal(t, backends.ErrorWrongType, flag.Reason.ErrorKind)
+	})
+
+	t.Run("weighted variants are sticky", func(t *testing.T) {
+		first, err := ub.State(ctx, backends.Flag{Key: "deploy-config", Type: backends.TypeJSON}, backends.User{Key: "carol"})
+		assert.NoError(t, err)
+
+		for i := 0; i < 10; i++ {
+			again, err := ub.State(ctx, backends.Flag{Key: "deploy-config", Type: backends.TypeJSON}, backends.User{Key: "carol"})
+			assert.NoError(t, err)
+			assert.Equal(t, first.Value, again.Value)
+		}
+	})
+}
+
+func TestAllFlags(t *testing.T) {
+
+	url, _ := serveFeatures(t)
+	ub := createBackend(t, url, "default")
+
+	flags, err := ub.AllFlags(context.Background(), backends.User{Key: "alice"})
+	assert.NoError(t, err)
+	assert.Len(t, flags, 13)
+
+	values := map[string]any{}
+	for _, f := range flags {
+		values[f.Key] = f.Value
+	}
+
+	assert.Equal(t, true, values["enabled"])
+	assert.Equal(t, false, values["disabled"])
+	assert.Equal(t, true, values["users"])
+}
+
+func TestRequestHeaders(t *testing.T) {
+
+	url, last := serveFeatures(t)
+	createBackend(t, url, "default")
+
+	assert.Equal(t, "default:production.secret", last.Header.Get("Authorization"))
+	assert.Equal(t, "flagon", last.Header.Get("UNLEASH-APPNAME"))
+	assert.NotEmpty(t, last.Header.Get("UNLEASH-INSTANCEID"))
+}
+
+func TestRefreshing(t *testing.T) {
+
+	requests := 0
+	body := `{ "features": [ { "name": "toggle", "enabled": false } ] }`
+
+	url := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		if r.Header.Get("If-None-Match") == `"v1"` && requests == 2 {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(body))
+	})
+
+	ub := createBackend(t, url, "default")
+	ctx := context.Background()
+
+	flag, _ := ub.State(ctx, backends.Flag{Key: "toggle", DefaultValue: true}, backends.User{Key: "alice"})
+	assert.Equal(t, false, flag.Value)
+	assert.Equal(t, 1, requests)
+
+	ub.toggles.Interval = 0
+
+	flag, _ = ub.State(ctx, backends.Flag{Key: "toggle", DefaultValue: true}, backends.User{Key: "alice"})
+	assert.Equal(t, false, flag.Value, "a not modified response keeps the features")
+	assert.Equal(t, 2, requests)
+
+	body = `{ "features": [ { "name": "toggle", "enabled": true } ] }`
+
+	flag, _ = ub.State(ctx, backends.Flag{Key: "toggle", DefaultValue: false}, backends.User{Key: "alice"})
+	assert.Equal(t, true, flag.Value)
+	assert.Equal(t, 3, requests)
+}
+
+func TestCreateBackendErrors(t *testing.T) {
+
+	t.Run("no url", func(t *testing.T) {
+		_, err := CreateBackend(context.Background(), DefaultConfig())
+		assert.ErrorContains(t, err, "no unleash url specified")
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		url := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		})
+
+		cfg := DefaultConfig()
+		cfg.Url = url
+
+		_, err := CreateBackend(context.Background(), cfg)
+		assert.EqualError(t, err, "unable to fetch features from unleash: 401 Unauthorized")
+	})
+}
+
+func TestConstraints(t *testing.T) {
+
+	ctx := &unleashContext{
+		UserID:      "alice",
+		CurrentTime: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Properties:  map[string]string{"branch": "Feature/Login", "build": "42", "version": "1.4.0"},
+	}
+
+	cases := []struct {
+		constraint constraint
+		expected   bool
+	}{
+		{constraint{ContextName: "userId", Operator: "IN", Values: []string{"alice"}}, true},
+		{constraint{ContextName: "userId", Operator: "IN", Values: []string{"alice"}, Inverted: true}, false},
+		{constraint{ContextName: "sessionId", Operator: "NOT_IN", Values: []string{"abc"}}, true},
+		{constraint{ContextName: "branch", Operator: "STR_STARTS_WITH", Values: []string{"feature/"}}, false},
+		{constraint{ContextName: "branch", Operator: "STR_STARTS_WITH", Values: []string{"feature/"}, CaseInsensitive: true}, true},
+		{constraint{ContextName: "branch", Operator: "STR_CONTAINS", Values: []string{"Log"}}, true},
+		{constraint{ContextName: "branch", Operator: "STR_ENDS_WITH", Values: []string{"Logout"}}, false},
+		{constraint{ContextName: "build", Operator: "NUM_GT", Value: "40"}, true},
+		{constraint{ContextName: "build", Operator: "NUM_LTE", Value: "41"}, false},
+		{constraint{ContextName: "missing", Operator: "NUM_EQ", Value: "0"}, false},
+		{constraint{ContextName: "version", Operator: "SEMVER_GT", Value: "1.3.9"}, true},
+		{constraint{ContextName: "version", Operator: "SEMVER_EQ", Value: "1.4.0"}, true},
+		{constraint{ContextName: "version", Operator: "SEMVER_LT", Value: "1.4.0-beta"}, false},
+		{constraint{ContextName: "currentTime", Operator: "DATE_AFTER", Value: "2023-12-31T00:00:00Z"}, true},
+		{constraint{ContextName: "currentTime", Operator: "DATE_BEFORE", Value: "2023-12-31T00:00:00Z"}, false},
+		{constraint{ContextName: "userId", Operator: "SOMETHING_NEW", Values: []string{"alice"}}, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.constraint.Operator, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.constraint.matches(ctx))
+		})
+	}
+}
+
+func TestNormalizedHash(t *testing.T) {
+	// values from the unleash sdks' own tests
+	assert.Equal(t, uint32(73), normalizedHash("123", "gr1", 100, rolloutSeed))
+	assert.Equal(t, uint32(25), normalizedHash("999", "groupX", 100, rolloutSeed))
+}
@@ -0,0 +1,68 @@
+package backends
+
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
+// WatchBackend is implemented by backends which are notified when a flag
+// changes, rather than needing to be polled.  The channel receives the flag's
+// current state, and then its new state each time its value changes for the
+// user, until the context is cancelled.
+type WatchBackend interface {
+	Watch(ctx context.Context, flag Flag, user User) (<-chan Flag, error)
+}
+
+// Watch sends the flag's current state, and then its new state each time its
+// value changes for the user, until the context is cancelled.  Backends which
+// do not implement WatchBackend are polled at the interval.
+func Watch(ctx context.Context, backend Backend, flag Flag, user User, interval time.Duration) (<-chan Flag, error) {
+
+	if wb, ok := backend.(WatchBackend); ok {
+		return wb.Watch(ctx, flag, user)
+	}
+
+	return poll(ctx, backend, flag, user, interval)
+}
+
+func poll(ctx context.Context, backend Backend, query Flag, user User, interval time.Duration) (<-chan Flag, error) {
+
+	current, err := backend.State(ctx, query, user)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(chan Flag, 1)
+	changes <- current
+
+	go func() {
+		defer close(changes)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case <-ticker.C:
+				next, err := backend.State(ctx, query, user)
+				if err != nil || reflect.DeepEqual(next.Value, current.Value) {
+					continue
+				}
+
+				current = next
+
+				select {
+				case changes <- current:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return changes, nil
+}
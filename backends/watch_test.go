@@ -0,0 +1,52 @@
+package backends_test
+
+import (
+	"context"
+	"flagon/backends"
+	"flagon/backends/backendtest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchPolling(t *testing.T) {
+
+	backend := &backendtest.SequenceBackend{Values: []any{false, false, true, true, false}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes, err := backends.Watch(ctx, backend, backends.Flag{Key: "approved", DefaultValue: false}, backends.User{}, time.Millisecond)
+	assert.NoError(t, err)
+
+	assert.Equal(t, false, (<-changes).Value)
+	assert.Equal(t, true, (<-changes).Value)
+	assert.Equal(t, false, (<-changes).Value)
+
+	cancel()
+	for range changes {
+	}
+}
+
+func TestWatchNotifications(t *testing.T) {
+
+	notifications := make(chan backends.Flag, 1)
+	notifications <- backends.Flag{Key: "approved", Value: true}
+
+	backend := &notifyingBackend{changes: notifications}
+
+	changes, err := backends.Watch(context.Background(), backend, backends.Flag{Key: "approved"}, backends.User{}, time.Hour)
+	assert.NoError(t, err)
+
+	assert.Equal(t, true, (<-changes).Value)
+}
+
+type notifyingBackend struct {
+	backendtest.SequenceBackend
+	changes chan backends.Flag
+}
+
+func (b *notifyingBackend) Watch(ctx context.Context, flag backends.Flag, user backends.User) (<-chan backends.Flag, error) {
+	return b.changes, nil
+}
@@ -0,0 +1,80 @@
+package command
+
+import (
+	"context"
+	"flagon/backends"
+	"flagon/tracing"
+	"fmt"
+
+	"github.com/mitchellh/cli"
+	"github.com/spf13/pflag"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func NewAllCommand(ui cli.Ui) (*AllCommand, error) {
+	cmd := &AllCommand{
+		userFlags: newUserFlags(),
+	}
+	cmd.Meta = NewMeta(ui, cmd)
+
+	return cmd, nil
+}
+
+type AllCommand struct {
+	Meta
+	userFlags
+}
+
+func (c *AllCommand) Name() string {
+	return "all"
+}
+
+func (c *AllCommand) Synopsis() string {
+	return "Evaluates every feature flag for a user"
+}
+
+func (c *AllCommand) Flags() *pflag.FlagSet {
+	flags := pflag.NewFlagSet(c.Name(), pflag.ContinueOnError)
+
+	c.userFlags.addFlags(flags)
+
+	return flags
+}
+
+func (c *AllCommand) RunContext(ctx context.Context, args []string) error {
+	ctx, span := c.tr.Start(ctx, "run")
+	defer span.End()
+
+	if len(args) != 0 {
+		return fmt.Errorf("this command takes no arguments")
+	}
+
+	backend, err := c.createBackend(ctx)
+	if err != nil {
+		return tracing.Error(span, err)
+	}
+	defer backend.Close(ctx)
+
+	all, ok := backend.(backends.AllFlagsBackend)
+	if !ok {
+		return tracing.Errorf(span, "the %s backend does not support evaluating all flags", c.backend)
+	}
+
+	user, err := c.createUser(ctx)
+	if err != nil {
+		return tracing.Error(span, err)
+	}
+
+	flags, err := all.AllFlags(ctx, user)
+	if err != nil {
+		return tracing.Error(span, err)
+	}
+
+	span.SetAttributes(attribute.Int("flag.count", len(flags)))
+
+	if err := c.print(keyedFlags(flags)); err != nil {
+		return tracing.Error(span, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"flagon/backends"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAll(t *testing.T) {
+
+	backend := &MockBackend{
+		flags: map[string]any{
+			"enabled":  true,
+			"strategy": "blue-green",
+			"config":   map[string]any{"retries": 3.0},
+		},
+	}
+
+	ui := cli.NewMockUi()
+	cmd, _ := NewAllCommand(ui)
+	cmd.Meta.testBackend = backend
+
+	assert.Equal(t, 0, cmd.Run([]string{"--user", "someone", "--attr", "branch=main"}))
+
+	flags := map[string]backends.Flag{}
+	assert.NoError(t, json.Unmarshal(ui.OutputWriter.Bytes(), &flags))
+
+	assert.Equal(t, map[string]backends.Flag{
+		"enabled":  {Key: "enabled", Type: backends.TypeBool, Value: true},
+		"strategy": {Key: "strategy", Type: backends.TypeString, Value: "blue-green"},
+		"config":   {Key: "config", Type: backends.TypeJSON, Value: map[string]any{"retries": 3.0}},
+	}, flags)
+
+	assert.Equal(t, "someone", backend.users[0].Key)
+	assert.Equal(t, "main", backend.users[0].Attributes["branch"])
+}
+
+func TestAllUnsupportedBackend(t *testing.T) {
+
+	ui := cli.NewMockUi()
+	cmd, _ := NewAllCommand(ui)
+	cmd.Meta.testBackend = &stateOnlyBackend{}
+
+	assert.Equal(t, 2, cmd.Run([]string{"--backend", "state-only"}))
+	assert.Equal(t, "the state-only backend does not support evaluating all flags\n", ui.ErrorWriter.String())
+}
+
+type stateOnlyBackend struct{}
+
+func (b *stateOnlyBackend) State(ctx context.Context, flag backends.Flag, user backends.User) (backends.Flag, error) {
+	return flag, nil
+}
+
+func (b *stateOnlyBackend) Close(ctx context.Context) error {
+	return nil
+}
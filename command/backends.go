@@ -0,0 +1,16 @@
+package command
+
+// backends register themselves when imported, which makes them available to
+// the --backend flag
+import (
+	_ "flagon/backends/chain"
+	_ "flagon/backends/env"
+	_ "flagon/backends/file"
+	_ "flagon/backends/flagsmith"
+	_ "flagon/backends/flipt"
+	_ "flagon/backends/growthbook"
+	_ "flagon/backends/launchdarkly"
+	_ "flagon/backends/ofrep"
+	_ "flagon/backends/remote"
+	_ "flagon/backends/unleash"
+)
@@ -17,5 +17,33 @@ func Commands(ui cli.Ui) map[string]cli.CommandFactory {
 		"state": func() (cli.Command, error) {
 			return NewStateCommand(ui)
 		},
+
+		"variation": func() (cli.Command, error) {
+			return NewVariationCommand(ui)
+		},
+
+		"all": func() (cli.Command, error) {
+			return NewAllCommand(ui)
+		},
+
+		"exec": func() (cli.Command, error) {
+			return NewExecCommand(ui)
+		},
+
+		"env": func() (cli.Command, error) {
+			return NewEnvCommand(ui)
+		},
+
+		"serve": func() (cli.Command, error) {
+			return NewServeCommand(ui)
+		},
+
+		"wait": func() (cli.Command, error) {
+			return NewWaitCommand(ui)
+		},
+
+		"watch": func() (cli.Command, error) {
+			return NewWatchCommand(ui)
+		},
 	}
 }
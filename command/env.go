@@ -0,0 +1,187 @@
+package command
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"flagon/tracing"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	"github.com/spf13/pflag"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const GithubOutputEnvVar = "GITHUB_OUTPUT"
+const GithubEnvEnvVar = "GITHUB_ENV"
+
+func NewEnvCommand(ui cli.Ui) (*EnvCommand, error) {
+	cmd := &EnvCommand{
+		userFlags: newUserFlags(),
+		openFile: func(f string) (io.WriteCloser, error) {
+			return os.OpenFile(f, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		},
+	}
+	cmd.Meta = NewMeta(ui, cmd)
+
+	return cmd, nil
+}
+
+type EnvCommand struct {
+	Meta
+	userFlags
+	flagVariables
+
+	format string
+	file   string
+
+	openFile func(filePath string) (io.WriteCloser, error)
+}
+
+type envFormat struct {
+	write func(w io.Writer, v variable) error
+
+	// the environment variable containing the default file to write to
+	fileEnvVar string
+}
+
+var envFormats = map[string]envFormat{
+	"shell":         {write: writeShell},
+	"dotenv":        {write: writeDotenv},
+	"gitlab":        {write: writeGitlab},
+	"github-output": {write: writeGithub, fileEnvVar: GithubOutputEnvVar},
+	"github-env":    {write: writeGithub, fileEnvVar: GithubEnvEnvVar},
+}
+
+func (c *EnvCommand) Name() string {
+	return "env"
+}
+
+func (c *EnvCommand) Synopsis() string {
+	return "Writes feature flags as environment variables for shells and CI systems"
+}
+
+func (c *EnvCommand) Flags() *pflag.FlagSet {
+	flags := pflag.NewFlagSet(c.Name(), pflag.ContinueOnError)
+
+	c.userFlags.addFlags(flags)
+	c.flagVariables.addFlags(flags)
+	flags.StringVar(&c.format, "format", "shell", "the format to write: shell, dotenv, gitlab, github-output, or github-env")
+	flags.StringVar(&c.file, "file", "", "append to this file rather than writing to stdout.  Defaults to $GITHUB_OUTPUT or $GITHUB_ENV for the github formats")
+
+	return flags
+}
+
+func (c *EnvCommand) RunContext(ctx context.Context, args []string) error {
+	ctx, span := c.tr.Start(ctx, "run")
+	defer span.End()
+
+	if len(args) != 0 {
+		return fmt.Errorf("this command takes no arguments, use --flag to specify flags")
+	}
+
+	format, found := envFormats[c.format]
+	if !found {
+		return tracing.Errorf(span, "unsupported format: %s", c.format)
+	}
+
+	file := c.file
+	if file == "" && format.fileEnvVar != "" {
+		file = os.Getenv(format.fileEnvVar)
+		if file == "" {
+			return tracing.Errorf(span, "the %s format needs --file or $%s to be set", c.format, format.fileEnvVar)
+		}
+	}
+
+	span.SetAttributes(
+		attribute.String("format", c.format),
+		attribute.String("file", file),
+	)
+
+	vars, err := c.flagVariables.evaluate(ctx, &c.Meta, &c.userFlags)
+	if err != nil {
+		return tracing.Error(span, err)
+	}
+
+	sb := &strings.Builder{}
+	for _, v := range vars {
+		if err := format.write(sb, v); err != nil {
+			return tracing.Error(span, err)
+		}
+	}
+
+	if file == "" {
+		if !c.silent {
+			c.Ui.Output(strings.TrimSuffix(sb.String(), "\n"))
+		}
+		return nil
+	}
+
+	f, err := c.openFile(file)
+	if err != nil {
+		return tracing.Error(span, err)
+	}
+	defer f.Close()
+
+	if _, err := io.WriteString(f, sb.String()); err != nil {
+		return tracing.Error(span, err)
+	}
+
+	return nil
+}
+
+func writeShell(w io.Writer, v variable) error {
+	_, err := fmt.Fprintf(w, "export %s='%s'\n", v.Name, strings.ReplaceAll(v.Value, "'", `'\''`))
+	return err
+}
+
+func writeDotenv(w io.Writer, v variable) error {
+	if !strings.ContainsAny(v.Value, " \t\n\r\"'\\#$=`") {
+		_, err := fmt.Fprintf(w, "%s=%s\n", v.Name, v.Value)
+		return err
+	}
+
+	escaped := strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"\n", `\n`,
+		"\r", `\r`,
+		"$", `\$`,
+		"`", "\\`",
+	).Replace(v.Value)
+
+	_, err := fmt.Fprintf(w, "%s=\"%s\"\n", v.Name, escaped)
+	return err
+}
+
+// gitlab's dotenv reports take values literally, and don't support quoting
+// or multi-line values
+func writeGitlab(w io.Writer, v variable) error {
+	if strings.ContainsAny(v.Value, "\r\n") {
+		return fmt.Errorf("%s has a multi-line value, which gitlab dotenv reports do not support", v.Name)
+	}
+
+	_, err := fmt.Fprintf(w, "%s=%s\n", v.Name, v.Value)
+	return err
+}
+
+// github's files use a heredoc style syntax for multi-line values, with a
+// random delimiter so that the value cannot end the block early
+func writeGithub(w io.Writer, v variable) error {
+	if !strings.ContainsAny(v.Value, "\r\n") {
+		_, err := fmt.Fprintf(w, "%s=%s\n", v.Name, v.Value)
+		return err
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	delimiter := "ghadelimiter_" + hex.EncodeToString(b)
+
+	_, err := fmt.Fprintf(w, "%s<<%s\n%s\n%s\n", v.Name, delimiter, v.Value, delimiter)
+	return err
+}
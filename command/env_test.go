@@ -0,0 +1,211 @@
+package command
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvFormats(t *testing.T) {
+
+	flagStates := map[string]any{
+		"deploy-v2": true,
+		"message":   "it's a \"test\" $HOME",
+	}
+
+	cases := []struct {
+		format   string
+		expected string
+	}{
+		{
+			format:   "shell",
+			expected: "export FLAG_DEPLOY_V2='true'\nexport FLAG_MESSAGE='it'\\''s a \"test\" $HOME'",
+		},
+		{
+			format:   "dotenv",
+			expected: "FLAG_DEPLOY_V2=true\nFLAG_MESSAGE=\"it's a \\\"test\\\" \\$HOME\"",
+		},
+		{
+			format:   "gitlab",
+			expected: "FLAG_DEPLOY_V2=true\nFLAG_MESSAGE=it's a \"test\" $HOME",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.format, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			cmd, _ := NewEnvCommand(ui)
+			cmd.Meta.testBackend = &MockBackend{flags: flagStates}
+
+			assert.Equal(t, 0, cmd.Run([]string{"--format", tc.format, "--flag", "deploy-v2", "--flag", "message:string"}))
+			assert.Equal(t, tc.expected, strings.TrimSpace(ui.OutputWriter.String()))
+		})
+	}
+}
+
+func TestEnvGithub(t *testing.T) {
+
+	flagStates := map[string]any{
+		"deploy-v2": true,
+		"notes":     "line one\nline two",
+	}
+
+	files := map[string]*bytes.Buffer{}
+	openFile := func(filePath string) (io.WriteCloser, error) {
+		if _, found := files[filePath]; !found {
+			files[filePath] = &bytes.Buffer{}
+		}
+		return &nopWriteCloser{files[filePath]}, nil
+	}
+
+	os.Setenv(GithubOutputEnvVar, "/github/output")
+	defer os.Unsetenv(GithubOutputEnvVar)
+
+	ui := cli.NewMockUi()
+	cmd, _ := NewEnvCommand(ui)
+	cmd.openFile = openFile
+	cmd.Meta.testBackend = &MockBackend{flags: flagStates}
+
+	assert.Equal(t, 0, cmd.Run([]string{"--format", "github-output", "--flag", "deploy-v2", "--flag", "notes"}))
+	assert.Equal(t, "", ui.OutputWriter.String())
+
+	written := files["/github/output"].String()
+	assert.Regexp(t, regexp.MustCompile(`^FLAG_DEPLOY_V2=true
+FLAG_NOTES<<(ghadelimiter_[0-9a-f]{32})
+line one
+line two
+ghadelimiter_[0-9a-f]{32}
+$`), written)
+
+	t.Run("missing env var", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		cmd, _ := NewEnvCommand(ui)
+		cmd.openFile = openFile
+		cmd.Meta.testBackend = &MockBackend{flags: flagStates}
+
+		assert.Equal(t, 2, cmd.Run([]string{"--format", "github-env", "--flag", "deploy-v2"}))
+		assert.Equal(t, "the github-env format needs --file or $GITHUB_ENV to be set\n", ui.ErrorWriter.String())
+	})
+
+	t.Run("explicit file", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		cmd, _ := NewEnvCommand(ui)
+		cmd.openFile = openFile
+		cmd.Meta.testBackend = &MockBackend{flags: flagStates}
+
+		assert.Equal(t, 0, cmd.Run([]string{"--format", "github-env", "--file", "/other/env", "--flag", "deploy-v2"}))
+		assert.Equal(t, "FLAG_DEPLOY_V2=true\n", files["/other/env"].String())
+	})
+
+	t.Run("named variable", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		cmd, _ := NewEnvCommand(ui)
+		cmd.openFile = openFile
+		cmd.Meta.testBackend = &MockBackend{flags: flagStates}
+
+		assert.Equal(t, 0, cmd.Run([]string{"--format", "github-output", "--file", "/named/output", "--var", "state=deploy-v2=false", "--var", "missing=unknown-flag=true"}))
+		assert.Equal(t, "state=true\nmissing=true\n", files["/named/output"].String())
+	})
+}
+
+func TestEnvTypedFlags(t *testing.T) {
+
+	// the env backend checks the values are of the flag's type
+	t.Setenv("FLAGON_ENV_FLAG_STRATEGY", "blue-green")
+	t.Setenv("FLAGON_ENV_FLAG_RETRIES", "3")
+
+	ui := cli.NewMockUi()
+	cmd, _ := NewEnvCommand(ui)
+
+	assert.Equal(t, 0, cmd.Run([]string{
+		"--backend", "env",
+		"--format", "dotenv",
+		"--flag", "strategy:string=rolling",
+		"--flag", "retries:number",
+		"--var", "CONFIG=config:json={\"retries\": 1}",
+		"--var", "ENABLED=enabled:bool=true",
+	}), ui.ErrorWriter.String())
+
+	assert.Equal(t, "FLAG_STRATEGY=blue-green\nFLAG_RETRIES=3\nCONFIG=\"{\\\"retries\\\":1}\"\nENABLED=true", strings.TrimSpace(ui.OutputWriter.String()))
+}
+
+func TestEnvEmptyOverrides(t *testing.T) {
+
+	ui := cli.NewMockUi()
+	cmd, _ := NewEnvCommand(ui)
+	cmd.Meta.testBackend = &MockBackend{flags: map[string]any{"strategy": "blue-green", "region": "eu-west-1"}}
+	cmd.Meta.overrideFlags.readFile = func(filePath string) (io.ReadCloser, error) {
+		return NewReadCloser("region=\n"), nil
+	}
+
+	assert.Equal(t, 0, cmd.Run([]string{
+		"--format", "dotenv",
+		"--flag", "strategy:string=rolling",
+		"--flag", "region:string",
+		"--override", "strategy=",
+	}), ui.ErrorWriter.String())
+
+	assert.Equal(t, "FLAG_STRATEGY=\nFLAG_REGION=", strings.TrimSpace(ui.OutputWriter.String()))
+}
+
+func TestEnvErrors(t *testing.T) {
+
+	t.Run("unknown format", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		cmd, _ := NewEnvCommand(ui)
+		cmd.Meta.testBackend = &MockBackend{}
+
+		assert.Equal(t, 2, cmd.Run([]string{"--format", "powershell"}))
+		assert.Equal(t, "unsupported format: powershell\n", ui.ErrorWriter.String())
+	})
+
+	t.Run("variable without a name", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		cmd, _ := NewEnvCommand(ui)
+		cmd.Meta.testBackend = &MockBackend{}
+
+		assert.Equal(t, 2, cmd.Run([]string{"--var", "deploy-v2"}))
+		assert.Equal(t, "a variable must be in the format NAME=flagKey[:type][=flagDefault]\n", ui.ErrorWriter.String())
+	})
+
+	t.Run("unknown flag type", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		cmd, _ := NewEnvCommand(ui)
+		cmd.Meta.testBackend = &MockBackend{}
+
+		assert.Equal(t, 2, cmd.Run([]string{"--flag", "retries:integer=3"}))
+		assert.Equal(t, "invalid flag retries:integer=3: unsupported flag type: integer (must be one of bool, string, number, json)\n", ui.ErrorWriter.String())
+	})
+
+	t.Run("default of the wrong type", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		cmd, _ := NewEnvCommand(ui)
+		cmd.Meta.testBackend = &MockBackend{}
+
+		assert.Equal(t, 2, cmd.Run([]string{"--flag", "retries:number=three"}))
+		assert.Contains(t, ui.ErrorWriter.String(), "unable to parse the default value of retries as number")
+	})
+
+	t.Run("multi-line gitlab value", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		cmd, _ := NewEnvCommand(ui)
+		cmd.Meta.testBackend = &MockBackend{flags: map[string]any{"notes": "one\ntwo"}}
+
+		assert.Equal(t, 2, cmd.Run([]string{"--format", "gitlab", "--flag", "notes"}))
+		assert.Equal(t, "FLAG_NOTES has a multi-line value, which gitlab dotenv reports do not support\n", ui.ErrorWriter.String())
+	})
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (n *nopWriteCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,140 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"flagon/tracing"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"github.com/mitchellh/cli"
+	"github.com/spf13/pflag"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func NewExecCommand(ui cli.Ui) (*ExecCommand, error) {
+	cmd := &ExecCommand{
+		userFlags: newUserFlags(),
+		stdin:     os.Stdin,
+		stdout:    os.Stdout,
+		stderr:    os.Stderr,
+	}
+	cmd.Meta = NewMeta(ui, cmd)
+
+	return cmd, nil
+}
+
+type ExecCommand struct {
+	Meta
+	userFlags
+	flagVariables
+
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func (c *ExecCommand) Name() string {
+	return "exec"
+}
+
+func (c *ExecCommand) Synopsis() string {
+	return "Runs a command with feature flags as environment variables"
+}
+
+func (c *ExecCommand) Flags() *pflag.FlagSet {
+	flags := pflag.NewFlagSet(c.Name(), pflag.ContinueOnError)
+
+	c.userFlags.addFlags(flags)
+	c.flagVariables.addFlags(flags)
+
+	return flags
+}
+
+func (c *ExecCommand) RunContext(ctx context.Context, args []string) error {
+	ctx, span := c.tr.Start(ctx, "run")
+	defer span.End()
+
+	if len(args) < 1 {
+		return fmt.Errorf("this command takes a command to run, for example: flagon exec --flag some-flag -- ./build.sh")
+	}
+
+	vars, err := c.flagVariables.evaluate(ctx, &c.Meta, &c.userFlags)
+	if err != nil {
+		return tracing.Error(span, err)
+	}
+
+	env := make([]string, 0, len(vars)+1)
+	for _, v := range vars {
+		env = append(env, v.Name+"="+v.Value)
+	}
+
+	if traceParent := tracing.TraceParent(ctx); traceParent != "" {
+		env = append(env, TraceParentEnvVar+"="+traceParent)
+	}
+
+	span.SetAttributes(attribute.StringSlice("command", args))
+
+	child := exec.Command(args[0], args[1:]...)
+	child.Stdin = c.stdin
+	child.Stdout = c.stdout
+	child.Stderr = c.stderr
+	child.Env = append(os.Environ(), env...)
+
+	// signals are for the child process to handle; flagon exits when it does
+	tracing.StopSignalHandling()
+
+	if err := child.Start(); err != nil {
+		return tracing.Error(span, err)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT)
+
+	go func() {
+		for s := range signals {
+			child.Process.Signal(s)
+		}
+	}()
+
+	err = child.Wait()
+
+	signal.Stop(signals)
+	close(signals)
+
+	code := exitCode(err)
+	span.SetAttributes(attribute.Int("exit_code", code))
+
+	if code == 0 {
+		return nil
+	}
+
+	if code == -1 {
+		return tracing.Error(span, err)
+	}
+
+	return &ExitCodeError{Code: code}
+}
+
+// exitCode returns the exit code of the child process, 128+signal if it was
+// killed by a signal, or -1 if it did not run.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return -1
+	}
+
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+
+	return exitErr.ExitCode()
+}
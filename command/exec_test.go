@@ -0,0 +1,101 @@
+package command
+
+import (
+	"bytes"
+	"flagon/tracing"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestExec(t *testing.T) {
+
+	cases := []struct {
+		name           string
+		args           []string
+		flagStates     map[string]any
+		expectedExit   int
+		expectedOutput string
+	}{
+		{
+			name:           "flags as environment variables",
+			args:           []string{"--flag", "deploy-v2", "--flag", "fast.tests=true", "--", "sh", "-c", "echo $FLAG_DEPLOY_V2 $FLAG_FAST_TESTS"},
+			flagStates:     map[string]any{"deploy-v2": true},
+			expectedExit:   0,
+			expectedOutput: "true true",
+		},
+		{
+			name:           "typed flags",
+			args:           []string{"--flag", "strategy:string=rolling", "--flag", "retries:number=2", "--flag", "config:json={\"a\":1}", "--", "sh", "-c", "echo $FLAG_STRATEGY $FLAG_RETRIES $FLAG_CONFIG"},
+			flagStates:     map[string]any{"strategy": "blue-green"},
+			expectedExit:   0,
+			expectedOutput: "blue-green 2 {\"a\":1}",
+		},
+		{
+			name:           "custom prefix",
+			args:           []string{"--flag", "deploy-v2", "--prefix", "CI_", "--", "sh", "-c", "echo $CI_DEPLOY_V2"},
+			expectedExit:   0,
+			expectedOutput: "false",
+		},
+		{
+			name:           "child exit code",
+			args:           []string{"--", "sh", "-c", "echo failing; exit 13"},
+			expectedExit:   13,
+			expectedOutput: "failing",
+		},
+		{
+			name:         "no command",
+			args:         []string{"--flag", "deploy-v2"},
+			expectedExit: 2,
+		},
+		{
+			name:         "command not found",
+			args:         []string{"--", "./this-does-not-exist"},
+			expectedExit: 2,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			stdout := &bytes.Buffer{}
+
+			cmd, _ := NewExecCommand(ui)
+			cmd.stdout = stdout
+			cmd.Meta.testBackend = &MockBackend{flags: tc.flagStates}
+
+			assert.Equal(t, tc.expectedExit, cmd.Run(tc.args))
+			assert.Equal(t, tc.expectedOutput, strings.TrimSpace(stdout.String()))
+		})
+	}
+}
+
+func TestExecTraceParent(t *testing.T) {
+
+	exporter := tracing.NewMemoryExporter()
+	tp := tracesdk.NewTracerProvider(tracesdk.WithSyncer(exporter))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	os.Setenv(TraceParentEnvVar, "00-7107538ee3f6bc77ada1b2d34a412e1d-bfe6177cefb76eb2-01")
+	defer os.Unsetenv(TraceParentEnvVar)
+
+	ui := cli.NewMockUi()
+	stdout := &bytes.Buffer{}
+
+	cmd, _ := NewExecCommand(ui)
+	cmd.Meta.tr = tp.Tracer("exec")
+	cmd.stdout = stdout
+
+	assert.Equal(t, 0, cmd.Run([]string{"--", "sh", "-c", "echo $TRACEPARENT"}))
+
+	parent := strings.Split(strings.TrimSpace(stdout.String()), "-")
+	assert.Len(t, parent, 4)
+	assert.Equal(t, "7107538ee3f6bc77ada1b2d34a412e1d", parent[1])
+	assert.NotEqual(t, "bfe6177cefb76eb2", parent[2])
+}
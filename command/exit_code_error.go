@@ -0,0 +1,22 @@
+package command
+
+import "fmt"
+
+// ExitCodeError makes flagon exit with a specific code, without printing
+// anything.  Used to pass through the exit code of a child process.
+type ExitCodeError struct {
+	Code int
+}
+
+func (e *ExitCodeError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+func IsExitCodeError(err error) (int, bool) {
+	e, ok := err.(*ExitCodeError)
+	if !ok {
+		return 0, false
+	}
+
+	return e.Code, true
+}
@@ -1,7 +1,9 @@
 package command
 
 import (
+	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -10,17 +12,11 @@ func parseKeyValuePairs(tags []string) (map[string]string, error) {
 	m := map[string]string{}
 
 	for _, pair := range tags {
-		index := strings.Index(pair, "=")
-		if index == -1 {
-			return nil, fmt.Errorf("must be in the format key=value")
+		key, val, err := splitKeyValue(pair)
+		if err != nil {
+			return nil, err
 		}
 
-		key := strings.TrimSpace(pair[:index])
-		val := strings.TrimSpace(pair[index+1:])
-
-		if key == "" {
-			return nil, fmt.Errorf("no key specified (must be in the format key=value)")
-		}
 		if val == "" {
 			return nil, fmt.Errorf("no value specified (must be in the format key=value)")
 		}
@@ -30,3 +26,160 @@ func parseKeyValuePairs(tags []string) (map[string]string, error) {
 
 	return m, nil
 }
+
+// splitKeyValue splits a key=value pair, trimming both.  The value can be
+// empty, but the key can't.
+func splitKeyValue(pair string) (string, string, error) {
+	index := strings.Index(pair, "=")
+	if index == -1 {
+		return "", "", fmt.Errorf("must be in the format key=value")
+	}
+
+	key := strings.TrimSpace(pair[:index])
+	val := strings.TrimSpace(pair[index+1:])
+
+	if key == "" {
+		return "", "", fmt.Errorf("no key specified (must be in the format key=value)")
+	}
+
+	return key, val, nil
+}
+
+// parseAttributes parses key=value pairs where the value can have a type,
+// such as `build=42:int`, or be a list such as `tags=[a,b]`
+func parseAttributes(pairs []string) (map[string]any, error) {
+
+	values, err := parseKeyValuePairs(pairs)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := make(map[string]any, len(values))
+
+	for key, raw := range values {
+		val, err := parseAttributeValue(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for attribute %s: %w", key, err)
+		}
+		attrs[key] = val
+	}
+
+	return attrs, nil
+}
+
+// parseAttributeValue converts a value ending with `:string`, `:int`,
+// `:number`, `:bool` or `:json` to that type.  A value in square brackets is
+// a list, either of json values or of comma separated strings, and anything
+// else is a string.
+func parseAttributeValue(raw string) (any, error) {
+
+	if index := strings.LastIndex(raw, ":"); index != -1 {
+		val := raw[:index]
+
+		switch raw[index+1:] {
+		case "string":
+			return val, nil
+
+		case "int":
+			i, err := strconv.ParseInt(val, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			return float64(i), nil
+
+		case "number":
+			return strconv.ParseFloat(val, 64)
+
+		case "bool":
+			return strconv.ParseBool(val)
+
+		case "json":
+			var v any
+			if err := json.Unmarshal([]byte(val), &v); err != nil {
+				return nil, err
+			}
+			return v, nil
+		}
+	}
+
+	if strings.HasPrefix(raw, "[") && strings.HasSuffix(raw, "]") {
+		list := []any{}
+		if err := json.Unmarshal([]byte(raw), &list); err == nil {
+			return list, nil
+		}
+
+		for _, item := range strings.Split(raw[1:len(raw)-1], ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		return list, nil
+	}
+
+	return raw, nil
+}
+
+// splitAttributes splits comma separated key=value pairs, ignoring commas
+// inside lists, json values and quotes, so that `--attr tags=[a,b]` is one
+// attribute.  As with csv, a pair or value starting with a quote is unquoted,
+// so `--attr 'a=1,b="x,y"'` and `--attr 'a=1,"b=x,y"'` both set b to x,y.
+func splitAttributes(args []string) []string {
+	pairs := make([]string, 0, len(args))
+
+	for _, arg := range args {
+		var pair strings.Builder
+		depth := 0
+		quoted := false
+		unquote := false
+		start := true
+		hasKey := false
+
+		for i := 0; i < len(arg); i++ {
+			c := arg[i]
+
+			if unquote {
+				if c != '"' {
+					pair.WriteByte(c)
+				} else if i+1 < len(arg) && arg[i+1] == '"' {
+					pair.WriteByte(c)
+					i++
+				} else {
+					unquote = false
+				}
+				continue
+			}
+
+			switch {
+			case c == '"' && start:
+				unquote = true
+				start = false
+				continue
+			case c == '\\' && quoted && i+1 < len(arg):
+				pair.WriteByte(c)
+				i++
+				c = arg[i]
+			case c == '"':
+				quoted = !quoted
+			case quoted:
+			case c == '[' || c == '{':
+				depth++
+			case c == ']' || c == '}':
+				depth--
+			case c == ',' && depth <= 0:
+				pairs = append(pairs, pair.String())
+				pair.Reset()
+				start = true
+				hasKey = false
+				continue
+			}
+
+			start = c == '=' && !hasKey
+			hasKey = hasKey || c == '='
+			pair.WriteByte(c)
+		}
+
+		pairs = append(pairs, pair.String())
+	}
+
+	return pairs
+}
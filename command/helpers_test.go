@@ -59,3 +59,73 @@ func TestParseKeyValuePairs(t *testing.T) {
 	}
 
 }
+
+func TestParseAttributes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input    string
+		expected any
+		err      string
+	}{
+		{input: "branch=main", expected: "main"},
+		{input: "time=12:30", expected: "12:30"},
+		{input: "url=https://example.com", expected: "https://example.com"},
+		{input: "build=42", expected: "42"},
+		{input: "build=42:int", expected: 42.0},
+		{input: "build=42:string", expected: "42"},
+		{input: "label=main:string:string", expected: "main:string"},
+		{input: "ratio=0.25:number", expected: 0.25},
+		{input: "enabled=true:bool", expected: true},
+		{input: `config={"retries":3}:json`, expected: map[string]any{"retries": 3.0}},
+		{input: "tags=[a, b]", expected: []any{"a", "b"}},
+		{input: "tags=[]", expected: []any{}},
+		{input: "builds=[1,2]", expected: []any{1.0, 2.0}},
+		{input: `tags=["a,b","c"]`, expected: []any{"a,b", "c"}},
+		{input: "build=4.2:int", err: `invalid value for attribute build: strconv.ParseInt: parsing "4.2": invalid syntax`},
+		{input: "enabled=yes:bool", err: `invalid value for attribute enabled: strconv.ParseBool: parsing "yes": invalid syntax`},
+		{input: "config={:json", err: "invalid value for attribute config: unexpected end of JSON input"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			attrs, err := parseAttributes([]string{tc.input})
+			if tc.err == "" {
+				assert.NoError(t, err)
+				assert.Len(t, attrs, 1)
+				for _, val := range attrs {
+					assert.Equal(t, tc.expected, val)
+				}
+			} else {
+				assert.EqualError(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestSplitAttributes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input    []string
+		expected []string
+	}{
+		{input: []string{"branch=main"}, expected: []string{"branch=main"}},
+		{input: []string{"branch=main,build=42:int"}, expected: []string{"branch=main", "build=42:int"}},
+		{input: []string{"tags=[a,b],branch=main"}, expected: []string{"tags=[a,b]", "branch=main"}},
+		{input: []string{`config={"a":[1,2],"b":"x,y"}:json`}, expected: []string{`config={"a":[1,2],"b":"x,y"}:json`}},
+		{input: []string{"a=1", "b=2"}, expected: []string{"a=1", "b=2"}},
+		{input: []string{`a=1,"b=x,y"`}, expected: []string{"a=1", "b=x,y"}},
+		{input: []string{`a=1,b="x,y"`}, expected: []string{"a=1", "b=x,y"}},
+		{input: []string{`b="x,y":string,a=1`}, expected: []string{"b=x,y:string", "a=1"}},
+		{input: []string{`"say ""hi"", twice=yes"`}, expected: []string{`say "hi", twice=yes`}},
+		{input: []string{`greeting=say "hi, there"`}, expected: []string{`greeting=say "hi, there"`}},
+		{input: []string{`a=b="c,d"`}, expected: []string{`a=b="c,d"`}},
+	}
+
+	for i, tc := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			assert.Equal(t, tc.expected, splitAttributes(tc.input))
+		})
+	}
+}
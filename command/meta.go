@@ -5,7 +5,8 @@ import (
 	"context"
 	"encoding/json"
 	"flagon/backends"
-	"flagon/backends/launchdarkly"
+	"flagon/backends/override"
+	"flagon/backends/plugin"
 	"flagon/tracing"
 	"fmt"
 	"os"
@@ -32,7 +33,10 @@ type Meta struct {
 	output  string
 	silent  bool
 
-	ldFlags launchdarkly.LaunchDarklyConfiguration
+	overrideFlags overrideFlags
+
+	pluginFlags    plugin.PluginConfiguration
+	backendConfigs []backendConfig
 
 	testBackend backends.Backend
 }
@@ -45,18 +49,31 @@ type NamedCommand interface {
 	RunContext(ctx context.Context, args []string) error
 }
 
+type backendConfig struct {
+	backends.Registration
+	config backends.BackendConfig
+}
+
 type FlagGroup struct {
 	*pflag.FlagSet
 	Name string
 }
 
 func NewMeta(ui cli.Ui, cmd NamedCommand) Meta {
+	registered := backends.Registered()
+	configs := make([]backendConfig, len(registered))
+
+	for i, r := range registered {
+		configs[i] = backendConfig{Registration: r, config: r.NewConfig()}
+	}
+
 	return Meta{
 		Ui:  ui,
 		cmd: cmd,
 		tr:  otel.Tracer(cmd.Name()),
 
-		ldFlags: launchdarkly.LaunchDarklyConfiguration{},
+		overrideFlags:  newOverrideFlags(),
+		backendConfigs: configs,
 	}
 }
 
@@ -112,22 +129,79 @@ func (m *Meta) allFlags() []FlagGroup {
 
 	common := newFlagGroup("Common")
 
-	common.StringVar(&m.backend, "backend", "launchdarkly", "which flag service to use")
+	names := make([]string, 0, len(m.backendConfigs))
+	for _, bc := range m.backendConfigs {
+		names = append(names, bc.Name)
+	}
+
+	common.StringVar(&m.backend, "backend", "launchdarkly", "which flag service to use: "+strings.Join(names, ", ")+", or a plugin named "+plugin.ExecutablePrefix+"<backend> on the $PATH")
 	common.StringVar(&m.output, "output", "json", "specifies the output format: json or \"template=go template\"")
 	common.BoolVar(&m.silent, "silent", false, "don't print anything to stdout/stderr")
+	m.overrideFlags.addFlags(common.FlagSet)
 
-	return []FlagGroup{
+	groups := []FlagGroup{
 		{Name: "Command", FlagSet: m.cmd.Flags()},
 		common,
-		{Name: "LaunchDarkly Backend", FlagSet: m.ldFlags.Flags()},
-		// other backend flags here
+		{Name: "Plugin", FlagSet: m.pluginFlags.Flags()},
 	}
+
+	for _, bc := range m.backendConfigs {
+		groups = append(groups, FlagGroup{Name: bc.Title, FlagSet: bc.config.Flags()})
+	}
+
+	return groups
 }
 
 func (m *Meta) createBackend(ctx context.Context) (backends.Backend, error) {
 	ctx, span := m.tr.Start(ctx, "create_backend")
 	defer span.End()
 
+	overrides, err := m.overrideFlags.read()
+	if err != nil {
+		return nil, tracing.Error(span, err)
+	}
+
+	backend, err := m.createQueryBackend(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(overrides) == 0 {
+		return backend, nil
+	}
+
+	span.SetAttributes(attribute.Int("overrides", len(overrides)))
+
+	return override.Wrap(backend, overrides), nil
+}
+
+// createQueryBackend creates the backend which flags are queried from, which
+// is a backend selected by its configuration (such as --server) if there is
+// one, otherwise the --backend.
+func (m *Meta) createQueryBackend(ctx context.Context) (backends.Backend, error) {
+	span := trace.SpanFromContext(ctx)
+
+	if m.testBackend != nil {
+		span.SetAttributes(attribute.String("backend", "mock"))
+		return m.testBackend, nil
+	}
+
+	for _, bc := range m.backendConfigs {
+		if bc.config.Selected() {
+			span.SetAttributes(attribute.String("backend", bc.Name))
+			return bc.config.CreateBackend(ctx)
+		}
+	}
+
+	return m.createDirectBackend(ctx)
+}
+
+// createDirectBackend creates the backend specified by the --backend flag,
+// ignoring any backend selected by its configuration, such as --server.
+func (m *Meta) createDirectBackend(ctx context.Context) (backends.Backend, error) {
+	ctx, span := m.tr.Start(ctx, "create_direct_backend")
+	defer span.End()
+
 	if m.testBackend != nil {
 		span.SetAttributes(attribute.String("backend", "mock"))
 		return m.testBackend, nil
@@ -135,18 +209,38 @@ func (m *Meta) createBackend(ctx context.Context) (backends.Backend, error) {
 
 	span.SetAttributes(attribute.String("backend", m.backend))
 
-	switch m.backend {
-	case "launchdarkly":
+	// backends made of other backends, such as the chain, create them with
+	// this command's configuration
+	ctx = backends.WithFactory(ctx, m.createNamedBackend)
 
-		cfg := launchdarkly.DefaultConfig()
-		cfg.OverrideFrom(launchdarkly.ConfigFromEnvironment())
-		cfg.OverrideFrom(m.ldFlags)
+	return m.createNamedBackend(ctx, m.backend)
+}
+
+// createNamedBackend creates a registered backend, or a plugin, by the name
+// it would be given to --backend
+func (m *Meta) createNamedBackend(ctx context.Context, name string) (backends.Backend, error) {
+	ctx, span := m.tr.Start(ctx, "create_named_backend")
+	defer span.End()
 
-		return launchdarkly.CreateBackend(ctx, cfg)
+	span.SetAttributes(attribute.String("backend", name))
 
-	default:
-		return nil, fmt.Errorf("unsupported backend: %s", m.backend)
+	for _, bc := range m.backendConfigs {
+		if bc.Name == name {
+			return bc.config.CreateBackend(ctx)
+		}
 	}
+
+	if path, found := plugin.Find(name); found {
+		span.SetAttributes(attribute.String("plugin.path", path))
+
+		cfg := plugin.DefaultConfig()
+		cfg.OverrideFrom(plugin.ConfigFromEnvironment())
+		cfg.OverrideFrom(m.pluginFlags)
+
+		return plugin.CreateBackend(ctx, name, path, cfg)
+	}
+
+	return nil, fmt.Errorf("unsupported backend: %s", name)
 }
 
 func (m *Meta) print(vals interface{}) error {
@@ -201,6 +295,10 @@ func (m *Meta) Run(args []string) int {
 			return 1
 		}
 
+		if code, ok := IsExitCodeError(err); ok {
+			return code
+		}
+
 		tracing.Error(span, err)
 		m.Ui.Error(err.Error())
 
@@ -56,6 +56,41 @@ func TestPrinting(t *testing.T) {
 
 	})
 
+	t.Run("Json - Reason", func(t *testing.T) {
+
+		m.output = "json"
+		ui.OutputWriter.Reset()
+		ui.ErrorWriter.Reset()
+
+		ruleIndex := 2
+		variationIndex := 0
+		withReason := input
+		withReason.VariationIndex = &variationIndex
+		withReason.Reason = &backends.Reason{Kind: backends.ReasonRuleMatch, RuleIndex: &ruleIndex, RuleID: "abc", InExperiment: true}
+
+		assert.NoError(t, m.print(withReason))
+		assert.Equal(t,
+			`{"key":"the-flag-key","defaultValue":false,"value":true,"variationIndex":0,"reason":{"kind":"RULE_MATCH","ruleIndex":2,"ruleId":"abc","inExperiment":true}}`,
+			strings.TrimSpace(ui.OutputWriter.String()),
+		)
+	})
+
+	t.Run("Template - Reason", func(t *testing.T) {
+
+		m.output = "template={{.Value}} {{.Reason.Kind}}"
+		ui.OutputWriter.Reset()
+		ui.ErrorWriter.Reset()
+
+		withReason := input
+		withReason.Reason = &backends.Reason{Kind: backends.ReasonFallthrough}
+
+		assert.NoError(t, m.print(withReason))
+		assert.Equal(t,
+			`true FALLTHROUGH`,
+			strings.TrimSpace(ui.OutputWriter.String()),
+		)
+	})
+
 	t.Run("Template - Bad Casing", func(t *testing.T) {
 
 		m.output = "template={{.value}}"
@@ -0,0 +1,68 @@
+package command
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// overrideFlags force flag values for every command, regardless of what the
+// backend returns
+type overrideFlags struct {
+	overrides     []string
+	overridesFile string
+
+	readFile func(filePath string) (io.ReadCloser, error)
+}
+
+func newOverrideFlags() overrideFlags {
+	return overrideFlags{
+		readFile: func(f string) (io.ReadCloser, error) {
+			return os.Open(f)
+		},
+	}
+}
+
+func (o *overrideFlags) addFlags(flags *pflag.FlagSet) {
+	flags.StringArrayVar(&o.overrides, "override", []string{}, "key=value of a flag to force to a value, can be specified multiple times")
+	flags.StringVar(&o.overridesFile, "overrides-file", "flagon.overrides", "a file containing key=value flag overrides")
+}
+
+// read combines the overrides file, if it exists, with the --override flags,
+// which take precedence.  Blank lines and lines starting with # are ignored in
+// the file.  A value can be empty, such as `key=`, to override a string flag
+// with an empty string.
+func (o *overrideFlags) read() (map[string]string, error) {
+
+	lines := []string{}
+	if o.overridesFile != "" {
+		f, err := o.readFile(o.overridesFile)
+		if err == nil {
+			defer f.Close()
+			s := bufio.NewScanner(f)
+			for s.Scan() {
+				line := strings.TrimSpace(s.Text())
+				if line != "" && !strings.HasPrefix(line, "#") {
+					lines = append(lines, line)
+				}
+			}
+		}
+	}
+
+	overrides := map[string]string{}
+
+	for _, pair := range append(lines, o.overrides...) {
+		key, val, err := splitKeyValue(pair)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the overrides: %w", err)
+		}
+
+		overrides[key] = val
+	}
+
+	return overrides, nil
+}
@@ -0,0 +1,92 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"flagon/backends/remote"
+	"flagon/tracing"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/mitchellh/cli"
+	"github.com/spf13/pflag"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func NewServeCommand(ui cli.Ui) (*ServeCommand, error) {
+	cmd := &ServeCommand{}
+	cmd.Meta = NewMeta(ui, cmd)
+
+	return cmd, nil
+}
+
+type ServeCommand struct {
+	Meta
+
+	listen string
+}
+
+func (c *ServeCommand) Name() string {
+	return "serve"
+}
+
+func (c *ServeCommand) Synopsis() string {
+	return "Serves flag evaluations to other flagon commands, using a single backend connection"
+}
+
+func (c *ServeCommand) Flags() *pflag.FlagSet {
+	flags := pflag.NewFlagSet(c.Name(), pflag.ContinueOnError)
+
+	flags.StringVar(&c.listen, "listen", "unix:///tmp/flagon.sock", "the address to listen on, for example unix:///tmp/flagon.sock or tcp://localhost:7468")
+
+	return flags
+}
+
+func (c *ServeCommand) RunContext(ctx context.Context, args []string) error {
+	ctx, span := c.tr.Start(ctx, "run")
+	defer span.End()
+
+	if len(args) != 0 {
+		return fmt.Errorf("this command takes no arguments")
+	}
+
+	span.SetAttributes(attribute.String("listen", c.listen))
+
+	backend, err := c.createDirectBackend(ctx)
+	if err != nil {
+		return tracing.Error(span, err)
+	}
+	defer backend.Close(ctx)
+
+	listener, err := remote.Listen(c.listen)
+	if err != nil {
+		return tracing.Error(span, err)
+	}
+
+	server := &http.Server{
+		Handler: remote.NewHandler(backend),
+	}
+
+	// shutdown gracefully rather than letting the tracing signal handler exit
+	tracing.StopSignalHandling()
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+	}()
+
+	if !c.silent {
+		c.Ui.Info(fmt.Sprintf("Listening on %s", c.listen))
+	}
+
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return tracing.Error(span, err)
+	}
+
+	return nil
+}
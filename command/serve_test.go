@@ -0,0 +1,46 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"flagon/backends"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServe(t *testing.T) {
+
+	socket := "unix://" + path.Join(t.TempDir(), "flagon.sock")
+	backend := &MockBackend{flags: map[string]any{"enabled": true}}
+
+	serve, _ := NewServeCommand(cli.NewMockUi())
+	serve.Meta.testBackend = backend
+	serve.listen = socket
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error)
+	go func() {
+		served <- serve.RunContext(ctx, []string{})
+	}()
+
+	ui := cli.NewMockUi()
+	assert.Eventually(t, func() bool {
+		ui.OutputWriter.Reset()
+		ui.ErrorWriter.Reset()
+
+		state, _ := NewStateCommand(ui)
+		return state.Run([]string{"enabled", "--user", "someone", "--server", socket}) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	flag := backends.Flag{}
+	assert.NoError(t, json.Unmarshal(ui.OutputWriter.Bytes(), &flag))
+	assert.Equal(t, backends.Flag{Key: "enabled", DefaultValue: false, Value: true}, flag)
+	assert.Equal(t, "someone", backend.users[0].Key)
+
+	cancel()
+	assert.NoError(t, <-served)
+}
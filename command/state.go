@@ -6,9 +6,8 @@ import (
 	"flagon/backends"
 	"flagon/tracing"
 	"fmt"
-	"io"
-	"os"
 	"strconv"
+	"strings"
 
 	"github.com/mitchellh/cli"
 	"github.com/spf13/pflag"
@@ -17,9 +16,7 @@ import (
 
 func NewStateCommand(ui cli.Ui) (*StateCommand, error) {
 	cmd := &StateCommand{
-		readFile: func(f string) (io.ReadCloser, error) {
-			return os.Open(f)
-		},
+		userFlags: newUserFlags(),
 	}
 	cmd.Meta = NewMeta(ui, cmd)
 
@@ -28,13 +25,9 @@ func NewStateCommand(ui cli.Ui) (*StateCommand, error) {
 
 type StateCommand struct {
 	Meta
+	userFlags
 
-	userKey        string
-	userAttributes []string
-
-	userAttributesFile string
-
-	readFile func(filePath string) (io.ReadCloser, error)
+	flagsFile string
 }
 
 func (c *StateCommand) Name() string {
@@ -42,15 +35,14 @@ func (c *StateCommand) Name() string {
 }
 
 func (c *StateCommand) Synopsis() string {
-	return "Checks the state of a feature flag"
+	return "Checks the state of one or more feature flags"
 }
 
 func (c *StateCommand) Flags() *pflag.FlagSet {
 	flags := pflag.NewFlagSet(c.Name(), pflag.ContinueOnError)
 
-	flags.StringVar(&c.userKey, "user", "", "The key/id of the user to query a flag against")
-	flags.StringSliceVar(&c.userAttributes, "attr", []string{}, "key=value pairs of additional properties for the user")
-	flags.StringVar(&c.userAttributesFile, "attr-file", "flagon.attrs", "a file containing additional properties for the user")
+	c.userFlags.addFlags(flags)
+	flags.StringVar(&c.flagsFile, "flags-file", "", "a file containing flagKey[=flagDefault] lines of flags to query")
 
 	return flags
 }
@@ -59,78 +51,155 @@ func (c *StateCommand) RunContext(ctx context.Context, args []string) error {
 	ctx, span := c.tr.Start(ctx, "run")
 	defer span.End()
 
-	if len(args) < 1 || len(args) > 2 {
-		return fmt.Errorf("this command takes one to two arguments: flagKey and flagDefault")
+	flags, err := c.readFlags(args)
+	if err != nil {
+		return tracing.Error(span, err)
 	}
 
+	span.SetAttributes(attribute.Int("flag.count", len(flags)))
+
 	backend, err := c.createBackend(ctx)
 	if err != nil {
 		return tracing.Error(span, err)
 	}
 	defer backend.Close(ctx)
 
-	flag := backends.Flag{
-		Key: args[0],
+	user, err := c.createUser(ctx)
+	if err != nil {
+		return tracing.Error(span, err)
 	}
 
-	if len(args) > 1 {
-		defaultValue, err := strconv.ParseBool(args[1])
-		if err != nil {
+	allOn := true
+	for i, flag := range flags {
+		if flags[i], err = c.evaluate(ctx, backend, flag, user); err != nil {
 			return tracing.Error(span, err)
 		}
 
-		flag.DefaultValue = defaultValue
+		allOn = allOn && flags[i].IsOn()
+	}
+
+	// a single flag prints as before, multiple flags are printed as an
+	// object keyed by the flag key
+	if len(flags) == 1 && c.flagsFile == "" {
+		err = c.print(flags[0])
+	} else {
+		err = c.print(keyedFlags(flags))
+	}
+
+	if err != nil {
+		return tracing.Error(span, err)
+	}
+
+	if allOn {
+		return nil
 	}
 
+	return &SilentError{}
+}
+
+func (c *StateCommand) evaluate(ctx context.Context, backend backends.Backend, flag backends.Flag, user backends.User) (backends.Flag, error) {
+	ctx, span := c.tr.Start(ctx, "evaluate")
+	defer span.End()
+
 	span.SetAttributes(
 		attribute.String("flag.key", flag.Key),
-		attribute.Bool("flag.default", flag.DefaultValue),
+		attribute.String("flag.default", fmt.Sprint(flag.DefaultValue)),
 	)
 
-	lines := []string{}
-	if c.userAttributesFile != "" {
-		f, err := c.readFile(c.userAttributesFile)
-		if err == nil {
-			defer f.Close()
-			s := bufio.NewScanner(f)
-			for s.Scan() {
-				lines = append(lines, s.Text())
+	flag, err := backend.State(ctx, flag, user)
+	if err != nil {
+		return flag, tracing.Error(span, err)
+	}
+
+	span.SetAttributes(attribute.Bool("flag.value", flag.IsOn()))
+
+	return flag, nil
+}
+
+// readFlags supports both the original `flagKey [flagDefault]` form of
+// arguments, and any number of `flagKey[=flagDefault]` arguments, optionally
+// read from a file.
+func (c *StateCommand) readFlags(args []string) ([]backends.Flag, error) {
+
+	if len(args) == 2 && !strings.Contains(args[0], "=") && !strings.Contains(args[1], "=") {
+		args = []string{args[0] + "=" + args[1]}
+	}
+
+	if c.flagsFile != "" {
+		f, err := c.readFile(c.flagsFile)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		s := bufio.NewScanner(f)
+		for s.Scan() {
+			line := strings.TrimSpace(s.Text())
+			if line != "" && !strings.HasPrefix(line, "#") {
+				args = append(args, line)
 			}
 		}
+
+		if err := s.Err(); err != nil {
+			return nil, err
+		}
 	}
 
-	attrs, err := parseKeyValuePairs(append(lines, c.userAttributes...))
-	if err != nil {
-		return tracing.Error(span, err)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("this command takes at least one argument: flagKey[=flagDefault], or a --flags-file")
 	}
 
-	if key, found := attrs["user-key"]; found {
-		delete(attrs, "user-key")
-		if c.userKey == "" {
-			c.userKey = key
+	flags := make([]backends.Flag, 0, len(args))
+	seen := map[string]bool{}
+
+	for _, arg := range args {
+		flag, err := parseFlagArg(arg)
+		if err != nil {
+			return nil, err
 		}
+
+		if seen[flag.Key] {
+			return nil, fmt.Errorf("flag %s was specified more than once", flag.Key)
+		}
+		seen[flag.Key] = true
+
+		flags = append(flags, flag)
 	}
 
-	user := backends.User{
-		Key:        c.userKey,
-		Attributes: attrs,
+	return flags, nil
+}
+
+func parseFlagArg(arg string) (backends.Flag, error) {
+
+	key, def, hasDefault := strings.Cut(arg, "=")
+
+	flag := backends.Flag{
+		Key:          strings.TrimSpace(key),
+		DefaultValue: false,
 	}
-	span.SetAttributes(attribute.String("user.key", user.Key))
-	span.SetAttributes(tracing.FromMap("user.", user.Attributes)...)
 
-	if flag, err = backend.State(ctx, flag, user); err != nil {
-		return tracing.Error(span, err)
+	if flag.Key == "" {
+		return flag, fmt.Errorf("no flag key specified (must be in the format flagKey[=flagDefault])")
 	}
 
-	if err := c.print(flag); err != nil {
-		return tracing.Error(span, err)
+	if hasDefault {
+		defaultValue, err := strconv.ParseBool(strings.TrimSpace(def))
+		if err != nil {
+			return flag, err
+		}
+
+		flag.DefaultValue = defaultValue
 	}
 
-	span.SetAttributes(attribute.Bool("flag.value", flag.Value))
+	return flag, nil
+}
 
-	if flag.Value {
-		return nil
+func keyedFlags(flags []backends.Flag) map[string]backends.Flag {
+	keyed := make(map[string]backends.Flag, len(flags))
+
+	for _, flag := range flags {
+		keyed[flag.Key] = flag
 	}
 
-	return &SilentError{}
+	return keyed
 }
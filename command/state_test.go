@@ -6,11 +6,15 @@ import (
 	"flagon/backends"
 	"io"
 	"os"
+	"path"
 	"strings"
 	"testing"
 
 	"github.com/mitchellh/cli"
+	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func TestState(t *testing.T) {
@@ -18,7 +22,7 @@ func TestState(t *testing.T) {
 	cases := []struct {
 		key          string
 		defaultValue string
-		flagStates   map[string]bool
+		flagStates   map[string]any
 		expectedExit int
 		expectedFlag backends.Flag
 	}{
@@ -41,7 +45,7 @@ func TestState(t *testing.T) {
 		},
 		{
 			key: "flag-name",
-			flagStates: map[string]bool{
+			flagStates: map[string]any{
 				"flag-name": true,
 			},
 			expectedExit: 0,
@@ -70,6 +74,115 @@ func TestState(t *testing.T) {
 	}
 }
 
+func TestMultipleFlags(t *testing.T) {
+
+	cases := []struct {
+		name          string
+		args          []string
+		files         map[string]string
+		flagStates    map[string]any
+		expectedExit  int
+		expectedFlags map[string]backends.Flag
+		expectedError string
+	}{
+		{
+			name:         "all on",
+			args:         []string{"one", "two=false", "three=true"},
+			flagStates:   map[string]any{"one": true, "two": true},
+			expectedExit: 0,
+			expectedFlags: map[string]backends.Flag{
+				"one":   {Key: "one", DefaultValue: false, Value: true},
+				"two":   {Key: "two", DefaultValue: false, Value: true},
+				"three": {Key: "three", DefaultValue: true, Value: true},
+			},
+		},
+		{
+			name:         "one off",
+			args:         []string{"one=true", "two"},
+			flagStates:   map[string]any{"one": true},
+			expectedExit: 1,
+			expectedFlags: map[string]backends.Flag{
+				"one": {Key: "one", DefaultValue: true, Value: true},
+				"two": {Key: "two", DefaultValue: false, Value: false},
+			},
+		},
+		{
+			name: "from flags file",
+			args: []string{"one", "--flags-file", "ci.flags"},
+			files: map[string]string{
+				"ci.flags": "# flags for ci\ntwo=true\n\nthree\n",
+			},
+			flagStates:   map[string]any{"one": true, "three": true},
+			expectedExit: 0,
+			expectedFlags: map[string]backends.Flag{
+				"one":   {Key: "one", DefaultValue: false, Value: true},
+				"two":   {Key: "two", DefaultValue: true, Value: true},
+				"three": {Key: "three", DefaultValue: false, Value: true},
+			},
+		},
+		{
+			name:         "single flag in flags file",
+			args:         []string{"--flags-file", "ci.flags"},
+			files:        map[string]string{"ci.flags": "one=true"},
+			expectedExit: 0,
+			expectedFlags: map[string]backends.Flag{
+				"one": {Key: "one", DefaultValue: true, Value: true},
+			},
+		},
+		{
+			name:          "missing flags file",
+			args:          []string{"--flags-file", "ci.flags"},
+			expectedExit:  2,
+			expectedError: "file does not exist",
+		},
+		{
+			name:          "duplicate flag",
+			args:          []string{"one", "two", "one=true"},
+			expectedExit:  2,
+			expectedError: "flag one was specified more than once",
+		},
+		{
+			name:          "no flags",
+			args:          []string{},
+			expectedExit:  2,
+			expectedError: "this command takes at least one argument",
+		},
+		{
+			name:          "bad default",
+			args:          []string{"one", "two=yes please"},
+			expectedExit:  2,
+			expectedError: "parsing \"yes please\": invalid syntax",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			cmd, _ := NewStateCommand(ui)
+			cmd.readFile = func(filePath string) (io.ReadCloser, error) {
+				content, found := tc.files[filePath]
+				if !found {
+					return nil, os.ErrNotExist
+				}
+				return NewReadCloser(content), nil
+			}
+			cmd.Meta.testBackend = &MockBackend{flags: tc.flagStates}
+
+			assert.Equal(t, tc.expectedExit, cmd.Run(tc.args))
+
+			if tc.expectedError != "" {
+				assert.Contains(t, ui.ErrorWriter.String(), tc.expectedError)
+				return
+			}
+
+			flags := map[string]backends.Flag{}
+			assert.NoError(t, json.Unmarshal(ui.OutputWriter.Bytes(), &flags))
+
+			assert.Equal(t, tc.expectedFlags, flags)
+		})
+	}
+}
+
 func TestAttributeParsing(t *testing.T) {
 
 	cases := []struct {
@@ -78,7 +191,7 @@ func TestAttributeParsing(t *testing.T) {
 		attrs           []string
 		attrFile        string
 		files           map[string]string
-		expectedAttrs   map[string]string
+		expectedAttrs   map[string]any
 		expectedUserKey string
 	}{
 		{attrs: []string{}},
@@ -86,7 +199,7 @@ func TestAttributeParsing(t *testing.T) {
 		{
 			name:  "only cli flags",
 			attrs: []string{"from=cli"},
-			expectedAttrs: map[string]string{
+			expectedAttrs: map[string]any{
 				"from": "cli",
 			},
 		},
@@ -96,7 +209,7 @@ func TestAttributeParsing(t *testing.T) {
 			files: map[string]string{
 				"flagon.attrs": "from=default file",
 			},
-			expectedAttrs: map[string]string{
+			expectedAttrs: map[string]any{
 				"from": "default file",
 			},
 		},
@@ -106,7 +219,7 @@ func TestAttributeParsing(t *testing.T) {
 			files: map[string]string{
 				"flagon.attrs": "from=default file",
 			},
-			expectedAttrs: map[string]string{
+			expectedAttrs: map[string]any{
 				"from": "cli",
 			},
 		},
@@ -118,7 +231,7 @@ func TestAttributeParsing(t *testing.T) {
 				"flagon.attrs": "from=default file",
 				"other.attrs":  "from=specified",
 			},
-			expectedAttrs: map[string]string{
+			expectedAttrs: map[string]any{
 				"from": "default file",
 			},
 		},
@@ -129,8 +242,8 @@ func TestAttributeParsing(t *testing.T) {
 			files: map[string]string{
 				"flagon.attrs": "from=default file",
 			},
-			expectedAttrs: map[string]string{
-				"from": "",
+			expectedAttrs: map[string]any{
+				"from": nil,
 			},
 		},
 		{
@@ -138,20 +251,20 @@ func TestAttributeParsing(t *testing.T) {
 			userKey:         "cli",
 			attrs:           []string{"user-key=cli"},
 			expectedUserKey: "cli",
-			expectedAttrs:   map[string]string{"user-key": ""},
+			expectedAttrs:   map[string]any{"user-key": nil},
 		},
 		{
 			name:            "user key from cli attr",
 			attrs:           []string{"user-key=attr"},
 			expectedUserKey: "attr",
-			expectedAttrs:   map[string]string{"user-key": ""},
+			expectedAttrs:   map[string]any{"user-key": nil},
 		},
 		{
 			name:            "user key from cli attr and cli flag",
 			userKey:         "flag",
 			attrs:           []string{"user-key=attr"},
 			expectedUserKey: "flag",
-			expectedAttrs:   map[string]string{"user-key": ""},
+			expectedAttrs:   map[string]any{"user-key": nil},
 		},
 		{
 			name: "user key from attr file",
@@ -159,7 +272,7 @@ func TestAttributeParsing(t *testing.T) {
 				"flagon.attrs": "user-key=file",
 			},
 			expectedUserKey: "file",
-			expectedAttrs:   map[string]string{"user-key": ""},
+			expectedAttrs:   map[string]any{"user-key": nil},
 		},
 		{
 			name:    "user key from attr file and flag",
@@ -168,7 +281,50 @@ func TestAttributeParsing(t *testing.T) {
 				"flagon.attrs": "user-key=file",
 			},
 			expectedUserKey: "flag",
-			expectedAttrs:   map[string]string{"user-key": ""},
+			expectedAttrs:   map[string]any{"user-key": nil},
+		},
+		{
+			name:  "typed cli attrs",
+			attrs: []string{"build=42:int", "tags=[a,b],enabled=true:bool"},
+			expectedAttrs: map[string]any{
+				"build":   42.0,
+				"tags":    []any{"a", "b"},
+				"enabled": true,
+			},
+		},
+		{
+			name:  "json attrs file",
+			attrs: []string{"from=cli"},
+			files: map[string]string{
+				"flagon.attrs": `{ "user-key": "file", "from": "default file", "build": 42, "tags": ["a", "b"] }`,
+			},
+			expectedUserKey: "file",
+			expectedAttrs: map[string]any{
+				"user-key": nil,
+				"from":     "cli",
+				"build":    42.0,
+				"tags":     []any{"a", "b"},
+			},
+		},
+		{
+			name:  "quoted commas in cli attrs",
+			attrs: []string{`a=1,b="x,y"`, `"c=x,y",d=[1,2]`},
+			expectedAttrs: map[string]any{
+				"a": "1",
+				"b": "x,y",
+				"c": "x,y",
+				"d": []any{1.0, 2.0},
+			},
+		},
+		{
+			name: "typed attrs file",
+			files: map[string]string{
+				"flagon.attrs": "build=42:int\ntags=[a,b]",
+			},
+			expectedAttrs: map[string]any{
+				"build": 42.0,
+				"tags":  []any{"a", "b"},
+			},
 		},
 	}
 	for _, tc := range cases {
@@ -188,7 +344,7 @@ func TestAttributeParsing(t *testing.T) {
 			}
 
 			backend := &MockBackend{
-				flags: map[string]bool{
+				flags: map[string]any{
 					"some-flag": true,
 				},
 			}
@@ -223,7 +379,7 @@ func TestErrorsExitWithStatus2(t *testing.T) {
 
 		ui := cli.NewMockUi()
 		cmd, _ := NewStateCommand(ui)
-		cmd.Meta.testBackend = &MockBackend{flags: map[string]bool{}}
+		cmd.Meta.testBackend = &MockBackend{flags: map[string]any{}}
 
 		assert.Equal(t, 2, cmd.Run([]string{"test-flag", "bad-bool"}))
 		assert.Contains(t, ui.ErrorWriter.String(), "parsing \"bad-bool\": invalid syntax")
@@ -239,8 +395,35 @@ func TestErrorsExitWithStatus2(t *testing.T) {
 	})
 }
 
+func TestPluginBackend(t *testing.T) {
+
+	// a plugin doesn't have to be written in go, it only needs to answer
+	// each line of json with a line of json
+	script := `#!/bin/sh
+while read -r line; do
+  case "$line" in
+    *'"method":"close"'*) echo '{}'; exit 0 ;;
+    *) echo '{"flag":{"key":"test-flag","defaultValue":false,"value":true,"reason":{"kind":"FALLTHROUGH"}}}' ;;
+  esac
+done
+`
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(path.Join(dir, "flagon-backend-shell"), []byte(script), 0755))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	ui := cli.NewMockUi()
+	cmd, _ := NewStateCommand(ui)
+
+	assert.Equal(t, 0, cmd.Run([]string{"test-flag", "--backend", "shell"}), ui.ErrorWriter.String())
+
+	flag := backends.Flag{}
+	assert.NoError(t, json.Unmarshal(ui.OutputWriter.Bytes(), &flag))
+	assert.Equal(t, true, flag.Value)
+	assert.Equal(t, backends.ReasonFallthrough, flag.Reason.Kind)
+}
+
 type MockBackend struct {
-	flags map[string]bool
+	flags map[string]any
 	users []backends.User
 }
 
@@ -256,6 +439,18 @@ func (m *MockBackend) State(ctx context.Context, flag backends.Flag, user backen
 	return flag, nil
 }
 
+func (m *MockBackend) AllFlags(ctx context.Context, user backends.User) ([]backends.Flag, error) {
+	flags := make([]backends.Flag, 0, len(m.flags))
+
+	for key, value := range m.flags {
+		flags = append(flags, backends.Flag{Key: key, Type: backends.TypeOf(value), Value: value})
+	}
+
+	m.users = append(m.users, user)
+
+	return flags, nil
+}
+
 func (m *MockBackend) Close(ctx context.Context) error {
 	return nil
 }
@@ -275,3 +470,208 @@ func (s *stringrc) Read(p []byte) (n int, err error) {
 func (s *stringrc) Close() error {
 	return nil
 }
+
+func TestChainBackend(t *testing.T) {
+
+	t.Setenv("FLAGON_ENV_FLAG_TEST_FLAG", "true")
+
+	ui := cli.NewMockUi()
+	cmd, _ := NewStateCommand(ui)
+
+	// the missing file can't be created, so the chain falls back to the env backend
+	assert.Equal(t, 0, cmd.Run([]string{"test-flag", "--backend", "chain", "--chain", "file,env", "--file-path", path.Join(t.TempDir(), "missing.yaml")}), ui.ErrorWriter.String())
+
+	flag := backends.Flag{}
+	assert.NoError(t, json.Unmarshal(ui.OutputWriter.Bytes(), &flag))
+	assert.Equal(t, true, flag.Value)
+	assert.Equal(t, "env", flag.Backend)
+}
+
+func TestEnvBackendIgnoresWatchHookVariables(t *testing.T) {
+
+	// a watch hook which runs flagon with the env backend has these set
+	t.Setenv(WatchKeyEnvVar, "approved")
+	t.Setenv(WatchOldValueEnvVar, "false")
+	t.Setenv(WatchNewValueEnvVar, "true")
+
+	for _, key := range []string{"key", "old-value", "new-value"} {
+		ui := cli.NewMockUi()
+		cmd, _ := NewStateCommand(ui)
+
+		assert.Equal(t, 1, cmd.Run([]string{key, "--backend", "env"}), ui.ErrorWriter.String())
+
+		flag := backends.Flag{}
+		assert.NoError(t, json.Unmarshal(ui.OutputWriter.Bytes(), &flag))
+		assert.Equal(t, backends.ErrorFlagNotFound, flag.Reason.ErrorKind, key)
+	}
+}
+
+func TestOverrides(t *testing.T) {
+
+	ui := cli.NewMockUi()
+	cmd, _ := NewStateCommand(ui)
+	cmd.Meta.testBackend = &MockBackend{flags: map[string]any{"first-flag": false, "second-flag": false, "third-flag": true}}
+	cmd.Meta.overrideFlags.readFile = func(filePath string) (io.ReadCloser, error) {
+		if filePath != "debug.overrides" {
+			return nil, os.ErrNotExist
+		}
+		return NewReadCloser("# forced while debugging\nfirst-flag=true\n\nsecond-flag=true\n"), nil
+	}
+
+	args := []string{"first-flag", "second-flag", "third-flag", "--overrides-file", "debug.overrides", "--override", "second-flag=false"}
+	assert.Equal(t, 1, cmd.Run(args), ui.ErrorWriter.String())
+
+	flags := map[string]backends.Flag{}
+	assert.NoError(t, json.Unmarshal(ui.OutputWriter.Bytes(), &flags))
+
+	assert.Equal(t, map[string]backends.Flag{
+		"first-flag":  {Key: "first-flag", DefaultValue: false, Value: true, Reason: &backends.Reason{Kind: backends.ReasonOverride}},
+		"second-flag": {Key: "second-flag", DefaultValue: false, Value: false, Reason: &backends.Reason{Kind: backends.ReasonOverride}},
+		"third-flag":  {Key: "third-flag", DefaultValue: false, Value: true},
+	}, flags)
+}
+
+func TestContextParsing(t *testing.T) {
+
+	cases := []struct {
+		name          string
+		args          []string
+		expectedUser  backends.User
+		expectedError string
+	}{
+		{
+			name: "user attributes are unchanged without contexts",
+			args: []string{"--user", "alice", "--attr", "repository.branch=main"},
+			expectedUser: backends.User{
+				Key:        "alice",
+				Attributes: map[string]any{"repository.branch": "main"},
+			},
+		},
+		{
+			name: "attributes belong to their context",
+			args: []string{"--user", "alice", "--context", "repository:flagon", "--context", "organisation:pondidum", "--attr", "repository.branch=main", "--attr", "email=alice@example.com"},
+			expectedUser: backends.User{
+				Key:        "alice",
+				Attributes: map[string]any{"email": "alice@example.com"},
+				Contexts: []backends.Context{
+					{Kind: "repository", Key: "flagon", Attributes: map[string]any{"branch": "main"}},
+					{Kind: "organisation", Key: "pondidum", Attributes: map[string]any{}},
+				},
+			},
+		},
+		{
+			name: "user context",
+			args: []string{"--context", "user:alice", "--context", "repository:flagon", "--attr", "user.email=alice@example.com"},
+			expectedUser: backends.User{
+				Key:        "alice",
+				Attributes: map[string]any{"email": "alice@example.com"},
+				Contexts: []backends.Context{
+					{Kind: "repository", Key: "flagon", Attributes: map[string]any{}},
+				},
+			},
+		},
+		{
+			name: "private attributes belong to their context",
+			args: []string{"--user", "alice", "--anonymous", "--context", "repository:flagon", "--attr", "repository.branch=main", "--attr", "email=alice@example.com", "--private-attr", "email,repository.branch"},
+			expectedUser: backends.User{
+				Key:               "alice",
+				Attributes:        map[string]any{"email": "alice@example.com"},
+				PrivateAttributes: []string{"email"},
+				Anonymous:         true,
+				Contexts: []backends.Context{
+					{Kind: "repository", Key: "flagon", Attributes: map[string]any{"branch": "main"}, PrivateAttributes: []string{"branch"}},
+				},
+			},
+		},
+		{
+			name:          "conflicting user",
+			args:          []string{"--user", "alice", "--context", "user:bob"},
+			expectedError: "the user is specified as both alice and bob",
+		},
+		{
+			name:          "duplicate kind",
+			args:          []string{"--context", "repository:flagon", "--context", "repository:other"},
+			expectedError: "there is more than one repository context",
+		},
+		{
+			name:          "no key",
+			args:          []string{"--context", "repository"},
+			expectedError: "a context must be in the format kind:key, for example repository:flagon",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			backend := &MockBackend{flags: map[string]any{"some-flag": true}}
+
+			ui := cli.NewMockUi()
+			cmd, _ := NewStateCommand(ui)
+			cmd.readFile = func(filePath string) (io.ReadCloser, error) {
+				return nil, os.ErrNotExist
+			}
+			cmd.Meta.testBackend = backend
+
+			exitCode := cmd.Run(append([]string{"some-flag"}, tc.args...))
+
+			if tc.expectedError != "" {
+				assert.Equal(t, 2, exitCode)
+				assert.Contains(t, ui.ErrorWriter.String(), tc.expectedError)
+				return
+			}
+
+			assert.Equal(t, 0, exitCode, ui.ErrorWriter.String())
+			assert.Equal(t, tc.expectedUser, backend.users[0])
+		})
+	}
+}
+
+func TestPrivateAttributesAreNotTraced(t *testing.T) {
+
+	recorder := tracetest.NewSpanRecorder()
+	ctx, span := tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(recorder)).Tracer("test").Start(context.Background(), "test")
+
+	u := newUserFlags()
+	u.readFile = func(filePath string) (io.ReadCloser, error) {
+		return nil, os.ErrNotExist
+	}
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	u.addFlags(flags)
+	assert.NoError(t, flags.Parse([]string{"--user", "alice", "--context", "repository:flagon", "--attr", "email=alice@example.com,branch=main,repository.owner=pondidum,repository.visibility=private", "--private-attr", "email,repository.owner"}))
+
+	_, err := u.createUser(ctx)
+	assert.NoError(t, err)
+	span.End()
+
+	attrs := map[string]string{}
+	for _, kv := range recorder.Ended()[0].Attributes() {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+
+	assert.Equal(t, map[string]string{
+		"user.key":                      "alice",
+		"user.anonymous":                "false",
+		"user.branch":                   "main",
+		"context.repository.key":        "flagon",
+		"context.repository.visibility": "private",
+	}, attrs)
+}
+
+func TestAnonymousUserKey(t *testing.T) {
+
+	backend := &MockBackend{flags: map[string]any{}}
+
+	ui := cli.NewMockUi()
+	cmd, _ := NewStateCommand(ui)
+	cmd.readFile = func(filePath string) (io.ReadCloser, error) {
+		return nil, os.ErrNotExist
+	}
+	cmd.Meta.testBackend = backend
+
+	assert.Equal(t, 1, cmd.Run([]string{"first-flag", "second-flag=false", "--anonymous"}), ui.ErrorWriter.String())
+
+	assert.Len(t, backend.users, 2)
+	assert.True(t, backend.users[0].Anonymous)
+	assert.NotEmpty(t, backend.users[0].Key)
+	assert.Equal(t, backend.users[0].Key, backend.users[1].Key)
+}
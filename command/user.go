@@ -0,0 +1,225 @@
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flagon/backends"
+	"flagon/tracing"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// userFlags are the flags shared by all commands which evaluate flags for a user
+type userFlags struct {
+	userKey        string
+	userAttributes []string
+
+	userAttributesFile string
+
+	privateAttributes []string
+	anonymous         bool
+
+	contexts []string
+
+	readFile func(filePath string) (io.ReadCloser, error)
+}
+
+func newUserFlags() userFlags {
+	return userFlags{
+		readFile: func(f string) (io.ReadCloser, error) {
+			return os.Open(f)
+		},
+	}
+}
+
+func (u *userFlags) addFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&u.userKey, "user", "", "The key/id of the user to query a flag against")
+	flags.StringArrayVar(&u.userAttributes, "attr", []string{}, "key=value pairs of additional properties for the user.  Values can have a type, such as build=42:int, enabled=true:bool, or config={...}:json, and tags=[a,b] is a list")
+	flags.StringVar(&u.userAttributesFile, "attr-file", "flagon.attrs", "a file containing additional properties for the user, as key=value lines or a json object")
+	flags.StringSliceVar(&u.privateAttributes, "private-attr", nil, "names of attributes which are used to evaluate flags, but are not stored by the backend or written to traces")
+	flags.BoolVar(&u.anonymous, "anonymous", false, "the user is anonymous, and is not stored by the backend")
+	flags.StringArrayVar(&u.contexts, "context", []string{}, "kind:key of a context to query a flag against, such as repository:flagon.  Attributes prefixed with the kind, such as repository.branch=main, belong to the context")
+}
+
+func (u *userFlags) createUser(ctx context.Context) (backends.User, error) {
+	span := trace.SpanFromContext(ctx)
+
+	attrs := map[string]any{}
+	if u.userAttributesFile != "" {
+		f, err := u.readFile(u.userAttributesFile)
+		if err == nil {
+			defer f.Close()
+			if attrs, err = readAttributes(f); err != nil {
+				return backends.User{}, err
+			}
+		}
+	}
+
+	cliAttrs, err := parseAttributes(splitAttributes(u.userAttributes))
+	if err != nil {
+		return backends.User{}, err
+	}
+
+	for key, val := range cliAttrs {
+		attrs[key] = val
+	}
+
+	if key, found := attrs["user-key"]; found {
+		delete(attrs, "user-key")
+		if u.userKey == "" {
+			u.userKey = backends.AttributeString(key)
+		}
+	}
+
+	user := backends.User{
+		Key:               u.userKey,
+		Attributes:        attrs,
+		PrivateAttributes: u.privateAttributes,
+		Anonymous:         u.anonymous,
+	}
+
+	if err := u.addContexts(&user); err != nil {
+		return backends.User{}, err
+	}
+
+	// backends such as launchdarkly need a key even for anonymous users, and
+	// it must be the same for every flag so that rollouts are consistent
+	if user.Anonymous && user.Key == "" {
+		key, err := anonymousKey()
+		if err != nil {
+			return backends.User{}, err
+		}
+		user.Key = key
+	}
+
+	span.SetAttributes(
+		attribute.String("user.key", user.Key),
+		attribute.Bool("user.anonymous", user.Anonymous),
+	)
+	span.SetAttributes(tracing.FromMap("user.", backends.PublicAttributes(user.Attributes, user.PrivateAttributes))...)
+
+	for _, c := range user.Contexts {
+		span.SetAttributes(attribute.String("context."+c.Kind+".key", c.Key))
+		span.SetAttributes(tracing.FromMap("context."+c.Kind+".", backends.PublicAttributes(c.Attributes, c.PrivateAttributes))...)
+	}
+
+	return user, nil
+}
+
+// anonymousKey creates a random key, so that each anonymous user is different
+func anonymousKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("unable to create a key for the anonymous user: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// readAttributes reads a json object of attributes, or key=value lines where
+// the values can have types in the same way as --attr
+func readAttributes(r io.Reader) (map[string]any, error) {
+
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte("{")) {
+		attrs := map[string]any{}
+		if err := json.Unmarshal(content, &attrs); err != nil {
+			return nil, err
+		}
+		return attrs, nil
+	}
+
+	lines := []string{}
+	s := bufio.NewScanner(bytes.NewReader(content))
+	for s.Scan() {
+		lines = append(lines, s.Text())
+	}
+
+	return parseAttributes(lines)
+}
+
+// addContexts adds each --context to the user, moving the attributes and
+// private attributes which are prefixed by a context's kind to that context.
+// A context of the user kind is the same as --user.
+func (u *userFlags) addContexts(user *backends.User) error {
+
+	// the index of each kind's context, where the user kind is -1
+	contexts := map[string]int{}
+
+	for _, arg := range u.contexts {
+		kind, key, found := strings.Cut(arg, ":")
+		if !found || kind == "" || key == "" {
+			return fmt.Errorf("a context must be in the format kind:key, for example repository:flagon")
+		}
+
+		if _, duplicate := contexts[kind]; duplicate {
+			return fmt.Errorf("there is more than one %s context", kind)
+		}
+
+		if kind == "user" {
+			if user.Key != "" && user.Key != key {
+				return fmt.Errorf("the user is specified as both %s and %s", user.Key, key)
+			}
+
+			user.Key = key
+			contexts[kind] = -1
+			continue
+		}
+
+		contexts[kind] = len(user.Contexts)
+		user.Contexts = append(user.Contexts, backends.Context{Kind: kind, Key: key, Attributes: map[string]any{}})
+	}
+
+	if len(contexts) == 0 {
+		return nil
+	}
+
+	// owner finds the context an attribute belongs to, and its name without
+	// the kind prefix
+	owner := func(name string) (int, string) {
+		kind, attr, found := strings.Cut(name, ".")
+		if index, isContext := contexts[kind]; found && isContext {
+			return index, attr
+		}
+		return -1, name
+	}
+
+	attrs := make(map[string]any, len(user.Attributes))
+
+	for name, value := range user.Attributes {
+		if index, attr := owner(name); index < 0 {
+			attrs[attr] = value
+		} else {
+			user.Contexts[index].Attributes[attr] = value
+		}
+	}
+
+	var private []string
+
+	for _, name := range user.PrivateAttributes {
+		if index, attr := owner(name); index < 0 {
+			private = append(private, attr)
+		} else {
+			user.Contexts[index].PrivateAttributes = append(user.Contexts[index].PrivateAttributes, attr)
+		}
+	}
+
+	user.Attributes = attrs
+	user.PrivateAttributes = private
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package command
+
+import (
+	"context"
+	"flagon/backends"
+	"flagon/tracing"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// flagVariables are the flags shared by commands which expose flag values as
+// variables, such as environment variables
+type flagVariables struct {
+	flags  []string
+	vars   []string
+	prefix string
+}
+
+type variable struct {
+	Name  string
+	Value string
+}
+
+func (v *flagVariables) addFlags(flags *pflag.FlagSet) {
+	flags.StringArrayVar(&v.flags, "flag", []string{}, "flagKey[:type][=flagDefault] of a flag to query, where the type is bool (the default), string, number or json.  Can be specified multiple times")
+	flags.StringArrayVar(&v.vars, "var", []string{}, "NAME=flagKey[:type][=flagDefault] of a flag to query as the variable NAME, rather than a name from the prefix and key.  Can be specified multiple times")
+	flags.StringVar(&v.prefix, "prefix", "FLAG_", "prefix for the variable names")
+}
+
+func (v *flagVariables) evaluate(ctx context.Context, m *Meta, u *userFlags) ([]variable, error) {
+	ctx, span := m.tr.Start(ctx, "evaluate_variables")
+	defer span.End()
+
+	// the variable name of each flag, where an empty name is generated from
+	// the prefix and the flag's key
+	names := make([]string, 0, len(v.flags)+len(v.vars))
+	args := make([]string, 0, len(v.flags)+len(v.vars))
+
+	for _, arg := range v.flags {
+		names = append(names, "")
+		args = append(args, arg)
+	}
+
+	for _, arg := range v.vars {
+		name, flagArg, found := strings.Cut(arg, "=")
+		if !found || name == "" {
+			return nil, tracing.Errorf(span, "a variable must be in the format NAME=flagKey[:type][=flagDefault]")
+		}
+
+		names = append(names, name)
+		args = append(args, flagArg)
+	}
+
+	if len(args) == 0 {
+		return []variable{}, nil
+	}
+
+	backend, err := m.createBackend(ctx)
+	if err != nil {
+		return nil, tracing.Error(span, err)
+	}
+	defer backend.Close(ctx)
+
+	user, err := u.createUser(ctx)
+	if err != nil {
+		return nil, tracing.Error(span, err)
+	}
+
+	vars := make([]variable, 0, len(args))
+
+	for i, arg := range args {
+		flag, err := parseVariableArg(arg)
+		if err != nil {
+			return nil, tracing.Error(span, err)
+		}
+
+		if flag, err = backend.State(ctx, flag, user); err != nil {
+			return nil, tracing.Error(span, err)
+		}
+
+		value := backends.AttributeString(flag.Value)
+
+		name := names[i]
+		if name == "" {
+			name = backends.EnvVarName(v.prefix, flag.Key)
+		}
+		span.SetAttributes(attribute.String("var."+name, value))
+
+		vars = append(vars, variable{Name: name, Value: value})
+	}
+
+	return vars, nil
+}
+
+// parseVariableArg reads a `flagKey[:type][=flagDefault]` argument, where the
+// type is bool unless specified, and the default is the type's zero value
+func parseVariableArg(arg string) (backends.Flag, error) {
+
+	key, def, hasDefault := strings.Cut(arg, "=")
+	key = strings.TrimSpace(key)
+
+	flagType := backends.TypeBool
+
+	if i := strings.LastIndex(key, ":"); i >= 0 {
+		t, err := backends.ParseFlagType(key[i+1:])
+		if err != nil {
+			return backends.Flag{}, fmt.Errorf("invalid flag %s: %w", arg, err)
+		}
+
+		key, flagType = key[:i], t
+	}
+
+	flag := backends.Flag{
+		Key:          key,
+		Type:         flagType,
+		DefaultValue: zeroValue(flagType),
+	}
+
+	if flag.Key == "" {
+		return flag, fmt.Errorf("no flag key specified (must be in the format flagKey[:type][=flagDefault])")
+	}
+
+	if hasDefault {
+		if flagType != backends.TypeString {
+			def = strings.TrimSpace(def)
+		}
+
+		defaultValue, err := backends.ParseValue(flagType, def)
+		if err != nil {
+			return flag, fmt.Errorf("unable to parse the default value of %s as %s: %w", flag.Key, flagType, err)
+		}
+
+		flag.DefaultValue = defaultValue
+	}
+
+	return flag, nil
+}
@@ -0,0 +1,99 @@
+package command
+
+import (
+	"context"
+	"flagon/backends"
+	"flagon/tracing"
+	"fmt"
+
+	"github.com/mitchellh/cli"
+	"github.com/spf13/pflag"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func NewVariationCommand(ui cli.Ui) (*VariationCommand, error) {
+	cmd := &VariationCommand{
+		userFlags: newUserFlags(),
+	}
+	cmd.Meta = NewMeta(ui, cmd)
+
+	return cmd, nil
+}
+
+type VariationCommand struct {
+	Meta
+	userFlags
+
+	flagType string
+}
+
+func (c *VariationCommand) Name() string {
+	return "variation"
+}
+
+func (c *VariationCommand) Synopsis() string {
+	return "Gets the value of a string, number or json feature flag"
+}
+
+func (c *VariationCommand) Flags() *pflag.FlagSet {
+	flags := pflag.NewFlagSet(c.Name(), pflag.ContinueOnError)
+
+	c.userFlags.addFlags(flags)
+	flags.StringVar(&c.flagType, "type", "string", "the type of the flag: bool, string, number or json")
+
+	return flags
+}
+
+func (c *VariationCommand) RunContext(ctx context.Context, args []string) error {
+	ctx, span := c.tr.Start(ctx, "run")
+	defer span.End()
+
+	if len(args) != 2 {
+		return fmt.Errorf("this command takes two arguments: flagKey and flagDefault")
+	}
+
+	flagType, err := backends.ParseFlagType(c.flagType)
+	if err != nil {
+		return tracing.Error(span, err)
+	}
+
+	defaultValue, err := backends.ParseValue(flagType, args[1])
+	if err != nil {
+		return tracing.Errorf(span, "unable to parse the default value as %s: %w", flagType, err)
+	}
+
+	flag := backends.Flag{
+		Key:          args[0],
+		Type:         flagType,
+		DefaultValue: defaultValue,
+	}
+
+	span.SetAttributes(
+		attribute.String("flag.key", flag.Key),
+		attribute.String("flag.type", string(flag.Type)),
+		attribute.String("flag.default", args[1]),
+	)
+
+	backend, err := c.createBackend(ctx)
+	if err != nil {
+		return tracing.Error(span, err)
+	}
+	defer backend.Close(ctx)
+
+	user, err := c.createUser(ctx)
+	if err != nil {
+		return tracing.Error(span, err)
+	}
+
+	if flag, err = backend.State(ctx, flag, user); err != nil {
+		return tracing.Error(span, err)
+	}
+
+	if err := c.print(flag); err != nil {
+		return tracing.Error(span, err)
+	}
+
+	span.SetAttributes(attribute.String("flag.value", fmt.Sprint(flag.Value)))
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package command
+
+import (
+	"encoding/json"
+	"flagon/backends"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVariation(t *testing.T) {
+
+	cases := []struct {
+		name          string
+		args          []string
+		flagStates    map[string]any
+		expectedExit  int
+		expectedFlag  backends.Flag
+		expectedError string
+	}{
+		{
+			name:         "string default",
+			args:         []string{"strategy", "rolling"},
+			expectedFlag: backends.Flag{Key: "strategy", Type: backends.TypeString, DefaultValue: "rolling", Value: "rolling"},
+		},
+		{
+			name:         "string value",
+			args:         []string{"strategy", "rolling"},
+			flagStates:   map[string]any{"strategy": "blue-green"},
+			expectedFlag: backends.Flag{Key: "strategy", Type: backends.TypeString, DefaultValue: "rolling", Value: "blue-green"},
+		},
+		{
+			name:         "number value",
+			args:         []string{"parallelism", "1", "--type", "number"},
+			flagStates:   map[string]any{"parallelism": 4.0},
+			expectedFlag: backends.Flag{Key: "parallelism", Type: backends.TypeNumber, DefaultValue: 1.0, Value: 4.0},
+		},
+		{
+			name:         "json value",
+			args:         []string{"config", "{}", "--type", "json"},
+			flagStates:   map[string]any{"config": map[string]any{"retries": 3.0}},
+			expectedFlag: backends.Flag{Key: "config", Type: backends.TypeJSON, DefaultValue: map[string]any{}, Value: map[string]any{"retries": 3.0}},
+		},
+		{
+			name:         "false bool still succeeds",
+			args:         []string{"enabled", "false", "--type", "bool"},
+			expectedFlag: backends.Flag{Key: "enabled", Type: backends.TypeBool, DefaultValue: false, Value: false},
+		},
+		{
+			name:          "missing default",
+			args:          []string{"strategy"},
+			expectedExit:  2,
+			expectedError: "this command takes two arguments: flagKey and flagDefault",
+		},
+		{
+			name:          "bad type",
+			args:          []string{"strategy", "rolling", "--type", "integer"},
+			expectedExit:  2,
+			expectedError: "unsupported flag type: integer (must be one of bool, string, number, json)",
+		},
+		{
+			name:          "bad default",
+			args:          []string{"parallelism", "many", "--type", "number"},
+			expectedExit:  2,
+			expectedError: "unable to parse the default value as number",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			cmd, _ := NewVariationCommand(ui)
+			cmd.Meta.testBackend = &MockBackend{flags: tc.flagStates}
+
+			assert.Equal(t, tc.expectedExit, cmd.Run(tc.args))
+
+			if tc.expectedError != "" {
+				assert.Contains(t, ui.ErrorWriter.String(), tc.expectedError)
+				return
+			}
+
+			flag := backends.Flag{}
+			assert.NoError(t, json.Unmarshal(ui.OutputWriter.Bytes(), &flag))
+
+			assert.Equal(t, tc.expectedFlag, flag)
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"flagon/backends"
+	"flagon/tracing"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"github.com/spf13/pflag"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func NewWaitCommand(ui cli.Ui) (*WaitCommand, error) {
+	cmd := &WaitCommand{
+		userFlags: newUserFlags(),
+	}
+	cmd.Meta = NewMeta(ui, cmd)
+
+	return cmd, nil
+}
+
+type WaitCommand struct {
+	Meta
+	userFlags
+
+	flagType string
+	timeout  time.Duration
+	interval time.Duration
+}
+
+func (c *WaitCommand) Name() string {
+	return "wait"
+}
+
+func (c *WaitCommand) Synopsis() string {
+	return "Waits until a feature flag has a specific value"
+}
+
+func (c *WaitCommand) Flags() *pflag.FlagSet {
+	flags := pflag.NewFlagSet(c.Name(), pflag.ContinueOnError)
+
+	c.userFlags.addFlags(flags)
+	flags.StringVar(&c.flagType, "type", "bool", "the type of the flag: bool, string, number or json")
+	flags.DurationVar(&c.timeout, "timeout", 0, "how long to wait before giving up, 0 waits forever")
+	flags.DurationVar(&c.interval, "interval", 10*time.Second, "how often to check the flag, for backends which cannot be notified of changes")
+
+	return flags
+}
+
+func (c *WaitCommand) RunContext(ctx context.Context, args []string) error {
+	ctx, span := c.tr.Start(ctx, "run")
+	defer span.End()
+
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("this command takes one to two arguments: flagKey and targetValue")
+	}
+
+	flagType, err := backends.ParseFlagType(c.flagType)
+	if err != nil {
+		return tracing.Error(span, err)
+	}
+
+	rawTarget := "true"
+	if len(args) > 1 {
+		rawTarget = args[1]
+	}
+
+	target, err := backends.ParseValue(flagType, rawTarget)
+	if err != nil {
+		return tracing.Errorf(span, "unable to parse the target value as %s: %w", flagType, err)
+	}
+
+	flag := backends.Flag{
+		Key:          args[0],
+		Type:         flagType,
+		DefaultValue: zeroValue(flagType),
+	}
+
+	span.SetAttributes(
+		attribute.String("flag.key", flag.Key),
+		attribute.String("flag.target", rawTarget),
+		attribute.Int64("timeout_ms", c.timeout.Milliseconds()),
+	)
+
+	backend, err := c.createBackend(ctx)
+	if err != nil {
+		return tracing.Error(span, err)
+	}
+	defer backend.Close(ctx)
+
+	user, err := c.createUser(ctx)
+	if err != nil {
+		return tracing.Error(span, err)
+	}
+
+	// handle signals here so that an interrupted wait is not reported as success
+	tracing.StopSignalHandling()
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var timeout <-chan time.Time
+	if c.timeout > 0 {
+		timer := time.NewTimer(c.timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	changes, err := backends.Watch(watchCtx, backend, flag, user, c.interval)
+	if err != nil {
+		return tracing.Error(span, err)
+	}
+
+	for {
+		select {
+		case current, ok := <-changes:
+			if !ok {
+				return tracing.Error(span, errors.New("stopped watching the flag"))
+			}
+
+			flag = current
+			if reflect.DeepEqual(flag.Value, target) {
+				span.SetAttributes(attribute.Bool("reached", true))
+				return c.print(flag)
+			}
+
+		case <-timeout:
+			span.SetAttributes(attribute.Bool("reached", false))
+			if err := c.print(flag); err != nil {
+				return tracing.Error(span, err)
+			}
+
+			return &SilentError{}
+
+		case <-ctx.Done():
+			return tracing.Error(span, errors.New("interrupted while waiting for the flag"))
+		}
+	}
+}
+
+func zeroValue(t backends.FlagType) any {
+	switch t {
+	case backends.TypeBool:
+		return false
+	case backends.TypeString:
+		return ""
+	case backends.TypeNumber:
+		return 0.0
+	default:
+		return nil
+	}
+}
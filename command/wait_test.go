@@ -0,0 +1,85 @@
+package command
+
+import (
+	"encoding/json"
+	"flagon/backends"
+	"flagon/backends/backendtest"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWait(t *testing.T) {
+
+	cases := []struct {
+		name          string
+		args          []string
+		values        []any
+		expectedExit  int
+		expectedValue any
+		expectedError string
+	}{
+		{
+			name:          "already on",
+			args:          []string{"approved"},
+			values:        []any{true},
+			expectedExit:  0,
+			expectedValue: true,
+		},
+		{
+			name:          "turned on",
+			args:          []string{"approved", "true"},
+			values:        []any{false, false, false, true},
+			expectedExit:  0,
+			expectedValue: true,
+		},
+		{
+			name:          "waiting for off",
+			args:          []string{"approved", "false"},
+			values:        []any{true, false},
+			expectedExit:  0,
+			expectedValue: false,
+		},
+		{
+			name:          "string value",
+			args:          []string{"stage", "production", "--type", "string"},
+			values:        []any{"staging", "production"},
+			expectedExit:  0,
+			expectedValue: "production",
+		},
+		{
+			name:          "timed out",
+			args:          []string{"approved", "--timeout", "20ms"},
+			values:        []any{false},
+			expectedExit:  1,
+			expectedValue: false,
+		},
+		{
+			name:          "bad target",
+			args:          []string{"approved", "yes"},
+			expectedExit:  2,
+			expectedError: "unable to parse the target value as bool",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			cmd, _ := NewWaitCommand(ui)
+			cmd.Meta.testBackend = &backendtest.SequenceBackend{Values: tc.values}
+
+			args := append(tc.args, "--interval", "1ms")
+			assert.Equal(t, tc.expectedExit, cmd.Run(args))
+
+			if tc.expectedError != "" {
+				assert.Contains(t, ui.ErrorWriter.String(), tc.expectedError)
+				return
+			}
+
+			flag := backends.Flag{}
+			assert.NoError(t, json.Unmarshal(ui.OutputWriter.Bytes(), &flag))
+			assert.Equal(t, tc.expectedValue, flag.Value)
+		})
+	}
+}
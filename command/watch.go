@@ -0,0 +1,177 @@
+package command
+
+import (
+	"context"
+	"flagon/backends"
+	"flagon/tracing"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"github.com/spf13/pflag"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	WatchKeyEnvVar      = "FLAGON_FLAG_KEY"
+	WatchOldValueEnvVar = "FLAGON_OLD_VALUE"
+	WatchNewValueEnvVar = "FLAGON_NEW_VALUE"
+)
+
+func NewWatchCommand(ui cli.Ui) (*WatchCommand, error) {
+	cmd := &WatchCommand{
+		userFlags: newUserFlags(),
+		stdout:    os.Stdout,
+		stderr:    os.Stderr,
+	}
+	cmd.Meta = NewMeta(ui, cmd)
+
+	return cmd, nil
+}
+
+type WatchCommand struct {
+	Meta
+	userFlags
+
+	flagType string
+	interval time.Duration
+	onChange string
+
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// flagChange is printed each time the flag changes
+type flagChange struct {
+	backends.Flag
+	PreviousValue any `json:"previousValue"`
+}
+
+func (c *WatchCommand) Name() string {
+	return "watch"
+}
+
+func (c *WatchCommand) Synopsis() string {
+	return "Prints a feature flag each time its value changes"
+}
+
+func (c *WatchCommand) Flags() *pflag.FlagSet {
+	flags := pflag.NewFlagSet(c.Name(), pflag.ContinueOnError)
+
+	c.userFlags.addFlags(flags)
+	flags.StringVar(&c.flagType, "type", "bool", "the type of the flag: bool, string, number or json")
+	flags.DurationVar(&c.interval, "interval", 10*time.Second, "how often to check the flag, for backends which cannot be notified of changes")
+	flags.StringVar(&c.onChange, "on-change", "", "a shell command to run each time the flag changes")
+
+	return flags
+}
+
+func (c *WatchCommand) RunContext(ctx context.Context, args []string) error {
+	ctx, span := c.tr.Start(ctx, "run")
+	defer span.End()
+
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("this command takes one to two arguments: flagKey and flagDefault")
+	}
+
+	flagType, err := backends.ParseFlagType(c.flagType)
+	if err != nil {
+		return tracing.Error(span, err)
+	}
+
+	flag := backends.Flag{
+		Key:          args[0],
+		Type:         flagType,
+		DefaultValue: zeroValue(flagType),
+	}
+
+	if len(args) > 1 {
+		if flag.DefaultValue, err = backends.ParseValue(flagType, args[1]); err != nil {
+			return tracing.Errorf(span, "unable to parse the default value as %s: %w", flagType, err)
+		}
+	}
+
+	span.SetAttributes(
+		attribute.String("flag.key", flag.Key),
+		attribute.String("on_change", c.onChange),
+	)
+
+	backend, err := c.createBackend(ctx)
+	if err != nil {
+		return tracing.Error(span, err)
+	}
+	defer backend.Close(ctx)
+
+	user, err := c.createUser(ctx)
+	if err != nil {
+		return tracing.Error(span, err)
+	}
+
+	// watching until interrupted is the normal way to stop
+	tracing.StopSignalHandling()
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	changes, err := backends.Watch(ctx, backend, flag, user, c.interval)
+	if err != nil {
+		return tracing.Error(span, err)
+	}
+
+	first := true
+	var previous any
+
+	for current := range changes {
+		if err := c.print(flagChange{Flag: current, PreviousValue: previous}); err != nil {
+			return tracing.Error(span, err)
+		}
+
+		if !first && c.onChange != "" {
+			if err := c.runHook(ctx, current, previous); err != nil {
+				c.Ui.Error(err.Error())
+			}
+		}
+
+		first = false
+		previous = current.Value
+	}
+
+	return nil
+}
+
+func (c *WatchCommand) runHook(ctx context.Context, flag backends.Flag, previous any) error {
+	ctx, span := c.tr.Start(ctx, "on_change")
+	defer span.End()
+
+	hook := shellCommand(c.onChange)
+	hook.Stdout = c.stdout
+	hook.Stderr = c.stderr
+	hook.Env = append(os.Environ(),
+		WatchKeyEnvVar+"="+flag.Key,
+		WatchOldValueEnvVar+"="+backends.AttributeString(previous),
+		WatchNewValueEnvVar+"="+backends.AttributeString(flag.Value),
+	)
+
+	if traceParent := tracing.TraceParent(ctx); traceParent != "" {
+		hook.Env = append(hook.Env, TraceParentEnvVar+"="+traceParent)
+	}
+
+	if err := hook.Run(); err != nil {
+		return tracing.Errorf(span, "error running %s: %w", c.onChange, err)
+	}
+
+	return nil
+}
+
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+
+	return exec.Command("sh", "-c", command)
+}
@@ -0,0 +1,62 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"flagon/backends/backendtest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatch(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ui := cli.NewMockUi()
+	hookOutput := &bytes.Buffer{}
+
+	cmd, _ := NewWatchCommand(ui)
+	cmd.Meta.testBackend = &backendtest.SequenceBackend{Values: []any{false, false, true, false, false}, OnLast: cancel}
+	cmd.Meta.output = "json"
+	cmd.flagType = "bool"
+	cmd.interval = time.Millisecond
+	cmd.onChange = `echo "$FLAGON_FLAG_KEY $FLAGON_OLD_VALUE $FLAGON_NEW_VALUE"`
+	cmd.stdout = hookOutput
+
+	assert.NoError(t, cmd.RunContext(ctx, []string{"approved"}))
+
+	assert.Equal(t, []string{
+		`{"key":"approved","type":"bool","defaultValue":false,"value":false,"previousValue":null}`,
+		`{"key":"approved","type":"bool","defaultValue":false,"value":true,"previousValue":false}`,
+		`{"key":"approved","type":"bool","defaultValue":false,"value":false,"previousValue":true}`,
+	}, strings.Split(strings.TrimSpace(ui.OutputWriter.String()), "\n"))
+
+	assert.Equal(t, "approved false true\napproved true false\n", hookOutput.String())
+	assert.Equal(t, "", ui.ErrorWriter.String())
+}
+
+func TestWatchHookFailure(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ui := cli.NewMockUi()
+
+	cmd, _ := NewWatchCommand(ui)
+	cmd.Meta.testBackend = &backendtest.SequenceBackend{Values: []any{"one", "two", "two"}, OnLast: cancel}
+	cmd.Meta.output = "template={{ .PreviousValue }} => {{ .Value }}"
+	cmd.flagType = "string"
+	cmd.interval = time.Millisecond
+	cmd.onChange = "exit 3"
+	cmd.stdout = &bytes.Buffer{}
+
+	assert.NoError(t, cmd.RunContext(ctx, []string{"stage", "none"}))
+
+	assert.Equal(t, "<no value> => one\none => two", strings.TrimSpace(ui.OutputWriter.String()))
+	assert.Equal(t, "error running exit 3: exit status 3\n", ui.ErrorWriter.String())
+}
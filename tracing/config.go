@@ -15,6 +15,8 @@ import (
 	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 )
 
+var signals = make(chan os.Signal, 1)
+
 func Configure(ctx context.Context, appName string, exporterConfig *ExporterConfig) (func(ctx context.Context) error, error) {
 
 	exporter, err := createExporter(ctx, exporterConfig)
@@ -34,7 +36,6 @@ func Configure(ctx context.Context, appName string, exporterConfig *ExporterConf
 	otel.SetTracerProvider(tp)
 	otel.SetTextMapPropagator(propagation.TraceContext{})
 
-	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		s := <-signals
@@ -50,3 +51,10 @@ func Configure(ctx context.Context, appName string, exporterConfig *ExporterConf
 
 	return tp.Shutdown, nil
 }
+
+// StopSignalHandling stops the SIGINT/SIGTERM handler installed by Configure,
+// for commands which need to handle signals themselves.  Traces are still
+// flushed by calling the shutdown function returned from Configure.
+func StopSignalHandling() {
+	signal.Stop(signals)
+}
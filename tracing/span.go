@@ -17,7 +17,7 @@ func FromMap[V any](prefix string, m map[string]V) []attribute.KeyValue {
 	attrs := make([]attribute.KeyValue, 0, len(m))
 
 	for k, v := range m {
-		attrs = append(attrs, asAttribute(k, v))
+		attrs = append(attrs, asAttribute(prefix+k, v))
 	}
 
 	return attrs
@@ -21,6 +21,17 @@ func WithTraceParent(ctx context.Context, parent string) context.Context {
 	return prop.Extract(ctx, carrier)
 }
 
+// TraceParent formats the span in the context as a w3c traceparent, or an
+// empty string if there is no span.
+func TraceParent(ctx context.Context) string {
+	carrier := NewCliCarrier()
+
+	prop := otel.GetTextMapPropagator()
+	prop.Inject(ctx, carrier)
+
+	return carrier.Get("traceparent")
+}
+
 type CliCarrier struct {
 	data map[string]string
 }